@@ -5,11 +5,25 @@ import (
 	"flag"
 	"fmt"
 	"github.com/happytobi/cf-puppeteer/cf/utils/env"
+	"github.com/happytobi/cf-puppeteer/journal"
 	"github.com/happytobi/cf-puppeteer/manifest"
+	"github.com/happytobi/cf-puppeteer/smoketest"
 	"os"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
+)
+
+const (
+	//StrategyVenerable renames the current application to <app>-venerable and pushes the new one next to it
+	StrategyVenerable = "venerable"
+	//StrategyBlueGreen pushes the new application as <app>-green with a temporary route before the routes will be switched
+	StrategyBlueGreen = "blue-green"
+	//StrategyCanary starts the new application with one instance on the shared routes and ramps it up step by step
+	StrategyCanary = "canary"
+	//StrategyRolling rolls out a new droplet of the existing application with a deployment of the cloud controller
+	StrategyRolling = "rolling"
 )
 
 //ParserArguments struct where all arguments will be parsed into
@@ -30,11 +44,63 @@ type ParserArguments struct {
 	DockerImage             string
 	DockerUserName          string
 	Manifest                manifest.Manifest
+	ApplicationManifest     manifest.Application
 	LegacyPush              bool
 	NoRoute                 bool
 	AddRoutes               bool
 	NoStart                 bool
-	VarsFile                string
+	VarsFiles               []string
+	OpsFiles                []string
+	Vars                    map[string]string
+	Parallel                int
+	Strategy                string
+	TemporaryRouteDomain    string
+	CanarySteps             []int
+	CanaryInterval          int
+	SmokeTest               smoketest.SmokeTest
+	DryRun                  bool
+	ReportPath              string
+	JournalPath             string
+	JournalLabels           bool
+	KeepReleases            int
+	KeepDroplet             bool
+	//Deadline of the whole deployment, it is only set if -t was passed
+	Deadline     time.Duration
+	Applications []*ParserArguments
+}
+
+//RollbackArguments arguments of the puppeteer-rollback command that puts the venerable application back in place
+type RollbackArguments struct {
+	AppName      string
+	ManifestPath string
+	VarsFiles    []string
+	OpsFiles     []string
+	Vars         map[string]string
+	FailedAction string
+	LegacyPush   bool
+	//Release number of the kept release to roll back to, the venerable application is used if it's 0
+	Release int
+	//Droplet rolls back to the previous droplet of the application instead of another application
+	Droplet bool
+	//Routes out of the manifest, the routes of the current application are used if no manifest was passed
+	Routes []map[string]string
+}
+
+//ValidateArguments arguments of the puppeteer-validate command that checks a manifest without deploying it
+type ValidateArguments struct {
+	ManifestPath string
+	VarsFiles    []string
+	OpsFiles     []string
+	Vars         map[string]string
+	//LegacyPush allows wildcards in the path of the applications
+	LegacyPush bool
+}
+
+//JournalArguments arguments to continue or undo an interrupted deployment out of its journal
+type JournalArguments struct {
+	Resume      bool
+	Rollback    bool
+	JournalPath string
 }
 
 type stringSlice []string
@@ -55,12 +121,42 @@ var (
 	ErrNoManifest = errors.New("a application manifest is required to push an application")
 	//ErrWrongEnvFormat error when env files was not in right format
 	ErrWrongEnvFormat = errors.New("environment variables passed in wrong format, pass the variables like key=value")
+	//ErrWrongOpsFileCombination error when ops files are used with the legacy push that passes the manifest to the cf cli
+	ErrWrongOpsFileCombination = errors.New("--ops-file can't be combined with --legacy-push, the cf cli does not support ops files")
+	//ErrWrongVarFormat error when a variable for the manifest was not in right format
+	ErrWrongVarFormat = errors.New("variables passed in wrong format, pass the variables like --var key=value")
 	//ErrWrongCombination error when legacy push is used with health check options
 	ErrWrongCombination = errors.New("--legacy-push and health check options couldn't be combined")
 	//ErrWrongDockerCombination error when private docker image repo will be pushed without a pass
 	ErrWrongPrivateDockerRepoCombination = errors.New("--docker-username have to be used in combination with env CF_DOCKER_PASSWORD and --docker-image")
+	//ErrNoApplications error when the manifest does not contain any application
+	ErrNoApplications = errors.New("the application manifest does not contain any application")
+	//ErrWrongParallel error when the number of parallel deployments is lower than one
+	ErrWrongParallel = errors.New("--parallel has to be at least 1")
+	//ErrUnknownStrategy error when an unsupported deployment strategy was passed
+	ErrUnknownStrategy = errors.New("unknown deployment strategy, use one of venerable, blue-green, canary or rolling")
+	//ErrWrongStrategyCombination error when the blue-green, canary or rolling strategy is combined with options that skip the route switch
+	ErrWrongStrategyCombination = errors.New("--strategy blue-green, canary or rolling couldn't be combined with --no-route, --no-start or --route-only")
+	//ErrWrongSmokeTestURL error when the blue-green strategy gets an absolute smoke test url, it would call the production route instead of the temporary route
+	ErrWrongSmokeTestURL = errors.New("--strategy blue-green needs a relative --smoke-test-url like /health that is called on the temporary route")
+	//ErrWrongRollingCombination error when the rolling strategy is combined with the legacy push
+	ErrWrongRollingCombination = errors.New("--strategy rolling needs the v3 api and couldn't be combined with --legacy-push")
+	//ErrWrongCanarySteps error when the canary steps are no ascending percentages
+	ErrWrongCanarySteps = errors.New("--canary-steps have to be ascending percentages between 1 and 100 like 10,50,100")
+	//ErrWrongJournalCombination error when --resume and --rollback are passed together or with other options
+	ErrWrongJournalCombination = errors.New("--resume or --rollback can only be combined with --journal")
+	//ErrNoRollbackApplication error when puppeteer-rollback is called without an application name
+	ErrNoRollbackApplication = errors.New("the name of the application to roll back is required: cf puppeteer-rollback <App-Name> [options]")
+	//ErrRollbackApplicationNotInManifest error when the manifest passed to puppeteer-rollback does not contain the application
+	ErrRollbackApplicationNotInManifest = errors.New("the manifest does not contain the application to roll back")
+	//ErrUnknownFailedAction error when an unsupported action for the failed application was passed
+	ErrUnknownFailedAction = errors.New("unknown failed action, use delete or stop")
+	//ErrWrongRollbackCombination error when a rollback to a release and to the previous droplet is requested at once
+	ErrWrongRollbackCombination = errors.New("--droplet couldn't be combined with --release")
+	//ErrWrongKeepReleases error when the kept releases are negative or combined with options that replace the venerable action
+	ErrWrongKeepReleases = errors.New("--keep-releases has to be positive and couldn't be combined with --venerable-action, --no-route, --no-start or --route-only")
 	//Error manifest error when a wildcard was in the path directive
-	ErrNoWildcardSupport = errors.New("wildcard expressions within the path directive in the application manifest are not supported - delete this path directive and pass the artifact path by using the -p option")
+	ErrNoWildcardSupport = manifest.ErrNoWildcardSupport
 )
 
 // ParseArgs parses the command line arguments
@@ -68,6 +164,11 @@ func ParseArgs(args []string) (*ParserArguments, error) {
 	flags := flag.NewFlagSet("zero-downtime-push", flag.ContinueOnError)
 
 	var envs stringSlice
+	var varsFiles stringSlice
+	var opsFiles stringSlice
+	var vars stringSlice
+	var canarySteps string
+	var smokeTestTimeout int
 
 	pta := &ParserArguments{}
 	flags.StringVar(&pta.ManifestPath, "f", "", "path to an application manifest")
@@ -85,7 +186,25 @@ func ParseArgs(args []string) (*ParserArguments, error) {
 	flags.BoolVar(&pta.NoRoute, "no-route", false, "deploy new application without adding routes")
 	flags.BoolVar(&pta.AddRoutes, "route-only", false, "only add routes from manifest to the application")
 	flags.BoolVar(&pta.NoStart, "no-start", false, "don't start application after deployment; venerable action is none")
-	flags.StringVar(&pta.VarsFile, "vars-file", "", "path to a variable substitution file for manifest")
+	flags.Var(&varsFiles, "vars-file", "path to a variable substitution file for manifest; can specify multiple times")
+	flags.Var(&vars, "var", "variable key value pair for variable substitution in the manifest; can specify multiple times")
+	flags.Var(&opsFiles, "ops-file", "path to an ops file with replace and remove operations for the manifest; can specify multiple times")
+	flags.IntVar(&pta.Parallel, "parallel", 1, "number of applications of the manifest that will be deployed in parallel")
+	flags.BoolVar(&pta.DryRun, "dry-run", false, "print the deployment plan without changing anything")
+	flags.StringVar(&pta.ReportPath, "report", "", "path of a json deployment report, - writes the report to stdout and all other output to stderr")
+	flags.StringVar(&pta.JournalPath, "journal", journal.DefaultPath, "path of the journal of completed steps")
+	flags.BoolVar(&pta.JournalLabels, "journal-labels", false, "mirror the progress of the deployment as labels on the applications")
+	flags.BoolVar(&pta.KeepDroplet, "keep-droplet", false, "copy the droplet of the venerable application to the new application for a droplet rollback")
+	flags.IntVar(&pta.KeepReleases, "keep-releases", 0, "number of previous releases that are kept as stopped applications instead of the venerable application")
+	flags.StringVar(&pta.Strategy, "strategy", StrategyVenerable, "deployment strategy venerable, blue-green, canary or rolling - default is venerable")
+	flags.StringVar(&pta.TemporaryRouteDomain, "temporary-route-domain", "", "domain of the temporary route used by the blue-green strategy - default is the domain of the first manifest route")
+	flags.StringVar(&canarySteps, "canary-steps", "10,50,100", "percentage of instances of the new application for each canary step")
+	flags.IntVar(&pta.CanaryInterval, "canary-interval", 60, "seconds to wait between the canary steps")
+	flags.StringVar(&pta.SmokeTest.URL, "smoke-test-url", "", "url or path like /health on the url of the new application that will be called after the new application is routed, before the venerable action")
+	flags.IntVar(&pta.SmokeTest.ExpectedStatus, "smoke-test-status", 200, "expected http status code of the smoke test url")
+	flags.StringVar(&pta.SmokeTest.BodyRegex, "smoke-test-body", "", "regular expression the response body of the smoke test url has to match")
+	flags.StringVar(&pta.SmokeTest.Command, "smoke-test-command", "", "local command that runs as smoke test, the application url is passed in env "+smoketest.AppURLEnv)
+	flags.IntVar(&smokeTestTimeout, "smoke-test-timeout", 60, "timeout of the smoke test in seconds")
 	flags.StringVar(&pta.DockerImage, "docker-image", "", "docker image url")
 	flags.StringVar(&pta.DockerUserName, "docker-username", "", "docker repository username; used with password from env CF_DOCKER_PASSWORD")
 	dockerPass := os.Getenv("CF_DOCKER_PASSWORD")
@@ -113,54 +232,81 @@ func ParseArgs(args []string) (*ParserArguments, error) {
 		return pta, ErrNoManifest
 	}
 
-	//parse manifest
-	parsedManifest, noRouteManifestPath, err := manifest.ParseApplicationManifest(pta.ManifestPath, pta.VarsFile)
-	if err != nil {
-		return pta, err //ErrManifest
+	if pta.Parallel < 1 {
+		return pta, ErrWrongParallel
 	}
 
-	if strings.ContainsAny(parsedManifest.ApplicationManifests[0].Path, "*") && pta.LegacyPush == false {
-		return pta, ErrNoWildcardSupport
+	pta.Strategy = strings.ToLower(pta.Strategy)
+	if pta.Strategy != StrategyVenerable && pta.Strategy != StrategyBlueGreen && pta.Strategy != StrategyCanary && pta.Strategy != StrategyRolling {
+		return pta, ErrUnknownStrategy
 	}
 
-	pta.Manifest = parsedManifest
-	pta.NoRouteManifestPath = noRouteManifestPath
+	if pta.Strategy != StrategyVenerable && (pta.NoRoute || pta.NoStart || pta.AddRoutes) {
+		return pta, ErrWrongStrategyCombination
+	}
 
-	//check if a docker image shouldbe pushed and verify passed args combination
-	if len(pta.DockerUserName) > 0 && (len(dockerPass) == 0 || len(pta.DockerImage) == 0) {
-		return nil, ErrWrongPrivateDockerRepoCombination
+	if pta.Strategy == StrategyRolling && pta.LegacyPush {
+		return pta, ErrWrongRollingCombination
 	}
 
-	//set timeout
-	manifestTimeout, _ := strconv.Atoi(parsedManifest.ApplicationManifests[0].Timeout)
-	if manifestTimeout > 0 && pta.Timeout <= 0 {
-		pta.Timeout = manifestTimeout
-	} else if manifestTimeout <= 0 && pta.Timeout <= 0 {
-		pta.Timeout = 60
+	if pta.Strategy == StrategyBlueGreen && len(pta.SmokeTest.URL) > 0 && !pta.SmokeTest.IsRelative() {
+		return pta, ErrWrongSmokeTestURL
 	}
 
-	//parse first argument as appName
-	pta.AppName = args[1]
-	if noAppNameProvided {
-		pta.AppName = parsedManifest.ApplicationManifests[0].Name
+	if len(opsFiles) > 0 && pta.LegacyPush {
+		return pta, ErrWrongOpsFileCombination
 	}
 
-	//check that health check works without legacy push only
-	if pta.LegacyPush && ((argPassed(flags, "health-check-type") && pta.HealthCheckType != "") || (argPassed(flags, "health-check-http-endpoint") && pta.HealthCheckHTTPEndpoint != "")) {
-		return nil, ErrWrongCombination
+	if pta.KeepReleases < 0 || (pta.KeepReleases > 0 && (argPassed(flags, "venerable-action") || pta.NoRoute || pta.NoStart || pta.AddRoutes)) {
+		return pta, ErrWrongKeepReleases
 	}
 
-	// get health check settings from manifest if nothing else was specified in the command line
-	if argPassed(flags, "health-check-type") == false {
-		if parsedManifest.ApplicationManifests[0].HealthCheckType == "" {
-			pta.HealthCheckType = "port"
-		} else {
-			pta.HealthCheckType = parsedManifest.ApplicationManifests[0].HealthCheckType
+	pta.SmokeTest.Timeout = time.Duration(smokeTestTimeout) * time.Second
+	if argPassed(flags, "t") && pta.Timeout > 0 {
+		pta.Deadline = time.Duration(pta.Timeout) * time.Second
+	}
+
+	pta.CanarySteps, err = parseCanarySteps(canarySteps)
+	if err != nil {
+		return pta, err
+	}
+
+	//parse manifest, the vars files are merged in the passed order and --var overrides them
+	pta.VarsFiles = varsFiles
+	pta.OpsFiles = opsFiles
+	pta.Vars, err = parseVars(vars)
+	if err != nil {
+		return pta, err
+	}
+	variables, err := manifest.LoadVariables(pta.VarsFiles, pta.Vars)
+	if err != nil {
+		return pta, err
+	}
+	parsedManifest, err := manifest.ComposeApplicationManifest(pta.ManifestPath, pta.OpsFiles, variables)
+	if err != nil {
+		return pta, err //ErrManifest
+	}
+
+	if len(parsedManifest.ApplicationManifests) == 0 {
+		return pta, ErrNoApplications
+	}
+
+	for _, application := range parsedManifest.ApplicationManifests {
+		if strings.ContainsAny(application.Path, "*") && pta.LegacyPush == false {
+			return pta, ErrNoWildcardSupport
 		}
+	}
 
+	pta.Manifest = parsedManifest
+
+	//check if a docker image shouldbe pushed and verify passed args combination
+	if len(pta.DockerUserName) > 0 && (len(dockerPass) == 0 || len(pta.DockerImage) == 0) {
+		return nil, ErrWrongPrivateDockerRepoCombination
 	}
-	if pta.HealthCheckHTTPEndpoint == "" {
-		pta.HealthCheckHTTPEndpoint = parsedManifest.ApplicationManifests[0].HealthCheckHTTPEndpoint
+
+	//check that health check works without legacy push only
+	if pta.LegacyPush && ((argPassed(flags, "health-check-type") && pta.HealthCheckType != "") || (argPassed(flags, "health-check-http-endpoint") && pta.HealthCheckHTTPEndpoint != "")) {
+		return nil, ErrWrongCombination
 	}
 
 	//validate envs format
@@ -179,9 +325,251 @@ func ParseArgs(args []string) (*ParserArguments, error) {
 		pta.VenerableAction = "none"
 	}
 
+	//an app name passed as first argument selects the application out of the manifest
+	applications := parsedManifest.ApplicationManifests
+	if noAppNameProvided == false {
+		applications = selectApplication(applications, args[1])
+	}
+
+	for _, application := range applications {
+		applicationArguments, err := parseApplicationArguments(pta, flags, application)
+		if err != nil {
+			return pta, err
+		}
+		pta.Applications = append(pta.Applications, applicationArguments)
+	}
+
+	//keep the first application settings on the top level for single application deployments
+	firstApplication := pta.Applications[0]
+	pta.AppName = firstApplication.AppName
+	pta.Timeout = firstApplication.Timeout
+	pta.HealthCheckType = firstApplication.HealthCheckType
+	pta.HealthCheckHTTPEndpoint = firstApplication.HealthCheckHTTPEndpoint
+	pta.ApplicationManifest = firstApplication.ApplicationManifest
+	pta.NoRouteManifestPath = firstApplication.NoRouteManifestPath
+
 	return pta, nil
 }
 
+//ParseJournalArgs parses the arguments of --resume or --rollback, nil is returned if none of them was passed
+func ParseJournalArgs(args []string) (*JournalArguments, error) {
+	journalCommand := false
+	for _, arg := range args[1:] {
+		name := strings.TrimLeft(arg, "-")
+		if strings.HasPrefix(arg, "-") && (name == "resume" || name == "rollback") {
+			journalCommand = true
+		}
+	}
+	if !journalCommand {
+		return nil, nil
+	}
+
+	journalArguments := &JournalArguments{}
+	flags := flag.NewFlagSet("zero-downtime-push", flag.ContinueOnError)
+	flags.BoolVar(&journalArguments.Resume, "resume", false, "continue an interrupted deployment")
+	flags.BoolVar(&journalArguments.Rollback, "rollback", false, "undo the completed steps of an interrupted deployment")
+	flags.StringVar(&journalArguments.JournalPath, "journal", journal.DefaultPath, "path of the journal of completed steps")
+
+	err := flags.Parse(args[1:])
+	if err != nil {
+		return nil, ErrWrongJournalCombination
+	}
+	if flags.NArg() > 0 || journalArguments.Resume == journalArguments.Rollback {
+		return nil, ErrWrongJournalCombination
+	}
+	return journalArguments, nil
+}
+
+//ParseRollbackArgs parse the arguments of the puppeteer-rollback command, the routes are read out of the manifest if one was passed
+func ParseRollbackArgs(args []string) (*RollbackArguments, error) {
+	if len(args) < 2 || strings.HasPrefix(args[1], "-") {
+		return nil, ErrNoRollbackApplication
+	}
+
+	var varsFiles stringSlice
+	var opsFiles stringSlice
+	var vars stringSlice
+	rollbackArguments := &RollbackArguments{AppName: args[1]}
+	flags := flag.NewFlagSet("puppeteer-rollback", flag.ContinueOnError)
+	flags.StringVar(&rollbackArguments.ManifestPath, "f", "", "path to an application manifest")
+	flags.Var(&varsFiles, "vars-file", "path to a variable substitution file for manifest; can specify multiple times")
+	flags.Var(&vars, "var", "variable key value pair for variable substitution in the manifest; can specify multiple times")
+	flags.Var(&opsFiles, "ops-file", "path to an ops file with replace and remove operations for the manifest; can specify multiple times")
+	flags.StringVar(&rollbackArguments.FailedAction, "failed-action", "delete", "delete or stop the failed application")
+	flags.BoolVar(&rollbackArguments.LegacyPush, "legacy-push", false, "use legacy push instead of new v3 api")
+	flags.IntVar(&rollbackArguments.Release, "release", 0, "number of the kept release to roll back to")
+	flags.BoolVar(&rollbackArguments.Droplet, "droplet", false, "roll back to the previous droplet kept by --keep-droplet")
+
+	err := flags.Parse(args[2:])
+	if err != nil {
+		return nil, err
+	}
+	if flags.NArg() > 0 {
+		return nil, ErrNoArgument
+	}
+
+	if rollbackArguments.Droplet && rollbackArguments.Release > 0 {
+		return nil, ErrWrongRollbackCombination
+	}
+
+	rollbackArguments.FailedAction = strings.ToLower(rollbackArguments.FailedAction)
+	if rollbackArguments.FailedAction != "delete" && rollbackArguments.FailedAction != "stop" {
+		return nil, ErrUnknownFailedAction
+	}
+
+	if len(rollbackArguments.ManifestPath) == 0 {
+		return rollbackArguments, nil
+	}
+	rollbackArguments.VarsFiles = varsFiles
+	rollbackArguments.OpsFiles = opsFiles
+	rollbackArguments.Vars, err = parseVars(vars)
+	if err != nil {
+		return nil, err
+	}
+	variables, err := manifest.LoadVariables(rollbackArguments.VarsFiles, rollbackArguments.Vars)
+	if err != nil {
+		return nil, err
+	}
+	parsedManifest, err := manifest.ComposeApplicationManifest(rollbackArguments.ManifestPath, rollbackArguments.OpsFiles, variables)
+	if err != nil {
+		return nil, err
+	}
+	if len(parsedManifest.ApplicationManifests) == 0 {
+		return nil, ErrNoApplications
+	}
+	//the routes of another application must not be mapped to the rolled back one
+	application, found := findApplication(parsedManifest.ApplicationManifests, rollbackArguments.AppName)
+	if !found {
+		return nil, ErrRollbackApplicationNotInManifest
+	}
+	rollbackArguments.Routes = application.Routes
+	return rollbackArguments, nil
+}
+
+//ParseValidateArgs parse the arguments of the puppeteer-validate command
+func ParseValidateArgs(args []string) (*ValidateArguments, error) {
+	var varsFiles stringSlice
+	var opsFiles stringSlice
+	var vars stringSlice
+	validateArguments := &ValidateArguments{}
+	flags := flag.NewFlagSet("puppeteer-validate", flag.ContinueOnError)
+	flags.StringVar(&validateArguments.ManifestPath, "f", "", "path to an application manifest")
+	flags.Var(&varsFiles, "vars-file", "path to a variable substitution file for manifest; can specify multiple times")
+	flags.Var(&vars, "var", "variable key value pair for variable substitution in the manifest; can specify multiple times")
+	flags.Var(&opsFiles, "ops-file", "path to an ops file with replace and remove operations for the manifest; can specify multiple times")
+	flags.BoolVar(&validateArguments.LegacyPush, "legacy-push", false, "validate the manifest for the legacy push that supports wildcards in the path")
+
+	err := flags.Parse(args[1:])
+	if err != nil {
+		return nil, err
+	}
+	if flags.NArg() > 0 {
+		return nil, ErrNoArgument
+	}
+	if len(validateArguments.ManifestPath) == 0 {
+		return nil, ErrNoManifest
+	}
+
+	validateArguments.VarsFiles = varsFiles
+	validateArguments.OpsFiles = opsFiles
+	validateArguments.Vars, err = parseVars(vars)
+	if err != nil {
+		return nil, err
+	}
+	return validateArguments, nil
+}
+
+//parseVars parse the key=value pairs of --var, a later pair overrides an earlier one with the same key
+func parseVars(vars []string) (map[string]string, error) {
+	parsedVars := make(map[string]string, len(vars))
+	for _, pair := range vars {
+		keyValue := strings.SplitN(pair, "=", 2)
+		if len(keyValue) != 2 || len(strings.TrimSpace(keyValue[0])) == 0 {
+			return nil, ErrWrongVarFormat
+		}
+		parsedVars[strings.TrimSpace(keyValue[0])] = keyValue[1]
+	}
+	return parsedVars, nil
+}
+
+//parseCanarySteps parse the comma separated percentages, the last step is always 100 percent
+func parseCanarySteps(canarySteps string) ([]int, error) {
+	var steps []int
+	for _, step := range strings.Split(canarySteps, ",") {
+		percentage, err := strconv.Atoi(strings.TrimSpace(step))
+		if err != nil || percentage < 1 || percentage > 100 {
+			return nil, ErrWrongCanarySteps
+		}
+		if len(steps) > 0 && percentage <= steps[len(steps)-1] {
+			return nil, ErrWrongCanarySteps
+		}
+		steps = append(steps, percentage)
+	}
+
+	if steps[len(steps)-1] != 100 {
+		steps = append(steps, 100)
+	}
+	return steps, nil
+}
+
+//selectApplication returns the application with the passed name, if no application matches the first one will be
+//deployed under the passed name
+func selectApplication(applications []manifest.Application, appName string) []manifest.Application {
+	application, found := findApplication(applications, appName)
+	if !found {
+		application = applications[0]
+		application.Name = appName
+	}
+	return []manifest.Application{application}
+}
+
+//findApplication returns the application of the manifest with the passed name
+func findApplication(applications []manifest.Application, appName string) (manifest.Application, bool) {
+	for _, application := range applications {
+		if application.Name == appName {
+			return application, true
+		}
+	}
+	return manifest.Application{}, false
+}
+
+//parseApplicationArguments generate the arguments for one application of the manifest
+func parseApplicationArguments(pta *ParserArguments, flags *flag.FlagSet, application manifest.Application) (*ParserArguments, error) {
+	applicationArguments := *pta
+	applicationArguments.Applications = nil
+	applicationArguments.AppName = application.Name
+	applicationArguments.ApplicationManifest = application
+
+	//set timeout
+	manifestTimeout, _ := strconv.Atoi(application.Timeout)
+	if manifestTimeout > 0 && pta.Timeout <= 0 {
+		applicationArguments.Timeout = manifestTimeout
+	} else if manifestTimeout <= 0 && pta.Timeout <= 0 {
+		applicationArguments.Timeout = 60
+	}
+
+	// get health check settings from manifest if nothing else was specified in the command line
+	if argPassed(flags, "health-check-type") == false {
+		if application.HealthCheckType == "" {
+			applicationArguments.HealthCheckType = "port"
+		} else {
+			applicationArguments.HealthCheckType = application.HealthCheckType
+		}
+	}
+	if applicationArguments.HealthCheckHTTPEndpoint == "" {
+		applicationArguments.HealthCheckHTTPEndpoint = application.HealthCheckHTTPEndpoint
+	}
+
+	//every application gets its own manifest without routes so that only this application will be changed
+	noRouteManifestPath, err := manifest.GenerateApplicationNoRouteYml(application)
+	if err != nil {
+		return nil, err
+	}
+	applicationArguments.NoRouteManifestPath = noRouteManifestPath
+
+	return &applicationArguments, nil
+}
+
 //search vor argument in name in passed args
 func argPassed(flags *flag.FlagSet, name string) (found bool) {
 	found = false
@@ -2,6 +2,7 @@ package arguments
 
 import (
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -45,6 +46,7 @@ var _ = Describe("Flag Parsing", func() {
 		Expect(parsedArguments.ShowCrashLogs).To(Equal(false))
 		Expect(parsedArguments.Timeout).To(Equal(120))
 		Expect(parsedArguments.InvocationTimeout).To(Equal(2211))
+		Expect(parsedArguments.Deadline).To(Equal(120 * time.Second))
 		Expect(parsedArguments.Process).To(Equal("process-name"))
 		Expect(parsedArguments.HealthCheckType).To(Equal("http"))
 		Expect(parsedArguments.HealthCheckHTTPEndpoint).To(Equal("/health"))
@@ -132,6 +134,7 @@ var _ = Describe("Flag Parsing", func() {
 		Expect(parsedArguments.VenerableAction).Should(Equal("stop"))
 		Expect(parsedArguments.ShowCrashLogs).To(Equal(true))
 		Expect(parsedArguments.Timeout).To(Equal(60))
+		Expect(parsedArguments.Deadline).To(BeZero())
 		Expect(parsedArguments.InvocationTimeout).To(Equal(-1))
 		Expect(parsedArguments.Process).To(Equal(""))
 		Expect(parsedArguments.HealthCheckType).To(Equal("http"))
@@ -174,7 +177,66 @@ var _ = Describe("Flag Parsing", func() {
 		Expect(parsedArguments.HealthCheckHTTPEndpoint).To(Equal("/foo/bar"))
 		Expect(parsedArguments.AddRoutes).To(Equal(true))
 		Expect(parsedArguments.NoStart).To(Equal(true))
-		Expect(parsedArguments.VarsFile).To(Equal("../fixtures/valid_vars_file.yml"))
+		Expect(parsedArguments.VarsFiles).To(Equal([]string{"../fixtures/valid_vars_file.yml"}))
+	})
+
+	It("merges multiple vars files and vars in order", func() {
+		parsedArguments, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"-f", "../fixtures/manifest_vars.yml",
+				"--vars-file", "../fixtures/valid_vars_file.yml",
+				"--vars-file", "../fixtures/override_vars_file.yml",
+				"--var", "instances=4",
+				"--var", "host=varHost",
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(parsedArguments.VarsFiles).To(Equal([]string{"../fixtures/valid_vars_file.yml", "../fixtures/override_vars_file.yml"}))
+		Expect(parsedArguments.Vars).To(Equal(map[string]string{"instances": "4", "host": "varHost"}))
+		Expect(parsedArguments.ApplicationManifest.Memory).To(Equal("2G"))
+		Expect(parsedArguments.ApplicationManifest.Instances).To(Equal("4"))
+		Expect(parsedArguments.ApplicationManifest.Routes[0]["route"]).To(Equal("varHost.external.test.com"))
+	})
+
+	It("applies ops files to the manifest", func() {
+		parsedArguments, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"-f", "../fixtures/manifest.yml",
+				"--ops-file", "../fixtures/prodOpsFile.yml",
+				"--var", "instances=4",
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(parsedArguments.OpsFiles).To(Equal([]string{"../fixtures/prodOpsFile.yml"}))
+		Expect(parsedArguments.Applications).To(HaveLen(2))
+		Expect(parsedArguments.Applications[0].ApplicationManifest.Instances).To(Equal("4"))
+	})
+
+	It("rejects ops files with legacy push", func() {
+		_, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"-f", "../fixtures/manifest.yml",
+				"--ops-file", "../fixtures/prodOpsFile.yml",
+				"--legacy-push",
+			},
+		)
+		Expect(err).To(MatchError(ErrWrongOpsFileCombination))
+	})
+
+	It("rejects vars in wrong format", func() {
+		_, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"-f", "../fixtures/manifest_vars.yml",
+				"--var", "instances",
+			},
+		)
+		Expect(err).To(MatchError(ErrWrongVarFormat))
 	})
 
 	It("parses args without appName and wrong envs format", func() {
@@ -236,6 +298,211 @@ var _ = Describe("Flag Parsing", func() {
 	})
 })
 
+var _ = Describe("Deployment strategy parsing", func() {
+	It("uses the venerable strategy as default", func() {
+		parsedArguments, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"-f", "../fixtures/manifest.yml",
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parsedArguments.Strategy).To(Equal(StrategyVenerable))
+	})
+
+	It("parses the blue-green strategy", func() {
+		parsedArguments, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"-f", "../fixtures/manifest.yml",
+				"--strategy", "Blue-Green",
+				"--temporary-route-domain", "test.com",
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parsedArguments.Strategy).To(Equal(StrategyBlueGreen))
+		Expect(parsedArguments.TemporaryRouteDomain).To(Equal("test.com"))
+	})
+
+	It("parses the canary steps", func() {
+		parsedArguments, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"-f", "../fixtures/manifest.yml",
+				"--strategy", "canary",
+				"--canary-steps", "10, 50",
+				"--canary-interval", "30",
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parsedArguments.Strategy).To(Equal(StrategyCanary))
+		Expect(parsedArguments.CanarySteps).To(Equal([]int{10, 50, 100}))
+		Expect(parsedArguments.CanaryInterval).To(Equal(30))
+	})
+
+	It("rejects canary steps that are not ascending", func() {
+		_, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"-f", "../fixtures/manifest.yml",
+				"--strategy", "canary",
+				"--canary-steps", "50,10",
+			},
+		)
+		Expect(err).To(MatchError(ErrWrongCanarySteps))
+	})
+
+	It("rejects unknown strategies", func() {
+		_, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"-f", "../fixtures/manifest.yml",
+				"--strategy", "big-bang",
+			},
+		)
+		Expect(err).To(MatchError(ErrUnknownStrategy))
+	})
+
+	It("rejects blue-green without route switch", func() {
+		_, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"-f", "../fixtures/manifest.yml",
+				"--strategy", "blue-green",
+				"--no-route",
+			},
+		)
+		Expect(err).To(MatchError(ErrWrongStrategyCombination))
+	})
+})
+
+var _ = Describe("Smoke test parsing", func() {
+	It("parses the smoke test options", func() {
+		parsedArguments, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"-f", "../fixtures/manifest.yml",
+				"--smoke-test-url", "https://myapp.test.com/health",
+				"--smoke-test-status", "204",
+				"--smoke-test-body", "UP",
+				"--smoke-test-timeout", "5",
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parsedArguments.SmokeTest.Enabled()).To(BeTrue())
+		Expect(parsedArguments.SmokeTest.URL).To(Equal("https://myapp.test.com/health"))
+		Expect(parsedArguments.SmokeTest.ExpectedStatus).To(Equal(204))
+		Expect(parsedArguments.SmokeTest.BodyRegex).To(Equal("UP"))
+		Expect(parsedArguments.SmokeTest.Timeout).To(Equal(5 * time.Second))
+		Expect(parsedArguments.Applications[0].SmokeTest.URL).To(Equal("https://myapp.test.com/health"))
+	})
+
+	It("needs a relative smoke test url for blue-green", func() {
+		_, err := ParseArgs([]string{"zero-downtime-push", "-f", "../fixtures/manifest.yml", "--strategy", "blue-green", "--smoke-test-url", "https://myapp.test.com/health"})
+		Expect(err).To(MatchError(ErrWrongSmokeTestURL))
+
+		parsedArguments, err := ParseArgs([]string{"zero-downtime-push", "-f", "../fixtures/manifest.yml", "--strategy", "blue-green", "--smoke-test-url", "/health"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parsedArguments.SmokeTest.IsRelative()).To(BeTrue())
+	})
+})
+
+var _ = Describe("Multi application manifest parsing", func() {
+	It("parses all applications of the manifest", func() {
+		parsedArguments, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"-f", "../fixtures/multiManifest.yml",
+				"-p", "app-path",
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(len(parsedArguments.Applications)).To(Equal(2))
+		Expect(parsedArguments.AppName).To(Equal("myApp"))
+		Expect(parsedArguments.Applications[0].AppName).To(Equal("myApp"))
+		Expect(parsedArguments.Applications[1].AppName).To(Equal("myApp2"))
+		Expect(parsedArguments.Applications[1].AppPath).To(Equal("app-path"))
+		Expect(parsedArguments.Applications[1].HealthCheckType).To(Equal("http"))
+		Expect(parsedArguments.Applications[0].NoRouteManifestPath).ToNot(Equal(parsedArguments.Applications[1].NoRouteManifestPath))
+	})
+
+	It("parses the number of parallel deployments", func() {
+		parsedArguments, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"-f", "../fixtures/multiManifest.yml",
+				"--parallel", "2",
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parsedArguments.Parallel).To(Equal(2))
+		Expect(parsedArguments.Applications[1].Parallel).To(Equal(2))
+	})
+
+	It("requires at least one parallel deployment", func() {
+		_, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"-f", "../fixtures/multiManifest.yml",
+				"--parallel", "0",
+			},
+		)
+		Expect(err).To(MatchError(ErrWrongParallel))
+	})
+
+	It("parses the dry run option", func() {
+		parsedArguments, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"-f", "../fixtures/multiManifest.yml",
+				"--dry-run",
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parsedArguments.DryRun).To(BeTrue())
+		Expect(parsedArguments.Applications[1].DryRun).To(BeTrue())
+	})
+
+	It("parses the report path", func() {
+		parsedArguments, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"-f", "../fixtures/multiManifest.yml",
+				"--report", "report.json",
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parsedArguments.ReportPath).To(Equal("report.json"))
+	})
+
+	It("selects the application by the passed app name", func() {
+		parsedArguments, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"myApp2",
+				"-f", "../fixtures/multiManifest.yml",
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(len(parsedArguments.Applications)).To(Equal(1))
+		Expect(parsedArguments.AppName).To(Equal("myApp2"))
+		Expect(parsedArguments.Applications[0].AppName).To(Equal("myApp2"))
+	})
+
+	It("deploys the first application with the passed app name if it is not part of the manifest", func() {
+		parsedArguments, err := ParseArgs(
+			[]string{
+				"zero-downtime-push",
+				"appname",
+				"-f", "../fixtures/multiManifest.yml",
+			},
+		)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(len(parsedArguments.Applications)).To(Equal(1))
+		Expect(parsedArguments.Applications[0].AppName).To(Equal("appname"))
+	})
+})
+
 var _ = Describe("Deprecated flag parsing", func() {
 	It("deprecated argument test", func() {
 
@@ -276,4 +543,91 @@ var _ = Describe("Deprecated flag parsing", func() {
 		Expect(err).ToNot(HaveOccurred())
 		Expect(arg.Manifest.ApplicationManifests[0].Path).To(Equal(""))
 	})
+
+	It("parses --resume with the journal path", func() {
+		journalArguments, err := ParseJournalArgs([]string{"zero-downtime-push", "--resume", "--journal", "journal.json"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(journalArguments.Resume).To(BeTrue())
+		Expect(journalArguments.Rollback).To(BeFalse())
+		Expect(journalArguments.JournalPath).To(Equal("journal.json"))
+	})
+
+	It("returns no journal arguments for a deployment", func() {
+		journalArguments, err := ParseJournalArgs([]string{"zero-downtime-push", "-f", "../fixtures/manifest.yml"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(journalArguments).To(BeNil())
+	})
+
+	It("does not combine --rollback with other options", func() {
+		_, err := ParseJournalArgs([]string{"zero-downtime-push", "--rollback", "-f", "../fixtures/manifest.yml"})
+		Expect(err).To(MatchError(ErrWrongJournalCombination))
+
+		_, err = ParseJournalArgs([]string{"zero-downtime-push", "--rollback", "--resume"})
+		Expect(err).To(MatchError(ErrWrongJournalCombination))
+	})
+	It("parses the rollback arguments with the routes out of the manifest", func() {
+		rollbackArguments, err := ParseRollbackArgs([]string{"puppeteer-rollback", "myApp", "-f", "../fixtures/manifest.yml", "--failed-action", "stop"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rollbackArguments.AppName).To(Equal("myApp"))
+		Expect(rollbackArguments.FailedAction).To(Equal("stop"))
+		Expect(rollbackArguments.Routes).ToNot(BeEmpty())
+
+		_, err = ParseRollbackArgs([]string{"puppeteer-rollback", "otherApp", "-f", "../fixtures/manifest.yml"})
+		Expect(err).To(MatchError(ErrRollbackApplicationNotInManifest))
+	})
+
+	It("needs an application name and a known failed action for a rollback", func() {
+		_, err := ParseRollbackArgs([]string{"puppeteer-rollback", "-f", "../fixtures/manifest.yml"})
+		Expect(err).To(MatchError(ErrNoRollbackApplication))
+
+		rollbackArguments, err := ParseRollbackArgs([]string{"puppeteer-rollback", "myApp"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rollbackArguments.FailedAction).To(Equal("delete"))
+		Expect(rollbackArguments.Routes).To(BeEmpty())
+
+		_, err = ParseRollbackArgs([]string{"puppeteer-rollback", "myApp", "--failed-action", "none"})
+		Expect(err).To(MatchError(ErrUnknownFailedAction))
+	})
+	It("keeps releases only instead of a venerable action", func() {
+		parsedArguments, err := ParseArgs([]string{"zero-downtime-push", "-f", "../fixtures/manifest.yml", "--keep-releases", "3"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parsedArguments.KeepReleases).To(Equal(3))
+		Expect(parsedArguments.Applications[0].KeepReleases).To(Equal(3))
+
+		_, err = ParseArgs([]string{"zero-downtime-push", "-f", "../fixtures/manifest.yml", "--keep-releases", "3", "--venerable-action", "stop"})
+		Expect(err).To(MatchError(ErrWrongKeepReleases))
+
+		_, err = ParseArgs([]string{"zero-downtime-push", "-f", "../fixtures/manifest.yml", "--keep-releases", "-1"})
+		Expect(err).To(MatchError(ErrWrongKeepReleases))
+	})
+	It("rolls back to the previous droplet or to a release", func() {
+		rollbackArguments, err := ParseRollbackArgs([]string{"puppeteer-rollback", "myApp", "--droplet"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rollbackArguments.Droplet).To(BeTrue())
+
+		_, err = ParseRollbackArgs([]string{"puppeteer-rollback", "myApp", "--droplet", "--release", "3"})
+		Expect(err).To(MatchError(ErrWrongRollbackCombination))
+	})
+	It("parses the rolling strategy that needs the v3 api", func() {
+		parsedArguments, err := ParseArgs([]string{"zero-downtime-push", "-f", "../fixtures/manifest.yml", "--strategy", "rolling"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(parsedArguments.Strategy).To(Equal(StrategyRolling))
+
+		_, err = ParseArgs([]string{"zero-downtime-push", "-f", "../fixtures/manifest.yml", "--strategy", "rolling", "--legacy-push"})
+		Expect(err).To(MatchError(ErrWrongRollingCombination))
+	})
+	It("parses the arguments of the validate command", func() {
+		validateArguments, err := ParseValidateArgs([]string{"puppeteer-validate", "-f", "../fixtures/manifest.yml", "--vars-file", "../fixtures/vars.yml", "--var", "instances=2", "--legacy-push"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(validateArguments.ManifestPath).To(Equal("../fixtures/manifest.yml"))
+		Expect(validateArguments.VarsFiles).To(Equal([]string{"../fixtures/vars.yml"}))
+		Expect(validateArguments.Vars).To(Equal(map[string]string{"instances": "2"}))
+		Expect(validateArguments.LegacyPush).To(BeTrue())
+
+		_, err = ParseValidateArgs([]string{"puppeteer-validate"})
+		Expect(err).To(MatchError(ErrNoManifest))
+
+		_, err = ParseValidateArgs([]string{"puppeteer-validate", "-f", "../fixtures/manifest.yml", "myApp"})
+		Expect(err).To(MatchError(ErrNoArgument))
+	})
 })
@@ -0,0 +1,108 @@
+package ccv3
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+//App v3 application resource
+type App struct {
+	GUID      string `json:"guid"`
+	Name      string `json:"name"`
+	State     string `json:"state"`
+	Lifecycle struct {
+		Type string `json:"type"`
+	} `json:"lifecycle"`
+	Relationships struct {
+		Space Relationship `json:"space"`
+	} `json:"relationships"`
+	Metadata Metadata `json:"metadata"`
+}
+
+type appsResponse struct {
+	Pagination Pagination `json:"pagination"`
+	Resources  []App      `json:"resources"`
+}
+
+//GetApp returns the application with the name in the space
+func (client *Client) GetApp(ctx context.Context, appName string, spaceGUID string) (*App, error) {
+	var response appsResponse
+	path := fmt.Sprintf("/v3/apps?names=%s&space_guids=%s", url.QueryEscape(appName), url.QueryEscape(spaceGUID))
+	err := client.get(ctx, path, &response)
+	if err != nil {
+		return nil, err
+	}
+	if len(response.Resources) == 0 {
+		return nil, ErrAppNotFound
+	}
+	return &response.Resources[0], nil
+}
+
+//ListApps returns all applications of the space, all pages are read
+func (client *Client) ListApps(ctx context.Context, spaceGUID string) ([]App, error) {
+	var apps []App
+	path := fmt.Sprintf("/v3/apps?space_guids=%s&per_page=5000", url.QueryEscape(spaceGUID))
+	for len(path) > 0 {
+		var response appsResponse
+		err := client.get(ctx, path, &response)
+		if err != nil {
+			return nil, err
+		}
+		apps = append(apps, response.Resources...)
+
+		path = ""
+		if response.Pagination.Next != nil {
+			path = response.Pagination.Next.Href
+		}
+	}
+	return apps, nil
+}
+
+//CreateApp creates an application in the space, docker applications need the docker lifecycle
+func (client *Client) CreateApp(ctx context.Context, appName string, spaceGUID string, docker bool) (*App, error) {
+	body := map[string]interface{}{
+		"name": appName,
+		"relationships": map[string]interface{}{
+			"space": NewRelationship(spaceGUID),
+		},
+	}
+	if docker {
+		body["lifecycle"] = map[string]interface{}{
+			"type": "docker",
+			"data": map[string]interface{}{},
+		}
+	}
+
+	var app App
+	err := client.post(ctx, "/v3/apps", body, &app)
+	if err != nil {
+		return nil, err
+	}
+	return &app, nil
+}
+
+//UpdateAppEnvironmentVariables adds the environment variables to the application
+func (client *Client) UpdateAppEnvironmentVariables(ctx context.Context, appGUID string, envs map[string]string) error {
+	body := map[string]interface{}{
+		"var": envs,
+	}
+	return client.patch(ctx, fmt.Sprintf("/v3/apps/%s/environment_variables", appGUID), body, nil)
+}
+
+//UpdateAppMetadata sets labels and annotations of the application
+func (client *Client) UpdateAppMetadata(ctx context.Context, appGUID string, metadata Metadata) error {
+	body := map[string]interface{}{
+		"metadata": metadata,
+	}
+	return client.patch(ctx, fmt.Sprintf("/v3/apps/%s", appGUID), body, nil)
+}
+
+//ApplyManifest applies the manifest to the space and waits until the job is complete
+func (client *Client) ApplyManifest(ctx context.Context, spaceGUID string, manifest []byte) error {
+	jobURL, err := client.http.PostYaml(ctx, fmt.Sprintf("/v3/spaces/%s/actions/apply_manifest", spaceGUID), manifest)
+	if err != nil {
+		return err
+	}
+	return client.PollJob(ctx, jobURL)
+}
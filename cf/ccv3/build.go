@@ -0,0 +1,70 @@
+package ccv3
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+//Build v3 build resource that stages a package into a droplet
+type Build struct {
+	GUID    string `json:"guid"`
+	State   string `json:"state"`
+	Error   string `json:"error"`
+	Droplet *struct {
+		GUID string `json:"guid"`
+	} `json:"droplet"`
+}
+
+//CreateBuild starts staging of the package
+func (client *Client) CreateBuild(ctx context.Context, packageGUID string) (*Build, error) {
+	body := map[string]interface{}{
+		"package": map[string]string{"guid": packageGUID},
+	}
+
+	var build Build
+	err := client.post(ctx, "/v3/builds", body, &build)
+	if err != nil {
+		return nil, err
+	}
+	return &build, nil
+}
+
+//GetBuild returns the build with the guid
+func (client *Client) GetBuild(ctx context.Context, buildGUID string) (*Build, error) {
+	var build Build
+	err := client.get(ctx, fmt.Sprintf("/v3/builds/%s", buildGUID), &build)
+	if err != nil {
+		return nil, err
+	}
+	return &build, nil
+}
+
+//WaitForBuild waits until the build is staged, onPoll is called after every status request
+func (client *Client) WaitForBuild(ctx context.Context, buildGUID string, onPoll func()) (*Build, error) {
+	deadline := time.Now().Add(client.PollTimeout)
+	for {
+		build, err := client.GetBuild(ctx, buildGUID)
+		if err != nil {
+			return nil, err
+		}
+		if onPoll != nil {
+			onPoll()
+		}
+
+		switch build.State {
+		case "STAGED":
+			return build, nil
+		case "FAILED":
+			return nil, fmt.Errorf("staging failed: %s", build.Error)
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("build %s is not staged in time, state is %s", buildGUID, build.State)
+		}
+		err = Wait(ctx, PollInterval)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package ccv3
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"code.cloudfoundry.org/cli/plugin"
+	"github.com/happytobi/cf-puppeteer/cf/cli"
+)
+
+var (
+	//ErrAppNotFound error when no application with the name exists in the space
+	ErrAppNotFound = errors.New("application not found")
+	//ErrRouteNotFound error when the route does not exist
+	ErrRouteNotFound = errors.New("route not found")
+	//ErrDomainNotFound error when the domain does not exist
+	ErrDomainNotFound = errors.New("domain not found")
+)
+
+//PollInterval time between two status requests of asynchronous resources like jobs, packages and builds
+var PollInterval = 2 * time.Second
+
+//Client typed client for the cloud controller v3 api
+type Client struct {
+	http        cli.HttpCalls
+	PollTimeout time.Duration
+}
+
+//NewClient constructor
+func NewClient(httpCalls cli.HttpCalls) *Client {
+	return &Client{
+		http:        httpCalls,
+		PollTimeout: 15 * time.Minute,
+	}
+}
+
+//NewClientFromConnection constructor that uses the api endpoint, token and ssl settings of the cli connection
+func NewClientFromConnection(conn plugin.CliConnection, traceLogging bool) *Client {
+	skipSSLValidation, err := conn.IsSSLDisabled()
+	if err != nil {
+		skipSSLValidation = false
+	}
+	return NewClient(cli.NewHttpClient(conn, traceLogging, 30, skipSSLValidation))
+}
+
+//Relationship to another resource
+type Relationship struct {
+	Data struct {
+		GUID string `json:"guid"`
+	} `json:"data"`
+}
+
+//NewRelationship creates a relationship to the resource with the guid
+func NewRelationship(guid string) Relationship {
+	relationship := Relationship{}
+	relationship.Data.GUID = guid
+	return relationship
+}
+
+//Link to another resource
+type Link struct {
+	Href string `json:"href"`
+}
+
+//Pagination of list responses
+type Pagination struct {
+	TotalResults int   `json:"total_results"`
+	TotalPages   int   `json:"total_pages"`
+	Next         *Link `json:"next"`
+}
+
+//Metadata labels and annotations of a resource
+type Metadata struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+//Job asynchronous operation of the cloud controller
+type Job struct {
+	GUID      string                           `json:"guid"`
+	Operation string                           `json:"operation"`
+	State     string                           `json:"state"`
+	Errors    []cli.CloudControllerErrorDetail `json:"errors"`
+}
+
+func (client *Client) get(ctx context.Context, path string, result interface{}) error {
+	response, err := client.http.GetJSON(ctx, path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(response), result)
+}
+
+func (client *Client) post(ctx context.Context, path string, body interface{}, result interface{}) error {
+	requestBody, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	response, err := client.http.PostJSON(ctx, path, requestBody)
+	if err != nil {
+		return err
+	}
+	if result == nil || len(response) == 0 {
+		return nil
+	}
+	return json.Unmarshal([]byte(response), result)
+}
+
+func (client *Client) patch(ctx context.Context, path string, body interface{}, result interface{}) error {
+	requestBody, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	response, err := client.http.PatchJSON(ctx, path, requestBody)
+	if err != nil {
+		return err
+	}
+	if result == nil || len(response) == 0 {
+		return nil
+	}
+	return json.Unmarshal([]byte(response), result)
+}
+
+//Wait sleeps for the duration, it returns the error of the context if the context is done before
+func Wait(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+//PollJob waits until the job is complete, a failed job returns its errors
+func (client *Client) PollJob(ctx context.Context, jobURL string) error {
+	if len(jobURL) == 0 {
+		return nil
+	}
+
+	deadline := time.Now().Add(client.PollTimeout)
+	for {
+		var job Job
+		err := client.get(ctx, jobURL, &job)
+		if err != nil {
+			return err
+		}
+
+		switch job.State {
+		case "COMPLETE":
+			return nil
+		case "FAILED":
+			return &cli.CloudControllerError{Method: "JOB", Path: jobURL, Errors: job.Errors}
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("job %s %s did not finish in time, state is %s", job.GUID, job.Operation, job.State)
+		}
+		err = Wait(ctx, PollInterval)
+		if err != nil {
+			return err
+		}
+	}
+}
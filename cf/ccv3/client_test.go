@@ -0,0 +1,249 @@
+package ccv3_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"code.cloudfoundry.org/cli/plugin/pluginfakes"
+	"github.com/happytobi/cf-puppeteer/cf/ccv3"
+	"github.com/happytobi/cf-puppeteer/cf/cli"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestCcv3Client(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Puppeteer Cloud Controller v3 Client")
+}
+
+var _ = Describe("ccv3 client test", func() {
+	var (
+		cliConn      *pluginfakes.FakeCliConnection
+		client       *ccv3.Client
+		server       *httptest.Server
+		handler      http.HandlerFunc
+		requestPaths []string
+	)
+
+	BeforeEach(func() {
+		requestPaths = nil
+		ccv3.PollInterval = time.Millisecond
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestPaths = append(requestPaths, r.Method+" "+r.URL.RequestURI())
+			handler(w, r)
+		}))
+		cliConn = &pluginfakes.FakeCliConnection{}
+		cliConn.ApiEndpointReturns(server.URL, nil)
+		cliConn.AccessTokenReturns("bearer token", nil)
+		client = ccv3.NewClient(cli.NewHttpClient(cliConn, false, 30, false))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("GetApp", func() {
+		It("returns the app of the space", func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.Header.Get("Authorization")).To(Equal("bearer token"))
+				_, _ = w.Write([]byte(`{"resources": [{"guid": "app-guid", "name": "my-app", "state": "STARTED"}]}`))
+			}
+			app, err := client.GetApp(context.Background(), "my-app", "space-guid")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(app.GUID).To(Equal("app-guid"))
+			Expect(requestPaths).To(Equal([]string{"GET /v3/apps?names=my-app&space_guids=space-guid"}))
+		})
+
+		It("returns ErrAppNotFound", func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"resources": []}`))
+			}
+			_, err := client.GetApp(context.Background(), "my-app", "space-guid")
+
+			Expect(err).To(Equal(ccv3.ErrAppNotFound))
+		})
+	})
+
+	Describe("ListApps", func() {
+		It("reads all pages of the apps of the space", func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Query().Get("page") == "2" {
+					_, _ = w.Write([]byte(`{"pagination": {"next": null}, "resources": [{"guid": "app-guid-2", "name": "my-app-v1"}]}`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"pagination": {"next": {"href": "` + server.URL + `/v3/apps?space_guids=space-guid&page=2"}}, "resources": [{"guid": "app-guid", "name": "my-app"}]}`))
+			}
+			apps, err := client.ListApps(context.Background(), "space-guid")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(apps).To(HaveLen(2))
+			Expect(apps[1].Name).To(Equal("my-app-v1"))
+			Expect(requestPaths).To(Equal([]string{"GET /v3/apps?space_guids=space-guid&per_page=5000", "GET /v3/apps?space_guids=space-guid&page=2"}))
+		})
+	})
+
+	Describe("CopyDroplet", func() {
+		It("copies the droplet to the app and waits until it is staged", func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == "POST" {
+					_, _ = w.Write([]byte(`{"guid": "copy-guid", "state": "COPYING"}`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"guid": "copy-guid", "state": "STAGED"}`))
+			}
+			droplet, err := client.CopyDroplet(context.Background(), "droplet-guid", "app-guid")
+			Expect(err).ToNot(HaveOccurred())
+			droplet, err = client.WaitForDroplet(context.Background(), droplet.GUID)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(droplet.State).To(Equal("STAGED"))
+			Expect(requestPaths).To(Equal([]string{"POST /v3/droplets?source_guid=droplet-guid", "GET /v3/droplets/copy-guid"}))
+		})
+	})
+
+	Describe("WaitForDeployment", func() {
+		It("polls the deployment until it is deployed", func() {
+			polls := 0
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				polls++
+				if polls < 2 {
+					_, _ = w.Write([]byte(`{"guid": "deployment-guid", "state": "DEPLOYING", "status": {"value": "ACTIVE", "reason": "DEPLOYING"}}`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"guid": "deployment-guid", "state": "DEPLOYED", "status": {"value": "FINALIZED", "reason": "DEPLOYED"}}`))
+			}
+			_, err := client.WaitForDeployment(context.Background(), "deployment-guid", nil)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(polls).To(Equal(2))
+		})
+
+		It("fails when the deployment was canceled", func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"guid": "deployment-guid", "state": "CANCELED", "status": {"value": "FINALIZED", "reason": "CANCELED"}}`))
+			}
+			_, err := client.WaitForDeployment(context.Background(), "deployment-guid", nil)
+
+			Expect(err).To(MatchError(ContainSubstring("CANCELED")))
+		})
+	})
+
+	Describe("Quotas", func() {
+		It("returns the quota of the space with unlimited values as nil", func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"resources": [{"guid": "quota-guid", "name": "small", "apps": {"total_memory_in_mb": 2048, "total_instances": null}}]}`))
+			}
+			quota, err := client.GetSpaceQuota(context.Background(), "space-guid")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*quota.Apps.TotalMemoryInMB).To(Equal(2048))
+			Expect(quota.Apps.TotalInstances).To(BeNil())
+			Expect(requestPaths).To(Equal([]string{"GET /v3/space_quotas?space_guids=space-guid"}))
+		})
+
+		It("returns nil if the space has no quota", func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"resources": []}`))
+			}
+			quota, err := client.GetSpaceQuota(context.Background(), "space-guid")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(quota).To(BeNil())
+		})
+
+		It("returns the usage of the organization", func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"usage_summary": {"started_instances": 3, "memory_in_mb": 1536}}`))
+			}
+			usage, err := client.GetOrganizationUsage(context.Background(), "org-guid")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*usage).To(Equal(ccv3.UsageSummary{StartedInstances: 3, MemoryInMB: 1536}))
+			Expect(requestPaths).To(Equal([]string{"GET /v3/organizations/org-guid/usage_summary"}))
+		})
+	})
+
+	Describe("ApplyManifest", func() {
+		It("polls the job until it is complete", func() {
+			polls := 0
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == "POST" {
+					Expect(r.Header.Get("Content-Type")).To(Equal("application/x-yaml"))
+					w.Header().Set("Location", "http://"+r.Host+"/v3/jobs/job-guid")
+					w.WriteHeader(http.StatusAccepted)
+					return
+				}
+				polls++
+				if polls < 2 {
+					_, _ = w.Write([]byte(`{"guid": "job-guid", "state": "PROCESSING"}`))
+					return
+				}
+				_, _ = w.Write([]byte(`{"guid": "job-guid", "state": "COMPLETE"}`))
+			}
+			err := client.ApplyManifest(context.Background(), "space-guid", []byte("applications: []"))
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(requestPaths).To(Equal([]string{
+				"POST /v3/spaces/space-guid/actions/apply_manifest",
+				"GET /v3/jobs/job-guid",
+				"GET /v3/jobs/job-guid",
+			}))
+		})
+
+		It("returns the errors of a failed job", func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == "POST" {
+					w.Header().Set("Location", "http://"+r.Host+"/v3/jobs/job-guid")
+					w.WriteHeader(http.StatusAccepted)
+					return
+				}
+				_, _ = w.Write([]byte(`{"guid": "job-guid", "state": "FAILED", "errors": [{"code": 10008, "title": "CF-UnprocessableEntity", "detail": "invalid manifest"}]}`))
+			}
+			err := client.ApplyManifest(context.Background(), "space-guid", []byte("applications: []"))
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("invalid manifest"))
+		})
+	})
+
+	Describe("Routes", func() {
+		It("unmap route destination", func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			}
+			err := client.UnmapRoute(context.Background(), "route-guid", "destination-guid")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(requestPaths).To(Equal([]string{"DELETE /v3/routes/route-guid/destinations/destination-guid"}))
+		})
+
+		It("returns ErrRouteNotFound", func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"resources": []}`))
+			}
+			_, err := client.GetRoute(context.Background(), "domain-guid", "host", "")
+
+			Expect(err).To(Equal(ccv3.ErrRouteNotFound))
+		})
+	})
+
+	Describe("MatchResources", func() {
+		It("match resources in batches", func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"resources": [{"checksum": {"value": "sha"}, "size_in_bytes": 1, "path": "file", "mode": "644"}]}`))
+			}
+			resources := make([]ccv3.Resource, 1500)
+			matched, err := client.MatchResources(context.Background(), resources)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(requestPaths).To(Equal([]string{"POST /v3/resource_matches", "POST /v3/resource_matches"}))
+			Expect(matched).To(HaveLen(2))
+			Expect(matched[0].Path).To(Equal("file"))
+		})
+	})
+})
@@ -0,0 +1,102 @@
+package ccv3
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+//Deployment v3 deployment resource that replaces the instances of an application with a new droplet
+type Deployment struct {
+	GUID   string `json:"guid"`
+	State  string `json:"state"`
+	Status struct {
+		Value  string `json:"value"`
+		Reason string `json:"reason"`
+	} `json:"status"`
+	Droplet struct {
+		GUID string `json:"guid"`
+	} `json:"droplet"`
+}
+
+//deployed returns true if all instances run the droplet of the deployment
+func (deployment *Deployment) deployed() bool {
+	if len(deployment.Status.Value) > 0 {
+		return deployment.Status.Value == "FINALIZED" && deployment.Status.Reason == "DEPLOYED"
+	}
+	//cloud controllers before the status field only know the state
+	return deployment.State == "DEPLOYED"
+}
+
+//failed returns true if the deployment was canceled or superseded by another deployment
+func (deployment *Deployment) failed() bool {
+	if len(deployment.Status.Value) > 0 {
+		return deployment.Status.Value == "FINALIZED" && deployment.Status.Reason != "DEPLOYED"
+	}
+	return deployment.State == "CANCELED"
+}
+
+//CreateDeployment starts a rolling deployment of the droplet, the current droplet of the application is used if
+//dropletGUID is empty
+func (client *Client) CreateDeployment(ctx context.Context, appGUID string, dropletGUID string) (*Deployment, error) {
+	body := map[string]interface{}{
+		"strategy": "rolling",
+		"relationships": map[string]interface{}{
+			"app": NewRelationship(appGUID),
+		},
+	}
+	if len(dropletGUID) > 0 {
+		body["droplet"] = map[string]string{"guid": dropletGUID}
+	}
+
+	var deployment Deployment
+	err := client.post(ctx, "/v3/deployments", body, &deployment)
+	if err != nil {
+		return nil, err
+	}
+	return &deployment, nil
+}
+
+//GetDeployment returns the deployment with the guid
+func (client *Client) GetDeployment(ctx context.Context, deploymentGUID string) (*Deployment, error) {
+	var deployment Deployment
+	err := client.get(ctx, fmt.Sprintf("/v3/deployments/%s", deploymentGUID), &deployment)
+	if err != nil {
+		return nil, err
+	}
+	return &deployment, nil
+}
+
+//CancelDeployment stops the deployment and rolls the application back to the droplet it had before
+func (client *Client) CancelDeployment(ctx context.Context, deploymentGUID string) error {
+	return client.post(ctx, fmt.Sprintf("/v3/deployments/%s/actions/cancel", deploymentGUID), map[string]interface{}{}, nil)
+}
+
+//WaitForDeployment waits until all instances run the droplet of the deployment, onPoll is called after every status request
+func (client *Client) WaitForDeployment(ctx context.Context, deploymentGUID string, onPoll func(deployment *Deployment)) (*Deployment, error) {
+	deadline := time.Now().Add(client.PollTimeout)
+	for {
+		deployment, err := client.GetDeployment(ctx, deploymentGUID)
+		if err != nil {
+			return nil, err
+		}
+		if onPoll != nil {
+			onPoll(deployment)
+		}
+
+		if deployment.deployed() {
+			return deployment, nil
+		}
+		if deployment.failed() {
+			return nil, fmt.Errorf("deployment %s did not finish, status is %s %s", deploymentGUID, deployment.Status.Reason, deployment.State)
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("deployment %s did not finish in time, state is %s", deploymentGUID, deployment.State)
+		}
+		err = Wait(ctx, PollInterval)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
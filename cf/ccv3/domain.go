@@ -0,0 +1,38 @@
+package ccv3
+
+import "context"
+
+//Domain v3 domain resource
+type Domain struct {
+	GUID     string `json:"guid"`
+	Name     string `json:"name"`
+	Internal bool   `json:"internal"`
+}
+
+//DomainPage one page of the domain list
+type DomainPage struct {
+	Pagination Pagination `json:"pagination"`
+	Resources  []Domain   `json:"resources"`
+}
+
+//GetDomainPage returns one page of domains, start with /v3/domains and follow Pagination.Next
+func (client *Client) GetDomainPage(ctx context.Context, path string) (*DomainPage, error) {
+	var page DomainPage
+	err := client.get(ctx, path, &page)
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+//GetDomain returns the domain with the name
+func (client *Client) GetDomain(ctx context.Context, domainName string) (*Domain, error) {
+	page, err := client.GetDomainPage(ctx, "/v3/domains?names="+domainName)
+	if err != nil {
+		return nil, err
+	}
+	if len(page.Resources) == 0 {
+		return nil, ErrDomainNotFound
+	}
+	return &page.Resources[0], nil
+}
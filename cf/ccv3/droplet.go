@@ -0,0 +1,85 @@
+package ccv3
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+//Droplet v3 droplet resource, the staged result of a build
+type Droplet struct {
+	GUID  string `json:"guid"`
+	State string `json:"state"`
+	Error string `json:"error"`
+}
+
+//SetCurrentDroplet sets the droplet that will be used when the application starts
+func (client *Client) SetCurrentDroplet(ctx context.Context, appGUID string, dropletGUID string) error {
+	body := map[string]interface{}{
+		"data": map[string]string{"guid": dropletGUID},
+	}
+	return client.patch(ctx, fmt.Sprintf("/v3/apps/%s/relationships/current_droplet", appGUID), body, nil)
+}
+
+//GetCurrentDroplet returns the current droplet of the application
+func (client *Client) GetCurrentDroplet(ctx context.Context, appGUID string) (*Droplet, error) {
+	var droplet Droplet
+	err := client.get(ctx, fmt.Sprintf("/v3/apps/%s/droplets/current", appGUID), &droplet)
+	if err != nil {
+		return nil, err
+	}
+	return &droplet, nil
+}
+
+//GetDroplet returns the droplet with the guid
+func (client *Client) GetDroplet(ctx context.Context, dropletGUID string) (*Droplet, error) {
+	var droplet Droplet
+	err := client.get(ctx, fmt.Sprintf("/v3/droplets/%s", dropletGUID), &droplet)
+	if err != nil {
+		return nil, err
+	}
+	return &droplet, nil
+}
+
+//CopyDroplet copies the droplet to the application, the copy is done when WaitForDroplet returns
+func (client *Client) CopyDroplet(ctx context.Context, dropletGUID string, appGUID string) (*Droplet, error) {
+	body := map[string]interface{}{
+		"relationships": map[string]interface{}{
+			"app": NewRelationship(appGUID),
+		},
+	}
+
+	var droplet Droplet
+	err := client.post(ctx, "/v3/droplets?source_guid="+url.QueryEscape(dropletGUID), body, &droplet)
+	if err != nil {
+		return nil, err
+	}
+	return &droplet, nil
+}
+
+//WaitForDroplet waits until the droplet is staged or copied
+func (client *Client) WaitForDroplet(ctx context.Context, dropletGUID string) (*Droplet, error) {
+	deadline := time.Now().Add(client.PollTimeout)
+	for {
+		droplet, err := client.GetDroplet(ctx, dropletGUID)
+		if err != nil {
+			return nil, err
+		}
+
+		switch droplet.State {
+		case "STAGED":
+			return droplet, nil
+		case "FAILED", "EXPIRED":
+			return nil, fmt.Errorf("droplet %s is %s: %s", dropletGUID, droplet.State, droplet.Error)
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("droplet %s is not staged in time, state is %s", dropletGUID, droplet.State)
+		}
+		err = Wait(ctx, PollInterval)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package ccv3
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//ErrNoLogCache error when the cloud controller does not provide a log cache
+var ErrNoLogCache = errors.New("no log cache available")
+
+//LogMessage single log line of an application
+type LogMessage struct {
+	Timestamp  int64
+	SourceType string
+	Message    string
+}
+
+type rootResponse struct {
+	Links struct {
+		LogCache *Link `json:"log_cache"`
+	} `json:"links"`
+}
+
+type logCacheResponse struct {
+	Envelopes struct {
+		Batch []struct {
+			Timestamp string            `json:"timestamp"`
+			Tags      map[string]string `json:"tags"`
+			Log       *struct {
+				Payload string `json:"payload"`
+			} `json:"log"`
+		} `json:"batch"`
+	} `json:"envelopes"`
+}
+
+//GetLogCacheURL returns the url of the log cache out of the root links
+func (client *Client) GetLogCacheURL(ctx context.Context) (string, error) {
+	var response rootResponse
+	err := client.get(ctx, "/", &response)
+	if err != nil {
+		return "", err
+	}
+	if response.Links.LogCache == nil || len(response.Links.LogCache.Href) == 0 {
+		return "", ErrNoLogCache
+	}
+	return strings.TrimSuffix(response.Links.LogCache.Href, "/"), nil
+}
+
+//ReadLogs returns the log messages of the source (application guid) since the start time in nanoseconds
+func (client *Client) ReadLogs(ctx context.Context, logCacheURL string, sourceGUID string, startTime int64) ([]LogMessage, error) {
+	var response logCacheResponse
+	err := client.get(ctx, fmt.Sprintf("%s/api/v1/read/%s?envelope_types=LOG&start_time=%d", logCacheURL, sourceGUID, startTime), &response)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []LogMessage
+	for _, envelope := range response.Envelopes.Batch {
+		if envelope.Log == nil {
+			continue
+		}
+		timestamp, err := strconv.ParseInt(envelope.Timestamp, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		payload, err := base64.StdEncoding.DecodeString(envelope.Log.Payload)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, LogMessage{
+			Timestamp:  timestamp,
+			SourceType: envelope.Tags["source_type"],
+			Message:    strings.TrimRight(string(payload), "\n"),
+		})
+	}
+
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].Timestamp < messages[j].Timestamp
+	})
+	return messages, nil
+}
@@ -0,0 +1,144 @@
+package ccv3
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"time"
+)
+
+//Package v3 package resource that contains the application bits or docker image
+type Package struct {
+	GUID  string `json:"guid"`
+	Type  string `json:"type"`
+	State string `json:"state"`
+}
+
+//DockerData image and credentials of a docker package
+type DockerData struct {
+	Image    string `json:"image"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+//CreatePackage creates a bits package for the application, a docker package if the docker image is set
+func (client *Client) CreatePackage(ctx context.Context, appGUID string, docker *DockerData) (*Package, error) {
+	body := map[string]interface{}{
+		"type": "bits",
+		"relationships": map[string]interface{}{
+			"app": NewRelationship(appGUID),
+		},
+	}
+	if docker != nil {
+		body["type"] = "docker"
+		body["data"] = docker
+	}
+
+	var createdPackage Package
+	err := client.post(ctx, "/v3/packages", body, &createdPackage)
+	if err != nil {
+		return nil, err
+	}
+	return &createdPackage, nil
+}
+
+//GetPackage returns the package with the guid
+func (client *Client) GetPackage(ctx context.Context, packageGUID string) (*Package, error) {
+	var appPackage Package
+	err := client.get(ctx, fmt.Sprintf("/v3/packages/%s", packageGUID), &appPackage)
+	if err != nil {
+		return nil, err
+	}
+	return &appPackage, nil
+}
+
+//UploadPackageBits uploads the zipped application bits with the size to the package, progress is called while uploading.
+//Matched resources are cached by the cloud controller and will not be part of the bits, bits can be nil if all files were matched
+func (client *Client) UploadPackageBits(ctx context.Context, packageGUID string, matched []Resource, bits io.Reader, size int64, progress func(uploaded int64, total int64)) error {
+	if matched == nil {
+		matched = []Resource{}
+	}
+	resources, err := json.Marshal(matched)
+	if err != nil {
+		return err
+	}
+
+	var part bytes.Buffer
+	writer := multipart.NewWriter(&part)
+	err = writer.WriteField("resources", string(resources))
+	if err != nil {
+		return err
+	}
+	if bits == nil {
+		err = writer.Close()
+		if err != nil {
+			return err
+		}
+		_, err = client.http.PostFormData(ctx, fmt.Sprintf("/v3/packages/%s/upload", packageGUID), &part, int64(part.Len()), writer.FormDataContentType())
+		return err
+	}
+
+	_, err = writer.CreateFormFile("bits", "application.zip")
+	if err != nil {
+		return err
+	}
+	header := append([]byte(nil), part.Bytes()...)
+
+	part.Reset()
+	err = writer.Close()
+	if err != nil {
+		return err
+	}
+	trailer := part.Bytes()
+
+	body := io.MultiReader(bytes.NewReader(header), &progressReader{reader: bits, total: size, progress: progress}, bytes.NewReader(trailer))
+	contentLength := int64(len(header)) + size + int64(len(trailer))
+	_, err = client.http.PostFormData(ctx, fmt.Sprintf("/v3/packages/%s/upload", packageGUID), body, contentLength, writer.FormDataContentType())
+	return err
+}
+
+//WaitForPackage waits until the package is ready to be staged
+func (client *Client) WaitForPackage(ctx context.Context, packageGUID string) (*Package, error) {
+	deadline := time.Now().Add(client.PollTimeout)
+	for {
+		appPackage, err := client.GetPackage(ctx, packageGUID)
+		if err != nil {
+			return nil, err
+		}
+
+		switch appPackage.State {
+		case "READY":
+			return appPackage, nil
+		case "FAILED", "EXPIRED":
+			return nil, fmt.Errorf("package %s is %s", packageGUID, appPackage.State)
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("package %s is not ready in time, state is %s", packageGUID, appPackage.State)
+		}
+		err = Wait(ctx, PollInterval)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+//progressReader calls progress with the bytes read so far
+type progressReader struct {
+	reader   io.Reader
+	read     int64
+	total    int64
+	progress func(uploaded int64, total int64)
+}
+
+func (reader *progressReader) Read(p []byte) (int, error) {
+	n, err := reader.reader.Read(p)
+	reader.read += int64(n)
+	if n > 0 && reader.progress != nil {
+		reader.progress(reader.read, reader.total)
+	}
+	return n, err
+}
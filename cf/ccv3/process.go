@@ -0,0 +1,47 @@
+package ccv3
+
+import (
+	"context"
+	"fmt"
+)
+
+//Process v3 process resource
+type Process struct {
+	GUID        string      `json:"guid"`
+	Type        string      `json:"type"`
+	Instances   int         `json:"instances"`
+	MemoryInMB  int         `json:"memory_in_mb"`
+	DiskInMB    int         `json:"disk_in_mb"`
+	HealthCheck HealthCheck `json:"health_check"`
+}
+
+//HealthCheck of a process
+type HealthCheck struct {
+	Type string          `json:"type"`
+	Data HealthCheckData `json:"data"`
+}
+
+//HealthCheckData settings of the health check, only set values will be changed
+type HealthCheckData struct {
+	Timeout           *int    `json:"timeout,omitempty"`
+	InvocationTimeout *int    `json:"invocation_timeout,omitempty"`
+	Endpoint          *string `json:"endpoint,omitempty"`
+}
+
+//GetAppProcess returns the process of the application with the type like web or worker
+func (client *Client) GetAppProcess(ctx context.Context, appGUID string, processType string) (*Process, error) {
+	var process Process
+	err := client.get(ctx, fmt.Sprintf("/v3/apps/%s/processes/%s", appGUID, processType), &process)
+	if err != nil {
+		return nil, err
+	}
+	return &process, nil
+}
+
+//UpdateProcessHealthCheck changes the health check of the process
+func (client *Client) UpdateProcessHealthCheck(ctx context.Context, processGUID string, healthCheck HealthCheck) error {
+	body := map[string]interface{}{
+		"health_check": healthCheck,
+	}
+	return client.patch(ctx, fmt.Sprintf("/v3/processes/%s", processGUID), body, nil)
+}
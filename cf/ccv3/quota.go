@@ -0,0 +1,72 @@
+package ccv3
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+//Quota v3 space or organization quota, limits that are not set are nil
+type Quota struct {
+	GUID string `json:"guid"`
+	Name string `json:"name"`
+	Apps struct {
+		TotalMemoryInMB *int `json:"total_memory_in_mb"`
+		TotalInstances  *int `json:"total_instances"`
+	} `json:"apps"`
+}
+
+type quotasResponse struct {
+	Resources []Quota `json:"resources"`
+}
+
+//UsageSummary memory and instances of the started applications of a space or organization
+type UsageSummary struct {
+	StartedInstances int `json:"started_instances"`
+	MemoryInMB       int `json:"memory_in_mb"`
+}
+
+type usageSummaryResponse struct {
+	UsageSummary UsageSummary `json:"usage_summary"`
+}
+
+//GetSpaceQuota returns the quota that is assigned to the space or nil if the space has no quota
+func (client *Client) GetSpaceQuota(ctx context.Context, spaceGUID string) (*Quota, error) {
+	return client.getQuota(ctx, fmt.Sprintf("/v3/space_quotas?space_guids=%s", url.QueryEscape(spaceGUID)))
+}
+
+//GetOrganizationQuota returns the quota of the organization or nil if the organization has no quota
+func (client *Client) GetOrganizationQuota(ctx context.Context, orgGUID string) (*Quota, error) {
+	return client.getQuota(ctx, fmt.Sprintf("/v3/organization_quotas?organization_guids=%s", url.QueryEscape(orgGUID)))
+}
+
+func (client *Client) getQuota(ctx context.Context, path string) (*Quota, error) {
+	var response quotasResponse
+	err := client.get(ctx, path, &response)
+	if err != nil {
+		return nil, err
+	}
+	if len(response.Resources) == 0 {
+		return nil, nil
+	}
+	return &response.Resources[0], nil
+}
+
+//GetSpaceUsage returns the memory and instances the started applications of the space use
+func (client *Client) GetSpaceUsage(ctx context.Context, spaceGUID string) (*UsageSummary, error) {
+	return client.getUsageSummary(ctx, fmt.Sprintf("/v3/spaces/%s/usage_summary", spaceGUID))
+}
+
+//GetOrganizationUsage returns the memory and instances the started applications of the organization use
+func (client *Client) GetOrganizationUsage(ctx context.Context, orgGUID string) (*UsageSummary, error) {
+	return client.getUsageSummary(ctx, fmt.Sprintf("/v3/organizations/%s/usage_summary", orgGUID))
+}
+
+func (client *Client) getUsageSummary(ctx context.Context, path string) (*UsageSummary, error) {
+	var response usageSummaryResponse
+	err := client.get(ctx, path, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response.UsageSummary, nil
+}
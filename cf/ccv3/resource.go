@@ -0,0 +1,42 @@
+package ccv3
+
+import "context"
+
+//resourceMatchBatchSize maximum number of resources of one resource match request
+const resourceMatchBatchSize = 1000
+
+//Resource application file that is identified by its checksum
+type Resource struct {
+	Checksum    Checksum `json:"checksum"`
+	SizeInBytes int64    `json:"size_in_bytes"`
+	Path        string   `json:"path"`
+	Mode        string   `json:"mode"`
+}
+
+//Checksum sha1 of a resource
+type Checksum struct {
+	Value string `json:"value"`
+}
+
+type resourcesRequest struct {
+	Resources []Resource `json:"resources"`
+}
+
+//MatchResources returns the resources that are already cached by the cloud controller and don't have to be uploaded
+func (client *Client) MatchResources(ctx context.Context, resources []Resource) ([]Resource, error) {
+	matched := []Resource{}
+	for start := 0; start < len(resources); start += resourceMatchBatchSize {
+		end := start + resourceMatchBatchSize
+		if end > len(resources) {
+			end = len(resources)
+		}
+
+		var response resourcesRequest
+		err := client.post(ctx, "/v3/resource_matches", resourcesRequest{Resources: resources[start:end]}, &response)
+		if err != nil {
+			return nil, err
+		}
+		matched = append(matched, response.Resources...)
+	}
+	return matched, nil
+}
@@ -0,0 +1,105 @@
+package ccv3
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+//Route v3 route resource
+type Route struct {
+	GUID         string        `json:"guid"`
+	Host         string        `json:"host"`
+	Path         string        `json:"path"`
+	URL          string        `json:"url"`
+	Destinations []Destination `json:"destinations"`
+}
+
+//Destination of a route
+type Destination struct {
+	GUID string `json:"guid"`
+	App  struct {
+		GUID string `json:"guid"`
+	} `json:"app"`
+}
+
+type routesResponse struct {
+	Pagination Pagination `json:"pagination"`
+	Resources  []Route    `json:"resources"`
+}
+
+type destinationsResponse struct {
+	Destinations []Destination `json:"destinations"`
+}
+
+//GetRoute returns the route with host and path of the domain
+func (client *Client) GetRoute(ctx context.Context, domainGUID string, host string, path string) (*Route, error) {
+	var response routesResponse
+	requestPath := fmt.Sprintf("/v3/routes?domain_guids=%s&hosts=%s&paths=%s", url.QueryEscape(domainGUID), url.QueryEscape(host), url.QueryEscape(path))
+	err := client.get(ctx, requestPath, &response)
+	if err != nil {
+		return nil, err
+	}
+	if len(response.Resources) == 0 {
+		return nil, ErrRouteNotFound
+	}
+	return &response.Resources[0], nil
+}
+
+//CreateRoute creates the route in the space
+func (client *Client) CreateRoute(ctx context.Context, spaceGUID string, domainGUID string, host string, path string) (*Route, error) {
+	body := map[string]interface{}{
+		"host": host,
+		"relationships": map[string]interface{}{
+			"space":  NewRelationship(spaceGUID),
+			"domain": NewRelationship(domainGUID),
+		},
+	}
+	if len(path) > 0 {
+		body["path"] = path
+	}
+
+	var route Route
+	err := client.post(ctx, "/v3/routes", body, &route)
+	if err != nil {
+		return nil, err
+	}
+	return &route, nil
+}
+
+//MapRoute adds the application as destination of the route
+func (client *Client) MapRoute(ctx context.Context, routeGUID string, appGUID string) error {
+	destination := map[string]interface{}{
+		"app": map[string]string{"guid": appGUID},
+	}
+	body := map[string]interface{}{
+		"destinations": []interface{}{destination},
+	}
+	return client.post(ctx, fmt.Sprintf("/v3/routes/%s/destinations", routeGUID), body, nil)
+}
+
+//GetRouteDestinations returns all destinations of the route
+func (client *Client) GetRouteDestinations(ctx context.Context, routeGUID string) ([]Destination, error) {
+	var response destinationsResponse
+	err := client.get(ctx, fmt.Sprintf("/v3/routes/%s/destinations", routeGUID), &response)
+	if err != nil {
+		return nil, err
+	}
+	return response.Destinations, nil
+}
+
+//UnmapRoute removes the destination from the route
+func (client *Client) UnmapRoute(ctx context.Context, routeGUID string, destinationGUID string) error {
+	_, err := client.http.Delete(ctx, fmt.Sprintf("/v3/routes/%s/destinations/%s", routeGUID, destinationGUID))
+	return err
+}
+
+//GetAppRoutes returns the routes of the application
+func (client *Client) GetAppRoutes(ctx context.Context, appGUID string) ([]Route, error) {
+	var response routesResponse
+	err := client.get(ctx, fmt.Sprintf("/v3/apps/%s/routes", appGUID), &response)
+	if err != nil {
+		return nil, err
+	}
+	return response.Resources, nil
+}
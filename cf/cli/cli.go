@@ -1,6 +1,8 @@
 package cli
 
 import (
+	"context"
+	"encoding/json"
 	"github.com/happytobi/cf-puppeteer/cf/utils/print"
 	"strings"
 
@@ -10,9 +12,9 @@ import (
 
 //Calls cli curl calls
 type Calls interface {
-	GetJSON(path string) (string, error)
-	PostJSON(path string, jsonBody string) (string, error)
-	PatchJSON(path string, jsonBody string) (string, error)
+	GetJSON(ctx context.Context, path string) (string, error)
+	PostJSON(ctx context.Context, path string, jsonBody string) (string, error)
+	PatchJSON(ctx context.Context, path string, jsonBody string) (string, error)
 }
 
 //Connection cli connection object
@@ -29,32 +31,71 @@ func NewCli(conn plugin.CliConnection, traceLogging bool) *Connection {
 	}
 }
 
+//HttpCli calls the cloud controller with the http client instead of cf curl, the calls don't block the rpc server
+//of the cf cli and are canceled when the context is done
+type HttpCli struct {
+	http HttpCalls
+}
+
+//NewHttpCli constructor that uses the api endpoint, token and ssl settings of the cli connection
+func NewHttpCli(conn plugin.CliConnection, traceLogging bool) *HttpCli {
+	skipSSLValidation, err := conn.IsSSLDisabled()
+	if err != nil {
+		skipSSLValidation = false
+	}
+	return &HttpCli{
+		http: NewHttpClient(conn, traceLogging, 30, skipSSLValidation),
+	}
+}
+
+//GetJSON make an get call to an url
+func (httpCli *HttpCli) GetJSON(ctx context.Context, path string) (string, error) {
+	return httpCli.http.GetJSON(ctx, path)
+}
+
+//PostJSON post to path with json body
+func (httpCli *HttpCli) PostJSON(ctx context.Context, path string, jsonBody string) (string, error) {
+	return httpCli.http.PostJSON(ctx, path, []byte(jsonBody))
+}
+
+//PatchJSON patch to path with json body
+func (httpCli *HttpCli) PatchJSON(ctx context.Context, path string, jsonBody string) (string, error) {
+	return httpCli.http.PatchJSON(ctx, path, []byte(jsonBody))
+}
+
 //GetJSON make an get call to an url
-func (conn *Connection) GetJSON(path string) (string, error) {
-	result, err := conn.cf.CliCommandWithoutTerminalOutput("curl", path, "-X", "GET", "-H", "Content-type: application/json")
+func (conn *Connection) GetJSON(ctx context.Context, path string) (string, error) {
+	result, err := conn.curl(ctx, "curl", path, "-X", "GET", "-H", "Content-type: application/json")
 	if err != nil {
-		ui.Failed("error while calling %s - error: %s", path, err)
+		//the error is not printed here, a transient error is retried and the final error is reported by the caller
+		ui.DebugMessage("error while calling %s - error: %s", path, err)
 		return "", err
 	}
 
 	jsonResp := strings.Join(result, "")
 
 	if conn.traceLogging {
-		ui.Say("response from GET call - path: %s was: %s %s", path, jsonResp, print.PrettyJSON(jsonResp))
+		ui.Say("response from GET call - path: %s was: %s", path, print.PrettyJSON(jsonResp))
+	}
+
+	//the router answers with plain text and the cloud controller with an error code while it is not available
+	curlError := &CurlError{Path: path, Body: jsonResp}
+	if json.Unmarshal([]byte(jsonResp), curlError) != nil || len(curlError.ErrorCode) > 0 {
+		return "", curlError
 	}
 
 	return jsonResp, nil
 }
 
 //PostJSON post to path with json body
-func (conn *Connection) PostJSON(path string, jsonBody string) (string, error) {
+func (conn *Connection) PostJSON(ctx context.Context, path string, jsonBody string) (string, error) {
 	args := []string{"curl", path, "-X", "POST", "-H", "Content-type: application/json"}
 	if jsonBody != "" {
 		bodyArgs := []string{"-d", jsonBody}
 		args = append(args, bodyArgs...)
 	}
 
-	result, err := conn.cf.CliCommandWithoutTerminalOutput(args...)
+	result, err := conn.curl(ctx, args...)
 	if err != nil {
 		return "", err
 	}
@@ -67,10 +108,10 @@ func (conn *Connection) PostJSON(path string, jsonBody string) (string, error) {
 }
 
 //PatchJSON post to path with json body
-func (conn *Connection) PatchJSON(path string, jsonBody string) (string, error) {
+func (conn *Connection) PatchJSON(ctx context.Context, path string, jsonBody string) (string, error) {
 	args := []string{"curl", path, "-X", "PATCH", "-H", "Content-type: application/json", "-d", jsonBody}
 
-	result, err := conn.cf.CliCommandWithoutTerminalOutput(args...)
+	result, err := conn.curl(ctx, args...)
 	if err != nil {
 		return "", err
 	}
@@ -80,3 +121,34 @@ func (conn *Connection) PatchJSON(path string, jsonBody string) (string, error)
 	}
 	return jsonResp, nil
 }
+
+//curl runs the cf curl command, the rpc call of the cli can't be canceled so it will be abandoned when the context is done
+func (conn *Connection) curl(ctx context.Context, args ...string) ([]string, error) {
+	return WithContext(ctx, func() ([]string, error) {
+		return conn.cf.CliCommandWithoutTerminalOutput(args...)
+	})
+}
+
+//WithContext runs a cli rpc call and returns the error of the context if it is done before the call returns
+func WithContext(ctx context.Context, call func() ([]string, error)) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	type callResult struct {
+		output []string
+		err    error
+	}
+	done := make(chan callResult, 1)
+	go func() {
+		output, err := call()
+		done <- callResult{output: output, err: err}
+	}()
+
+	select {
+	case result := <-done:
+		return result.output, result.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+)
+
+//CurlError error response of a cf curl call, cf curl does not fail on error responses
+type CurlError struct {
+	Path        string
+	ErrorCode   string `json:"error_code"`
+	Description string `json:"description"`
+	Body        string
+}
+
+func (curlError *CurlError) Error() string {
+	if len(curlError.ErrorCode) > 0 {
+		return fmt.Sprintf("GET %s failed - %s: %s", curlError.Path, curlError.ErrorCode, curlError.Description)
+	}
+	return fmt.Sprintf("GET %s failed with unexpected response: %s", curlError.Path, curlError.Body)
+}
+
+//transientStatusCodes status codes the cloud controller and the router return while the cloud controller is not available
+var transientStatusCodes = map[int]bool{
+	502: true,
+	503: true,
+	504: true,
+}
+
+//transientMessage matches the errors of cf cli commands and cf curl responses of an unavailable cloud controller
+var transientMessage = regexp.MustCompile(`(?i)(status code:? 50[234]|response code:? 50[234]|bad ?gateway|service ?unavailable|gateway ?timeout|connection refused|connection reset by peer|i/o timeout|tls handshake timeout)`)
+
+//IsTransientError returns true if the error was caused by a cloud controller that was not available for a moment, like while it gets upgraded
+func IsTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+	//a deadline or cancellation of the deployment is never retried, even if the http client reports it as timeout
+	if urlError, ok := err.(*url.Error); ok && (urlError.Err == context.Canceled || urlError.Err == context.DeadlineExceeded) {
+		return false
+	}
+	if err == context.Canceled || err == context.DeadlineExceeded {
+		return false
+	}
+	if ccError, ok := err.(*CloudControllerError); ok {
+		return transientStatusCodes[ccError.StatusCode]
+	}
+	if netError, ok := err.(net.Error); ok && netError.Timeout() {
+		return true
+	}
+	return transientMessage.MatchString(err.Error())
+}
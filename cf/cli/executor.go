@@ -1,17 +1,23 @@
 package cli
 
 import (
+	"context"
+	"github.com/happytobi/cf-puppeteer/ui"
+	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
 )
 
 type CfExecutor interface {
-	Execute(arguments []string) (err error)
+	Execute(ctx context.Context, arguments []string) (err error)
 }
 
 //HttpConnection
 type Executor struct {
 	traceLogging bool
+	outputPrefix string
+	showOutput   bool
 }
 
 func NewExecutor(traceLogging bool) CfExecutor {
@@ -20,23 +26,54 @@ func NewExecutor(traceLogging bool) CfExecutor {
 	}
 }
 
-func (ec Executor) Execute(arguments []string) (err error) {
+//NewPrefixedExecutor executor that prints every output line of the cf cli with the passed prefix
+func NewPrefixedExecutor(traceLogging bool, outputPrefix string) CfExecutor {
+	return Executor{
+		traceLogging: traceLogging,
+		outputPrefix: outputPrefix,
+	}
+}
+
+//NewOutputExecutor executor that prints the output of the cf cli with the passed prefix also without trace logging,
+//used for commands like cf start or cf logs whose output is part of the deployment
+func NewOutputExecutor(traceLogging bool, outputPrefix string) CfExecutor {
+	return Executor{
+		traceLogging: traceLogging,
+		outputPrefix: outputPrefix,
+		showOutput:   true,
+	}
+}
+
+//Execute runs the cf cli with the arguments, the process will be killed when the context is done
+func (ec Executor) Execute(ctx context.Context, arguments []string) (err error) {
 	cfCmdToolPath, err := exec.LookPath("cf")
 	if err != nil {
 		return err
 	}
 
-	outChannel := os.NewFile(0, os.DevNull)
-	if ec.traceLogging {
-		outChannel = os.Stdout
+	var outChannel io.Writer = ioutil.Discard
+	if ec.traceLogging || ec.showOutput {
+		outChannel = ui.Output()
 	}
+	var errChannel io.Writer = os.Stderr
 
-	cmd := exec.Cmd{
-		Path:   cfCmdToolPath,
-		Args:   append([]string{cfCmdToolPath}, arguments...),
-		Stdout: outChannel,
-		Stderr: os.Stderr,
+	//every command gets its own writers so parallel executions don't share any state
+	if len(ec.outputPrefix) > 0 {
+		prefixedOut := ui.NewPrefixWriter(ec.outputPrefix, outChannel)
+		prefixedErr := ui.NewPrefixWriter(ec.outputPrefix, errChannel)
+		defer prefixedOut.Flush()
+		defer prefixedErr.Flush()
+		outChannel = prefixedOut
+		errChannel = prefixedErr
 	}
 
-	return cmd.Run()
+	cmd := exec.CommandContext(ctx, cfCmdToolPath, arguments...)
+	cmd.Stdout = outChannel
+	cmd.Stderr = errChannel
+
+	err = cmd.Run()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
 }
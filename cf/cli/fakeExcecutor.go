@@ -1,17 +1,25 @@
 package cli
 
+import (
+	"context"
+	"sync"
+)
+
 //Test struct for executro
 type FakeExecutor struct {
-	traceLogging bool
-	counter int
+	traceLogging    bool
+	counter         int
 	argumentsOutput map[int][]string
+	mutex           sync.Mutex
 }
 
 func (tx *FakeExecutor) NewFakeExecutor() CfExecutor {
 	return tx
 }
 
-func (tx *FakeExecutor) Execute(arguments []string) (err error) {
+func (tx *FakeExecutor) Execute(ctx context.Context, arguments []string) (err error) {
+	tx.mutex.Lock()
+	defer tx.mutex.Unlock()
 	tx.counter++
 	size := len(tx.argumentsOutput)
 	if tx.argumentsOutput == nil {
@@ -23,10 +31,13 @@ func (tx *FakeExecutor) Execute(arguments []string) (err error) {
 	return nil
 }
 func (tx *FakeExecutor) ExecutorCallCount() int {
+	tx.mutex.Lock()
+	defer tx.mutex.Unlock()
 	return tx.counter
 }
 
 func (tx *FakeExecutor) ExecutorArgumentsOutput() map[int][]string {
+	tx.mutex.Lock()
+	defer tx.mutex.Unlock()
 	return tx.argumentsOutput
 }
-
@@ -3,19 +3,54 @@ package cli
 import (
 	"bytes"
 	"code.cloudfoundry.org/cli/plugin"
+	"context"
 	"crypto/tls"
+	"encoding/json"
+	"fmt"
 	"github.com/happytobi/cf-puppeteer/cf/utils/print"
 	"github.com/happytobi/cf-puppeteer/ui"
+	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
+	"strings"
 	"time"
 )
 
 //Calls interface
 type HttpCalls interface {
-	PostJSON(path string, body []byte) (string, error)
-	PostFormData(path string, body []byte, contentType string) (string, error)
+	GetJSON(ctx context.Context, path string) (string, error)
+	PostJSON(ctx context.Context, path string, body []byte) (string, error)
+	PatchJSON(ctx context.Context, path string, body []byte) (string, error)
+	Delete(ctx context.Context, path string) (jobURL string, err error)
+	PostYaml(ctx context.Context, path string, body []byte) (jobURL string, err error)
+	PostFormData(ctx context.Context, path string, body io.Reader, size int64, contentType string) (string, error)
+}
+
+//CloudControllerError error response of the cloud controller, contains the cc error codes
+type CloudControllerError struct {
+	StatusCode int
+	Method     string
+	Path       string
+	Errors     []CloudControllerErrorDetail `json:"errors"`
+}
+
+//CloudControllerErrorDetail single error of a cloud controller error response
+type CloudControllerErrorDetail struct {
+	Code   int    `json:"code"`
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+}
+
+func (ccError *CloudControllerError) Error() string {
+	if len(ccError.Errors) == 0 {
+		return fmt.Sprintf("%s %s failed with status code %d", ccError.Method, ccError.Path, ccError.StatusCode)
+	}
+	var details []string
+	for _, detail := range ccError.Errors {
+		details = append(details, fmt.Sprintf("%s (%d): %s", detail.Title, detail.Code, detail.Detail))
+	}
+	return fmt.Sprintf("%s %s failed with status code %d - %s", ccError.Method, ccError.Path, ccError.StatusCode, strings.Join(details, "; "))
 }
 
 //HttpConnection
@@ -25,9 +60,15 @@ type HttpConnection struct {
 	traceLogging  bool
 }
 
+//httpResponse body and location header of a cloud controller response
+type httpResponse struct {
+	body     string
+	location string
+}
+
 //NewHttpClient ff
 func NewHttpClient(cliConnection plugin.CliConnection, traceLogging bool, timeout int, skipSSLValidation bool) *HttpConnection {
-	timeoutDuration, _ := time.ParseDuration(string(timeout))
+	timeoutDuration := time.Duration(timeout) * time.Second
 
 	return &HttpConnection{
 		cliConnection: cliConnection,
@@ -52,92 +93,139 @@ func setupHttpClient(timout time.Duration, skipSSLValidation bool) *http.Client
 	return &http.Client{Transport: tr}
 }
 
-func (conn *HttpConnection) PostFormData(path string, body []byte, contentType string) (string, error) {
-	request, err := http.NewRequest(
-		"POST",
-		path,
-		bytes.NewReader(body),
-	)
+//GetJSON make an get call to an url
+func (conn *HttpConnection) GetJSON(ctx context.Context, path string) (string, error) {
+	response, err := conn.request(ctx, "GET", path, nil, "")
+	if err != nil {
+		return "", err
+	}
+	return response.body, nil
+}
 
+//PostJSON post to path with json body
+func (conn *HttpConnection) PostJSON(ctx context.Context, path string, body []byte) (string, error) {
+	response, err := conn.request(ctx, "POST", path, bytes.NewReader(body), "application/json")
 	if err != nil {
 		return "", err
 	}
+	return response.body, nil
+}
 
-	//get access token from cli connection
-	token, _ := conn.cliConnection.AccessToken()
+//PatchJSON patch to path with json body
+func (conn *HttpConnection) PatchJSON(ctx context.Context, path string, body []byte) (string, error) {
+	response, err := conn.request(ctx, "PATCH", path, bytes.NewReader(body), "application/json")
+	if err != nil {
+		return "", err
+	}
+	return response.body, nil
+}
 
-	request.Header = http.Header{}
-	request.Header.Set("Accept", "application/json")
-	request.Header.Set("User-Agent", "client.userAgent")
-	request.Header.Set("Content-Type", contentType)
-	request.Header.Set("Authorization", token)
+//Delete resource of the path, returns the url of the job if the resource will be deleted asynchronous
+func (conn *HttpConnection) Delete(ctx context.Context, path string) (string, error) {
+	response, err := conn.request(ctx, "DELETE", path, nil, "")
+	if err != nil {
+		return "", err
+	}
+	return response.location, nil
+}
 
-	if conn.traceLogging {
-		ui.Say("try to call path: %s", path)
+//PostYaml post yaml body to path (used by apply manifest), returns the url of the created job
+func (conn *HttpConnection) PostYaml(ctx context.Context, path string, body []byte) (string, error) {
+	response, err := conn.request(ctx, "POST", path, bytes.NewReader(body), "application/x-yaml")
+	if err != nil {
+		return "", err
 	}
+	return response.location, nil
+}
 
-	res, err := conn.httpClient.Do(request)
+//PostFormData post multipart form data with the size to path, the body will be streamed
+func (conn *HttpConnection) PostFormData(ctx context.Context, path string, body io.Reader, size int64, contentType string) (string, error) {
+	response, err := conn.requestWithLength(ctx, "POST", path, body, size, contentType)
 	if err != nil {
-		ui.Failed("Error while calling the apply manifest url %s - error: %s", path, err)
 		return "", err
 	}
+	return response.body, nil
+}
 
-	result, _ := ioutil.ReadAll(res.Body)
-	defer res.Body.Close()
+//url returns the complete url for the path, paths of the api get the api endpoint as prefix
+func (conn *HttpConnection) url(path string) (string, error) {
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path, nil
+	}
 
-	jsonResp := string(result)
-	if conn.traceLogging {
-		if len(jsonResp) == 0 {
-			ui.Say("response from post form data call to path: %s status code: %s", path, res.StatusCode)
-		} else {
-			ui.Say("response from post form data call to path: %s status code: %s, was: %s", path, res.StatusCode, print.PrettyJSON(jsonResp))
-		}
+	apiEndpoint, err := conn.cliConnection.ApiEndpoint()
+	if err != nil {
+		return "", err
 	}
+	return fmt.Sprintf("%s/%s", strings.TrimSuffix(apiEndpoint, "/"), strings.TrimPrefix(path, "/")), nil
+}
 
-	return jsonResp, nil
+//request call the cloud controller with the access token of the cli connection
+func (conn *HttpConnection) request(ctx context.Context, method string, path string, body io.Reader, contentType string) (*httpResponse, error) {
+	return conn.requestWithLength(ctx, method, path, body, -1, contentType)
 }
 
-func (conn *HttpConnection) PostJSON(path string, body []byte) (string, error) {
-	request, err := http.NewRequest(
-		"POST",
-		path,
-		bytes.NewReader(body),
-	)
+//requestWithLength call the cloud controller, a content length >= 0 will be set to the request.
+//The request is canceled when the context is done
+func (conn *HttpConnection) requestWithLength(ctx context.Context, method string, path string, body io.Reader, contentLength int64, contentType string) (*httpResponse, error) {
+	url, err := conn.url(path)
+	if err != nil {
+		return nil, err
+	}
 
+	request, err := http.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	if contentLength >= 0 {
+		request.ContentLength = contentLength
 	}
 
 	//get access token from cli connection
-	token, _ := conn.cliConnection.AccessToken()
+	token, err := conn.cliConnection.AccessToken()
+	if err != nil {
+		return nil, err
+	}
 
 	request.Header = http.Header{}
 	request.Header.Set("Accept", "application/json")
-	request.Header.Set("User-Agent", "client.userAgent")
-	request.Header.Set("Content-Type", "application/x-yaml")
+	request.Header.Set("User-Agent", "cf-puppeteer")
 	request.Header.Set("Authorization", token)
+	if len(contentType) > 0 {
+		request.Header.Set("Content-Type", contentType)
+	}
 
 	if conn.traceLogging {
-		ui.Say("try to call path: %s", path)
+		ui.Say("try to call %s path: %s", method, url)
 	}
 
 	res, err := conn.httpClient.Do(request)
 	if err != nil {
-		ui.Failed("Error while calling the apply manifest url %s - error: %s", path, err)
-		return "", err
+		//the error is not printed here, a transient error is retried and the final error is reported by the caller
+		ui.DebugMessage("error while calling %s %s - error: %s", method, url, err)
+		return nil, err
 	}
-
-	result, _ := ioutil.ReadAll(res.Body)
 	defer res.Body.Close()
 
+	result, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
 	jsonResp := string(result)
 	if conn.traceLogging {
 		if len(jsonResp) == 0 {
-			ui.Say("response from post call to path: %s status code: %s", path, res.StatusCode)
+			ui.Say("response from %s call to path: %s status code: %d", method, url, res.StatusCode)
 		} else {
-			ui.Say("response from post call to path: %s status code: %s was: %s", path, res.StatusCode, print.PrettyJSON(jsonResp))
+			ui.Say("response from %s call to path: %s status code: %d was: %s", method, url, res.StatusCode, print.PrettyJSON(jsonResp))
 		}
 	}
 
-	return jsonResp, nil
+	if res.StatusCode >= 400 {
+		ccError := &CloudControllerError{StatusCode: res.StatusCode, Method: method, Path: path}
+		_ = json.Unmarshal(result, ccError)
+		return nil, ccError
+	}
+
+	return &httpResponse{body: jsonResp, location: res.Header.Get("Location")}, nil
 }
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/cli/plugin"
+	plugin_models "code.cloudfoundry.org/cli/plugin/models"
+)
+
+//SynchronizedConnection cli connection that can be used from multiple goroutines. The rpc server of the cf cli
+//captures the output of all commands in one buffer and refreshes the token in the shared config, so commands and
+//token calls run one at a time. The getters only read the config and are not serialized.
+type SynchronizedConnection struct {
+	plugin.CliConnection
+	mutex sync.Mutex
+}
+
+//NewSynchronizedConnection wraps the passed cli connection
+func NewSynchronizedConnection(conn plugin.CliConnection) *SynchronizedConnection {
+	return &SynchronizedConnection{
+		CliConnection: conn,
+	}
+}
+
+//CliCommandWithoutTerminalOutput see plugin.CliConnection
+func (conn *SynchronizedConnection) CliCommandWithoutTerminalOutput(args ...string) ([]string, error) {
+	conn.mutex.Lock()
+	defer conn.mutex.Unlock()
+	return conn.CliConnection.CliCommandWithoutTerminalOutput(args...)
+}
+
+//CliCommand see plugin.CliConnection
+func (conn *SynchronizedConnection) CliCommand(args ...string) ([]string, error) {
+	conn.mutex.Lock()
+	defer conn.mutex.Unlock()
+	return conn.CliConnection.CliCommand(args...)
+}
+
+//AccessToken see plugin.CliConnection, the token may be refreshed
+func (conn *SynchronizedConnection) AccessToken() (string, error) {
+	conn.mutex.Lock()
+	defer conn.mutex.Unlock()
+	return conn.CliConnection.AccessToken()
+}
+
+//GetApp see plugin.CliConnection, runs the cf app command
+func (conn *SynchronizedConnection) GetApp(appName string) (plugin_models.GetAppModel, error) {
+	conn.mutex.Lock()
+	defer conn.mutex.Unlock()
+	return conn.CliConnection.GetApp(appName)
+}
@@ -1,8 +1,14 @@
 package cf
 
 import (
+	"context"
+	"fmt"
+	"os"
+
 	"github.com/happytobi/cf-puppeteer/arguments"
+	"github.com/happytobi/cf-puppeteer/cf/cli"
 	v2 "github.com/happytobi/cf-puppeteer/cf/v2"
+	"github.com/happytobi/cf-puppeteer/ui"
 
 	v3 "github.com/happytobi/cf-puppeteer/cf/v3"
 
@@ -13,12 +19,20 @@ import (
 type ApplicationPushData struct {
 	Connection   plugin.CliConnection
 	TraceLogging bool
+	OutputPrefix string
 }
 
 //PuppeteerPush push application interface
 type PuppeteerPush interface {
-	PushApplication(venAppName string, venAppExists bool, spaceGUID string, parsedArguments *arguments.ParserArguments) error
-	SwitchRoutes(venAppName string, venAppExists bool, appName string, routes []map[string]string, legacyPush bool) error
+	PushApplication(ctx context.Context, venAppName string, venAppExists bool, spaceGUID string, parsedArguments *arguments.ParserArguments) error
+	SwitchRoutes(ctx context.Context, venAppName string, venAppExists bool, appName string, routes []map[string]string, legacyPush bool) error
+	ResolveDomain(ctx context.Context, route map[string]string, legacyPush bool) (string, error)
+	ResolveRoutes(ctx context.Context, routes []map[string]string, legacyPush bool) ([]string, error)
+	MapRoute(ctx context.Context, appName string, host string, domain string, legacyPush bool) error
+	UnMapRoute(ctx context.Context, appName string, host string, domain string, legacyPush bool) error
+	MapRoutes(ctx context.Context, appName string, routes []map[string]string, legacyPush bool) error
+	UnMapRoutes(ctx context.Context, appName string, routes []map[string]string, legacyPush bool) error
+	RollingPush(ctx context.Context, spaceGUID string, parsedArguments *arguments.ParserArguments) error
 }
 
 //NewApplicationPush generate new cf puppeteer push
@@ -30,23 +44,149 @@ func NewApplicationPush(conn plugin.CliConnection, traceLogging bool) *Applicati
 }
 
 //PushApplication push application to cf
-func (adp *ApplicationPushData) PushApplication(venAppName string, venAppExists bool, spaceGUID string, parsedArguments *arguments.ParserArguments) error {
+func (adp *ApplicationPushData) PushApplication(ctx context.Context, venAppName string, venAppExists bool, spaceGUID string, parsedArguments *arguments.ParserArguments) error {
+	//the manifest without routes is generated for this push only
+	defer os.Remove(parsedArguments.NoRouteManifestPath)
 	if parsedArguments.LegacyPush == true {
-		var legacyPush v2.Push = v2.NewV2LegacyPush(adp.Connection, adp.TraceLogging)
-		return legacyPush.PushApplication(parsedArguments)
+		var legacyPush v2.Push = adp.legacyPush()
+		return legacyPush.PushApplication(ctx, parsedArguments)
 	}
 	//v3 push
-	var v2Resources v2.Resources = v2.NewV2Resources(adp.Connection, adp.TraceLogging)
-	var push v3.Push = v3.NewV3Push(adp.Connection, adp.TraceLogging)
-	return push.PushApplication(venAppName, spaceGUID, parsedArguments, v2Resources)
+	var v2Resources v2.Resources = v2.NewPrefixedV2Resources(adp.Connection, adp.TraceLogging, adp.OutputPrefix)
+	var push v3.Push = adp.push()
+	return push.PushApplication(ctx, venAppName, spaceGUID, parsedArguments, v2Resources)
+}
+
+//RollingPush stage a new droplet for the existing application and roll it out with a deployment of the cloud controller
+func (adp *ApplicationPushData) RollingPush(ctx context.Context, spaceGUID string, parsedArguments *arguments.ParserArguments) error {
+	return adp.push().RollingPushApplication(ctx, spaceGUID, parsedArguments)
 }
 
 //handle route switch
-func (adp *ApplicationPushData) SwitchRoutes(venAppName string, venAppExists bool, appName string, routes []map[string]string, legacyPush bool) error {
+func (adp *ApplicationPushData) SwitchRoutes(ctx context.Context, venAppName string, venAppExists bool, appName string, routes []map[string]string, legacyPush bool) error {
+	if legacyPush {
+		var legacyPush v2.Push = adp.legacyPush()
+		return legacyPush.SwitchRoutesOnly(ctx, venAppName, venAppExists, appName, routes)
+	}
+	var push v3.Push = adp.push()
+	return push.SwitchRoutesOnly(ctx, venAppName, venAppExists, appName, routes)
+}
+
+//routeResources resolves, maps and unmaps the routes with the legacy v2 push or the v3 push
+type routeResources interface {
+	GetDomain(ctx context.Context, domains []map[string]string) (*[]v2.Routes, error)
+	MapRoute(ctx context.Context, appName string, host string, domain string, path string) error
+	UnMapRoute(ctx context.Context, appName string, host string, domain string, path string) error
+}
+
+//routeResources returns the legacy v2 push or the v3 push to handle the routes
+func (adp *ApplicationPushData) routeResources(legacyPush bool) routeResources {
 	if legacyPush {
-		var legacyPush v2.Push = v2.NewV2LegacyPush(adp.Connection, adp.TraceLogging)
-		return legacyPush.SwitchRoutesOnly(venAppName, venAppExists, appName, routes)
+		return adp.legacyPush()
+	}
+	return adp.push()
+}
+
+//ResolveDomain returns the domain of the passed manifest route
+func (adp *ApplicationPushData) ResolveDomain(ctx context.Context, route map[string]string, legacyPush bool) (string, error) {
+	domains, err := adp.routeResources(legacyPush).GetDomain(ctx, []map[string]string{route})
+	if err != nil {
+		return "", err
+	}
+	if len(*domains) == 0 {
+		return "", fmt.Errorf("could not find a domain for route %v", route)
+	}
+	return (*domains)[0].Domain, nil
+}
+
+//ResolveRoutes returns the manifest routes as host.domain/path, every route has to belong to a known domain
+func (adp *ApplicationPushData) ResolveRoutes(ctx context.Context, routes []map[string]string, legacyPush bool) ([]string, error) {
+	resources := adp.routeResources(legacyPush)
+	var resolved []string
+	for _, route := range routes {
+		if len(route["route"]) == 0 {
+			continue
+		}
+
+		domains, err := resources.GetDomain(ctx, []map[string]string{route})
+		if err != nil {
+			return nil, err
+		}
+		if len(*domains) == 0 || len((*domains)[0].Domain) == 0 {
+			return nil, fmt.Errorf("could not find a domain for route %s", route["route"])
+		}
+
+		domain := (*domains)[0]
+		resolvedRoute := domain.Domain
+		if len(domain.Host) > 0 {
+			resolvedRoute = domain.Host + "." + resolvedRoute
+		}
+		if len(domain.Path) > 0 {
+			resolvedRoute = resolvedRoute + "/" + domain.Path
+		}
+		resolved = append(resolved, resolvedRoute)
+	}
+	return resolved, nil
+}
+
+//MapRoute map route with host and domain to the application
+func (adp *ApplicationPushData) MapRoute(ctx context.Context, appName string, host string, domain string, legacyPush bool) error {
+	return adp.routeResources(legacyPush).MapRoute(ctx, appName, host, domain, "")
+}
+
+//UnMapRoute remove route with host and domain from the application
+func (adp *ApplicationPushData) UnMapRoute(ctx context.Context, appName string, host string, domain string, legacyPush bool) error {
+	return adp.routeResources(legacyPush).UnMapRoute(ctx, appName, host, domain, "")
+}
+
+//MapRoutes map all manifest routes to the application without touching other applications
+func (adp *ApplicationPushData) MapRoutes(ctx context.Context, appName string, routes []map[string]string, legacyPush bool) error {
+	resources := adp.routeResources(legacyPush)
+	domains, err := resources.GetDomain(ctx, routes)
+	if err != nil {
+		return err
+	}
+	for _, route := range *domains {
+		err = resources.MapRoute(ctx, appName, route.Host, route.Domain, route.Path)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//UnMapRoutes remove all manifest routes from the application
+func (adp *ApplicationPushData) UnMapRoutes(ctx context.Context, appName string, routes []map[string]string, legacyPush bool) error {
+	resources := adp.routeResources(legacyPush)
+	domains, err := resources.GetDomain(ctx, routes)
+	if err != nil {
+		return err
+	}
+	for _, route := range *domains {
+		err = resources.UnMapRoute(ctx, appName, route.Host, route.Domain, route.Path)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//legacyPush generate v2 push that prints the cf cli output with the output prefix
+func (adp *ApplicationPushData) legacyPush() *v2.LegacyResourcesData {
+	legacyPush := v2.NewV2LegacyPush(adp.Connection, adp.TraceLogging)
+	if len(adp.OutputPrefix) > 0 {
+		legacyPush.Executor = cli.NewPrefixedExecutor(adp.TraceLogging, adp.OutputPrefix)
+	}
+	legacyPush.Output = ui.Prefixed{Prefix: adp.OutputPrefix}
+	return legacyPush
+}
+
+//push generate v3 push that prints the cf cli output with the output prefix
+func (adp *ApplicationPushData) push() *v3.ResourcesData {
+	push := v3.NewV3Push(adp.Connection, adp.TraceLogging)
+	if len(adp.OutputPrefix) > 0 {
+		push.Executor = cli.NewPrefixedExecutor(adp.TraceLogging, adp.OutputPrefix)
 	}
-	var push v3.Push = v3.NewV3Push(adp.Connection, adp.TraceLogging)
-	return push.SwitchRoutesOnly(venAppName, appName, routes)
+	push.Output = ui.Prefixed{Prefix: adp.OutputPrefix}
+	return push
 }
@@ -3,18 +3,16 @@ package print
 import (
 	"bytes"
 	"encoding/json"
-	"github.com/happytobi/cf-puppeteer/ui"
 )
 
-//PrettyJSON prints pretty json
-func PrettyJSON(jsonUgly string) error {
+//PrettyJSON returns the indented json, if the passed string is no valid json it will be returned unchanged
+func PrettyJSON(jsonUgly string) string {
 	jsonPretty := &bytes.Buffer{}
 	err := json.Indent(jsonPretty, []byte(jsonUgly), "", "  ")
 
 	if err != nil {
-		ui.Failed("PrettyJSON error %s", err)
-		return err
+		return jsonUgly
 	}
 
-	return nil
+	return jsonPretty.String()
 }
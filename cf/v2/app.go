@@ -1,11 +1,12 @@
 package v2
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"github.com/happytobi/cf-puppeteer/ui"
 	"net/url"
+	"strconv"
 	"time"
 )
 
@@ -76,15 +77,20 @@ type Entity struct {
 	RouteMappingsURL     string    `json:"route_mappings_url"`
 }
 
+//InstanceStats state of one application instance
+type InstanceStats struct {
+	State string `json:"state"`
+}
+
 //GetAppMetadata
-func (resource *ResourcesData) GetAppMetadata(appName string) (*AppResourcesEntity, error) {
-	space, err := resource.connection.GetCurrentSpace()
+func (resource *ResourcesData) GetAppMetadata(ctx context.Context, appName string) (*AppResourcesEntity, error) {
+	space, err := resource.Connection.GetCurrentSpace()
 	if err != nil {
 		return nil, err
 	}
 
 	path := fmt.Sprintf(`v2/apps?q=name:%s&q=space_guid:%s`, url.QueryEscape(appName), space.Guid)
-	jsonResult, err := resource.cli.GetJSON(path)
+	jsonResult, err := resource.Cli.GetJSON(ctx, path)
 
 	if err != nil {
 		return nil, err
@@ -94,44 +100,77 @@ func (resource *ResourcesData) GetAppMetadata(appName string) (*AppResourcesEnti
 	err = json.Unmarshal([]byte(jsonResult), &metaDataResponseEntity)
 
 	if err != nil {
-		ui.Failed("no response / parsable response from %s %s", path, err)
+		resource.Output.FailedMessage(fmt.Sprintf("no response / parsable response from %s %s", path, err))
 		return nil, err
 	}
 
 	if len(metaDataResponseEntity.AppResourcesEntity) == 0 {
-		ui.Warn(fmt.Sprintf("No application with name: %s found", appName))
+		resource.Output.Warn("No application with name: %s found", appName)
 		return nil, ErrAppNotFound
 	}
 
 	return &metaDataResponseEntity.AppResourcesEntity[0], nil
 }
 
-func (resource *ResourcesData) RenameApplication(oldName, newName string) error {
-	_, err := resource.connection.CliCommand("rename", oldName, newName)
+func (resource *ResourcesData) RenameApplication(ctx context.Context, oldName, newName string) error {
+	err := resource.cliCommand(ctx, "rename", oldName, newName)
+	return err
+}
+
+func (resource *ResourcesData) StopApplication(ctx context.Context, appName string) error {
+	err := resource.cliCommand(ctx, "stop", appName)
 	return err
 }
 
-func (resource *ResourcesData) StopApplication(appName string) error {
-	_, err := resource.connection.CliCommand("stop", appName)
+func (resource *ResourcesData) StartApplication(ctx context.Context, appName string) error {
+	err := resource.cliCommand(ctx, "start", appName)
 	return err
 }
 
-func (resource *ResourcesData) StartApplication(appName string) error {
-	_, err := resource.connection.CliCommand("start", appName)
+func (resource *ResourcesData) DeleteApplication(ctx context.Context, appName string) error {
+	err := resource.cliCommand(ctx, "delete", appName, "-f")
 	return err
 }
 
-func (resource *ResourcesData) DeleteApplication(appName string) error {
-	_, err := resource.connection.CliCommand("delete", appName, "-f")
+func (resource *ResourcesData) ShowCrashLogs(ctx context.Context, appName string) error {
+	err := resource.cliCommand(ctx, "logs", "--recent", appName)
 	return err
 }
 
-func (resource *ResourcesData) ShowCrashLogs(appName string) error {
-	_, err := resource.connection.CliCommand("logs", "--recent", appName)
+func (resource *ResourcesData) ListApplications(ctx context.Context) error {
+	err := resource.cliCommand(ctx, "apps")
 	return err
 }
 
-func (resource *ResourcesData) ListApplications() error {
-	_, err := resource.connection.CliCommand("apps")
+//ScaleApplication changes the number of instances of the application
+func (resource *ResourcesData) ScaleApplication(ctx context.Context, appName string, instances int) error {
+	err := resource.cliCommand(ctx, "scale", appName, "-i", strconv.Itoa(instances))
 	return err
 }
+
+//GetAppInstances returns the state of all instances of the application
+func (resource *ResourcesData) GetAppInstances(ctx context.Context, appName string) ([]InstanceStats, error) {
+	app, err := resource.GetAppMetadata(ctx, appName)
+	if err != nil {
+		return nil, err
+	}
+
+	path := fmt.Sprintf(`v2/apps/%s/stats`, app.Metadata.GUID)
+	jsonResult, err := resource.Cli.GetJSON(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var stats map[string]InstanceStats
+	err = json.Unmarshal([]byte(jsonResult), &stats)
+	if err != nil {
+		resource.Output.FailedMessage(fmt.Sprintf("no response / parsable response from %s %s", path, err))
+		return nil, err
+	}
+
+	instances := make([]InstanceStats, 0, len(stats))
+	for _, instance := range stats {
+		instances = append(instances, instance)
+	}
+	return instances, nil
+}
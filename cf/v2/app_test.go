@@ -0,0 +1,74 @@
+package v2_test
+
+import (
+	"code.cloudfoundry.org/cli/plugin/models"
+	"code.cloudfoundry.org/cli/plugin/pluginfakes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/happytobi/cf-puppeteer/cf/cli"
+	v2 "github.com/happytobi/cf-puppeteer/cf/v2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("cf-app test", func() {
+	var (
+		cliConn       *pluginfakes.FakeCliConnection
+		fakeExecutor  *cli.FakeExecutor
+		resourcesData *v2.ResourcesData
+	)
+
+	BeforeEach(func() {
+		cliConn = &pluginfakes.FakeCliConnection{}
+		cliConn.GetCurrentSpaceReturns(plugin_models.Space{SpaceFields: plugin_models.SpaceFields{Guid: "space-guid"}}, nil)
+		fakeExecutor = &cli.FakeExecutor{}
+		resourcesData = &v2.ResourcesData{Cli: cli.NewCli(cliConn, false), Connection: cliConn, Executor: fakeExecutor.NewFakeExecutor()}
+	})
+
+	Describe("Application instances", func() {
+		It("returns the state of all instances", func() {
+			responses := [][]string{
+				{`{"resources": [{"metadata": {"guid": "app-guid"}, "entity": {"name": "myApp", "state": "STARTED"}}]}`},
+				{`{"0": {"state": "RUNNING"}, "1": {"state": "CRASHED"}}`},
+			}
+			cliConn.CliCommandWithoutTerminalOutputStub = func(args ...string) ([]string, error) {
+				return responses[cliConn.CliCommandWithoutTerminalOutputCallCount()-1], nil
+			}
+
+			instances, err := resourcesData.GetAppInstances(context.Background(), "myApp")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cliConn.CliCommandWithoutTerminalOutputArgsForCall(1)[1]).To(Equal("v2/apps/app-guid/stats"))
+			Expect(len(instances)).To(Equal(2))
+			Expect(instances).To(ContainElement(v2.InstanceStats{State: "CRASHED"}))
+		})
+
+		It("scales the application", func() {
+			err := resourcesData.ScaleApplication(context.Background(), "myApp", 3)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cliConn.CliCommandCallCount()).To(Equal(0))
+			Expect(fakeExecutor.ExecutorArgumentsOutput()[0]).To(Equal([]string{"scale", "myApp", "-i", "3"}))
+		})
+
+		It("calls the cloud controller without the rpc server of the cf cli", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Path).To(Equal("/v2/apps"))
+				Expect(r.Header.Get("Authorization")).To(Equal("bearer token"))
+				_, _ = w.Write([]byte(`{"resources": [{"metadata": {"guid": "app-guid"}, "entity": {"name": "myApp"}}]}`))
+			}))
+			defer server.Close()
+			cliConn.ApiEndpointReturns(server.URL, nil)
+			cliConn.AccessTokenReturns("bearer token", nil)
+
+			app, err := v2.NewV2Resources(cliConn, false).GetAppMetadata(context.Background(), "myApp")
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(app.Metadata.GUID).To(Equal("app-guid"))
+			Expect(cliConn.CliCommandWithoutTerminalOutputCallCount()).To(Equal(0))
+		})
+	})
+})
@@ -1,6 +1,7 @@
 package v2
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"sort"
@@ -36,11 +37,11 @@ type DomainResponse struct {
 	} `json:"resources"`
 }
 
-func (resource *LegacyResourcesData) GetDomain(domains []map[string]string) (*[]Routes, error) {
+func (resource *LegacyResourcesData) GetDomain(ctx context.Context, domains []map[string]string) (*[]Routes, error) {
 	//default order asc.
 	ui.DebugMessage("GetDomain called, try to find matching domains for all routes %v", domains)
 	path := fmt.Sprintf(`/v2/domains`)
-	response, err := resource.getDomain(path)
+	response, err := resource.getDomain(ctx, path)
 	if err != nil {
 		return nil, err
 	}
@@ -80,7 +81,7 @@ func (resource *LegacyResourcesData) GetDomain(domains []map[string]string) (*[]
 
 	//move to func and all recursive
 	for response.Pagination.NextUrl != "" && len(domainGUID) <= 0 {
-		response, err := resource.getDomain(response.Pagination.NextUrl)
+		response, err := resource.getDomain(ctx, response.Pagination.NextUrl)
 		if err != nil {
 			return nil, err
 		}
@@ -126,10 +127,10 @@ func (resource *LegacyResourcesData) GetDomain(domains []map[string]string) (*[]
 	return &domainsFound, err
 }
 
-func (resource *LegacyResourcesData) getDomain(path string) (*DomainResponse, error) {
+func (resource *LegacyResourcesData) getDomain(ctx context.Context, path string) (*DomainResponse, error) {
 	var response DomainResponse
 
-	jsonResult, err := resource.Cli.GetJSON(path)
+	jsonResult, err := resource.Cli.GetJSON(ctx, path)
 	if err != nil {
 		return nil, err
 	}
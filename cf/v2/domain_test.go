@@ -1,9 +1,11 @@
 package v2_test
 
 import (
+	"context"
 	"testing"
 
 	"code.cloudfoundry.org/cli/plugin/pluginfakes"
+	"github.com/happytobi/cf-puppeteer/cf/cli"
 	v2 "github.com/happytobi/cf-puppeteer/cf/v2"
 
 	. "github.com/onsi/ginkgo"
@@ -23,7 +25,7 @@ var _ = Describe("cf-domain test", func() {
 
 	BeforeEach(func() {
 		cliConn = &pluginfakes.FakeCliConnection{}
-		resourcesData = v2.NewV2LegacyPush(cliConn, false)
+		resourcesData = &v2.LegacyResourcesData{Cli: cli.NewCli(cliConn, false)}
 	})
 	Describe("Domain actions with curl v2 api", func() {
 		It("use v2 domain api", func() {
@@ -95,7 +97,7 @@ var _ = Describe("cf-domain test", func() {
 			cliConn.CliCommandWithoutTerminalOutputReturns(response, nil)
 
 			var routes = []map[string]string{0: {"route": "url.test-domain.com"}, 1: {"route": "foo.example.com"}, 2: {"route": "my.foo.example.com"}, 3: {"route": "puppeteer.internal.emea.github.com"}}
-			domainResponse, err := resourcesData.GetDomain(routes)
+			domainResponse, err := resourcesData.GetDomain(context.Background(), routes)
 
 			Expect(cliConn.CliCommandWithoutTerminalOutputCallCount()).To(Equal(1))
 			Expect(4).To(Equal(len(*domainResponse)))
@@ -153,7 +155,7 @@ var _ = Describe("cf-domain test", func() {
 			cliConn.CliCommandWithoutTerminalOutputReturns(response, nil)
 
 			var routes = []map[string]string{0: {"route": " staging-p.cfapp.io"}, 1: {"route": "staging-product.cfapp.io"}, 2: {"route": "staging.product.com"}, 3: {"route": "bcd-test.cfapp.io/api"}}
-			domainResponse, err := resourcesData.GetDomain(routes)
+			domainResponse, err := resourcesData.GetDomain(context.Background(), routes)
 
 			Expect(cliConn.CliCommandWithoutTerminalOutputCallCount()).To(Equal(1))
 			Expect(4).To(Equal(len(*domainResponse)))
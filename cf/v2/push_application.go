@@ -1,7 +1,9 @@
 package v2
 
 import (
+	"context"
 	"fmt"
+	"sort"
 	"strconv"
 
 	"code.cloudfoundry.org/cli/plugin"
@@ -13,26 +15,27 @@ import (
 
 //Push interface with all v3 actions
 type Push interface {
-	PushApplication(parsedArguments *arguments.ParserArguments) error
-	SwitchRoutesOnly(venAppName string, venAppExists bool, appName string, routes []map[string]string) error
+	PushApplication(ctx context.Context, parsedArguments *arguments.ParserArguments) error
+	SwitchRoutesOnly(ctx context.Context, venAppName string, venAppExists bool, appName string, routes []map[string]string) error
 }
 
 //ResourcesData internal struct with connection an tracing options etc
 type LegacyResourcesData struct {
 	Executor cli.CfExecutor
 	Cli      cli.Calls
+	Output   ui.Prefixed
 }
 
 //NewV2LegacyPush constructor
 func NewV2LegacyPush(conn plugin.CliConnection, traceLogging bool) *LegacyResourcesData {
 	return &LegacyResourcesData{
 		Executor: cli.NewExecutor(traceLogging),
-		Cli:      cli.NewCli(conn, traceLogging),
+		Cli:      cli.NewHttpCli(conn, traceLogging),
 	}
 }
 
-func (resource *LegacyResourcesData) PushApplication(parsedArguments *arguments.ParserArguments) error {
-	ui.InfoMessage("Use legacy push")
+func (resource *LegacyResourcesData) PushApplication(ctx context.Context, parsedArguments *arguments.ParserArguments) error {
+	resource.Output.InfoMessage("Use legacy push")
 
 	args := []string{"push", parsedArguments.AppName, "-f", parsedArguments.ManifestPath, "--no-start", "--no-route"}
 	if parsedArguments.AppPath != "" {
@@ -52,18 +55,29 @@ func (resource *LegacyResourcesData) PushApplication(parsedArguments *arguments.
 		args = append(args, "--no-start")
 	}
 
-	if parsedArguments.VarsFile != "" {
-		args = append(args, "--vars-file", parsedArguments.VarsFile)
+	//-f is the original manifest with its placeholders, not the interpolated manifest without routes, because relative
+	//and wildcard paths are resolved against its directory. The cf cli interpolates it itself: later vars files override
+	//earlier ones and --var overrides all vars files, like puppeteer interpolates the manifest for the v3 push
+	for _, varsFile := range parsedArguments.VarsFiles {
+		args = append(args, "--vars-file", varsFile)
+	}
+	varNames := make([]string, 0, len(parsedArguments.Vars))
+	for name := range parsedArguments.Vars {
+		varNames = append(varNames, name)
+	}
+	sort.Strings(varNames)
+	for _, name := range varNames {
+		args = append(args, "--var", fmt.Sprintf("%s=%s", name, parsedArguments.Vars[name]))
 	}
 
-	ui.Say("start pushing application with arguments %s", args)
-	err := resource.Executor.Execute(args)
+	resource.Output.Say("start pushing application with arguments %s", args)
+	err := resource.Executor.Execute(ctx, args)
 	if err != nil {
 		return err
 	}
 
 	//set all environment variables
-	err = resource.setEnvironmentVariables(parsedArguments)
+	err = resource.setEnvironmentVariables(ctx, parsedArguments)
 	if err != nil {
 		return err
 	}
@@ -72,28 +86,26 @@ func (resource *LegacyResourcesData) PushApplication(parsedArguments *arguments.
 }
 
 //SwitchRoutes switch route interface method to provide switch routes only option
-func (resource *LegacyResourcesData) SwitchRoutesOnly(venAppName string, venAppExists bool, appName string, routes []map[string]string) (err error) {
-	domains, err := resource.GetDomain(routes)
+func (resource *LegacyResourcesData) SwitchRoutesOnly(ctx context.Context, venAppName string, venAppExists bool, appName string, routes []map[string]string) (err error) {
+	domains, err := resource.GetDomain(ctx, routes)
 	if err != nil {
 		return err
 	}
 
-	ui.Say("map routes to new application %s", appName)
+	resource.Output.Say("map routes to new application %s", appName)
 	for _, route := range *domains {
-		err = resource.MapRoute(appName, route.Host, route.Domain, route.Path)
+		err = resource.MapRoute(ctx, appName, route.Host, route.Domain, route.Path)
 		if err != nil {
-			//loop through
-			ui.Warn("could not map route %s.%s to application", route.Host, route.Domain, appName)
+			return errors.Wrap(err, fmt.Sprintf("could not map route %s.%s to application %s", route.Host, route.Domain, appName))
 		}
 	}
 
 	if venAppExists {
-		ui.Say("remove routes from venerable application %s", venAppName)
+		resource.Output.Say("remove routes from venerable application %s", venAppName)
 		for _, route := range *domains {
-			err = resource.UnMapRoute(venAppName, route.Host, route.Domain, route.Path)
+			err = resource.UnMapRoute(ctx, venAppName, route.Host, route.Domain, route.Path)
 			if err != nil {
-				//loop through
-				ui.Warn("could not remove route %s.%s from application", route.Host, route.Domain, venAppName)
+				return errors.Wrap(err, fmt.Sprintf("could not remove route %s.%s from application %s", route.Host, route.Domain, venAppName))
 			}
 		}
 	}
@@ -101,17 +113,17 @@ func (resource *LegacyResourcesData) SwitchRoutesOnly(venAppName string, venAppE
 	return nil
 }
 
-func (resource *LegacyResourcesData) setEnvironmentVariables(parsedArguments *arguments.ParserArguments) (err error) {
-	ui.Say("set passed environment variables")
+func (resource *LegacyResourcesData) setEnvironmentVariables(ctx context.Context, parsedArguments *arguments.ParserArguments) (err error) {
+	resource.Output.Say("set passed environment variables")
 	//set all variables passed by --var
 	for envKey, envVal := range parsedArguments.Envs {
 		executeArgument := []string{"set-env", parsedArguments.AppName, envKey, envVal}
 		ui.DebugMessage("set-env: %s", executeArgument)
-		err := resource.Executor.Execute(executeArgument)
+		err := resource.Executor.Execute(ctx, executeArgument)
 		if err != nil {
 			return errors.Wrap(err, fmt.Sprintf("could not set env-variable with key %s to application %s", envKey, parsedArguments.AppName))
 		}
 	}
-	ui.Ok()
+	resource.Output.Ok()
 	return nil
 }
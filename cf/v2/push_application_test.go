@@ -0,0 +1,33 @@
+package v2_test
+
+import (
+	"context"
+
+	"github.com/happytobi/cf-puppeteer/arguments"
+	"github.com/happytobi/cf-puppeteer/cf/cli"
+	v2 "github.com/happytobi/cf-puppeteer/cf/v2"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("legacy push", func() {
+	It("pushes the original manifest and lets the cf cli interpolate it with the vars files and vars", func() {
+		fakeExecutor := &cli.FakeExecutor{}
+		resourcesData := &v2.LegacyResourcesData{Executor: fakeExecutor.NewFakeExecutor()}
+		parsedArguments := &arguments.ParserArguments{
+			AppName:             "myApp",
+			ManifestPath:        "manifest.yml",
+			NoRouteManifestPath: "/tmp/no-route-manifest.yml",
+			InvocationTimeout:   -1,
+			VarsFiles:           []string{"base-vars.yml", "prod-vars.yml"},
+			Vars:                map[string]string{"zone": "b", "instances": "2"},
+		}
+
+		err := resourcesData.PushApplication(context.Background(), parsedArguments)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fakeExecutor.ExecutorArgumentsOutput()[0]).To(Equal([]string{"push", "myApp", "-f", "manifest.yml", "--no-start", "--no-route",
+			"--vars-file", "base-vars.yml", "--vars-file", "prod-vars.yml", "--var", "instances=2", "--var", "zone=b"}))
+	})
+})
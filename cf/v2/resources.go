@@ -2,29 +2,49 @@ package v2
 
 import (
 	"code.cloudfoundry.org/cli/plugin"
+	"context"
 	"github.com/happytobi/cf-puppeteer/cf/cli"
+	"github.com/happytobi/cf-puppeteer/ui"
 )
 
 type Resources interface {
-	GetAppMetadata(appName string) (*AppResourcesEntity, error)
-	RenameApplication(oldName string, newName string) (err error)
-	StopApplication(appName string) (err error)
-	StartApplication(appName string) (err error)
-	DeleteApplication(appName string) (err error)
-	ShowCrashLogs(appName string) (err error)
-	ListApplications() (err error)
+	GetAppMetadata(ctx context.Context, appName string) (*AppResourcesEntity, error)
+	RenameApplication(ctx context.Context, oldName string, newName string) (err error)
+	StopApplication(ctx context.Context, appName string) (err error)
+	StartApplication(ctx context.Context, appName string) (err error)
+	DeleteApplication(ctx context.Context, appName string) (err error)
+	ShowCrashLogs(ctx context.Context, appName string) (err error)
+	ListApplications(ctx context.Context) (err error)
+	ScaleApplication(ctx context.Context, appName string, instances int) (err error)
+	GetAppInstances(ctx context.Context, appName string) ([]InstanceStats, error)
 }
 
 //ResourcesData internal struct with connection an tracing options etc
 type ResourcesData struct {
-	cli        cli.Calls
-	connection plugin.CliConnection
+	Cli        cli.Calls
+	Connection plugin.CliConnection
+	Executor   cli.CfExecutor
+	Output     ui.Prefixed
 }
 
 //NewV2Resources constructor
 func NewV2Resources(conn plugin.CliConnection, traceLogging bool) *ResourcesData {
+	return NewPrefixedV2Resources(conn, traceLogging, "")
+}
+
+//NewPrefixedV2Resources constructor that prints the output of the cf cli commands with the prefix of the deployment.
+//The cloud controller is called with the http client and the commands run as cf cli processes, so parallel
+//deployments don't wait for each other and a command is killed when the context is done
+func NewPrefixedV2Resources(conn plugin.CliConnection, traceLogging bool, outputPrefix string) *ResourcesData {
 	return &ResourcesData{
-		cli:        cli.NewCli(conn, traceLogging),
-		connection: conn,
+		Cli:        cli.NewHttpCli(conn, traceLogging),
+		Connection: conn,
+		Executor:   cli.NewOutputExecutor(traceLogging, outputPrefix),
+		Output:     ui.Prefixed{Prefix: outputPrefix},
 	}
 }
+
+//cliCommand runs the cf cli command, it is killed when the context is done
+func (resource *ResourcesData) cliCommand(ctx context.Context, args ...string) error {
+	return resource.Executor.Execute(ctx, args)
+}
@@ -1,19 +1,20 @@
 package v2
 
 import (
-	"fmt"
+	"context"
+	"github.com/happytobi/cf-puppeteer/ui"
 )
 
 //MapRoute map route to application
-func (resource *LegacyResourcesData) MapRoute(appName string, host string, domain string, path string) (err error) {
+func (resource *LegacyResourcesData) MapRoute(ctx context.Context, appName string, host string, domain string, path string) (err error) {
 	args := []string{"map-route", appName, domain, "--hostname", host}
 
 	if len(path) > 0 {
 		args = append(args, "--path", path)
 	}
 
-	fmt.Printf("map route %v", args)
-	err = resource.Executor.Execute(args)
+	ui.DebugMessage("map route %v", args)
+	err = resource.Executor.Execute(ctx, args)
 	if err != nil {
 		return err
 	}
@@ -21,15 +22,15 @@ func (resource *LegacyResourcesData) MapRoute(appName string, host string, domai
 }
 
 //UnMapRoute remove route from application
-func (resource *LegacyResourcesData) UnMapRoute(appName string, host string, domain string, path string) (err error) {
+func (resource *LegacyResourcesData) UnMapRoute(ctx context.Context, appName string, host string, domain string, path string) (err error) {
 	args := []string{"unmap-route", appName, domain, "--hostname", host}
 
 	if len(path) > 0 {
 		args = append(args, "--path", path)
 	}
 
-	fmt.Printf("map route %v", args)
-	err = resource.Executor.Execute(args)
+	ui.DebugMessage("unmap route %v", args)
+	err = resource.Executor.Execute(ctx, args)
 	if err != nil {
 		return err
 	}
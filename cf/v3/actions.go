@@ -1,14 +1,20 @@
 package v3
 
 import (
+	"context"
 	"fmt"
 	"github.com/pkg/errors"
+	"io/ioutil"
 )
 
-func (resource *ResourcesData) AssignAppManifest(manifestPath string) (err error) {
-	args := []string{"v3-apply-manifest", "-f", manifestPath}
+//AssignAppManifest apply the manifest file to the space
+func (resource *ResourcesData) AssignAppManifest(ctx context.Context, spaceGUID string, manifestPath string) (err error) {
+	manifest, err := ioutil.ReadFile(manifestPath)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("could not read manifest %s", manifestPath))
+	}
 
-	err = resource.Executor.Execute(args)
+	err = resource.Client.ApplyManifest(ctx, spaceGUID, manifest)
 	if err != nil {
 		return errors.Wrap(err, fmt.Sprintf("error while assigning manifest to application %s", manifestPath))
 	}
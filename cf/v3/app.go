@@ -1,57 +1,74 @@
 package v3
 
 import (
+	"context"
 	"fmt"
 	"github.com/happytobi/cf-puppeteer/arguments"
-	"github.com/happytobi/cf-puppeteer/ui"
+	"github.com/happytobi/cf-puppeteer/cf/ccv3"
 	"github.com/pkg/errors"
 )
 
-func (resource *ResourcesData) CreateApp(parsedArguments *arguments.ParserArguments) (err error) {
-	args := []string{"v3-create-app", parsedArguments.AppName}
+//CreateApp create the application in the space, docker images get the docker lifecycle
+func (resource *ResourcesData) CreateApp(ctx context.Context, spaceGUID string, parsedArguments *arguments.ParserArguments) (*ccv3.App, error) {
+	app, err := resource.Client.CreateApp(ctx, parsedArguments.AppName, spaceGUID, len(parsedArguments.DockerImage) > 0)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create app")
+	}
+	return app, nil
+}
 
-	//check if user pushes a docker image
-	if len(parsedArguments.DockerImage) > 0 {
-		args = append(args, "--app-type", "docker")
+//PushApp upload the application bits or docker image, stage them and set the droplet as current droplet
+func (resource *ResourcesData) PushApp(ctx context.Context, app *ccv3.App, parsedArguments *arguments.ParserArguments) (err error) {
+	droplet, err := resource.StageApp(ctx, app, parsedArguments)
+	if err != nil {
+		return err
 	}
 
-	err = resource.Executor.Execute(args)
+	err = resource.Client.SetCurrentDroplet(ctx, app.GUID, droplet)
 	if err != nil {
-		return errors.Wrap(err, "could not create app")
+		return pushPhaseError(err, "droplet", app.Name)
 	}
 	return nil
 }
 
-func (resource *ResourcesData) PushApp(parsedArguments *arguments.ParserArguments) (err error) {
-	args := []string{"v3-push", parsedArguments.AppName, "--no-start"}
-	if len(parsedArguments.AppPath) > 0 && len(parsedArguments.DockerImage) == 0 {
-		args = append(args, "-p", parsedArguments.AppPath)
+//StageApp upload the application bits or docker image and stage them, the guid of the staged droplet is returned
+func (resource *ResourcesData) StageApp(ctx context.Context, app *ccv3.App, parsedArguments *arguments.ParserArguments) (string, error) {
+	appPackage, err := resource.createPackage(ctx, app, parsedArguments)
+	if err != nil {
+		return "", pushPhaseError(err, "package", app.Name)
 	}
 
-	if len(parsedArguments.DockerImage) > 0 {
-		args = append(args, "--docker-image", parsedArguments.DockerImage)
-		if len(parsedArguments.DockerUserName) > 0 {
-			args = append(args, "--docker-username", parsedArguments.DockerUserName)
+	if len(parsedArguments.DockerImage) == 0 {
+		err = resource.uploadBits(ctx, app, appPackage, appBitsPath(parsedArguments))
+		if err != nil {
+			return "", pushPhaseError(err, "upload", app.Name)
 		}
 	}
 
-	if parsedArguments.NoRoute == true {
-		args = append(args, "--no-route")
+	appPackage, err = resource.Client.WaitForPackage(ctx, appPackage.GUID)
+	if err != nil {
+		return "", pushPhaseError(err, "package", app.Name)
 	}
 
-	err = resource.Executor.Execute(args)
+	droplet, err := resource.stagePackage(ctx, app, appPackage)
 	if err != nil {
-		return errors.Wrap(err, fmt.Sprintf("could not push application with passed args: %v", args))
+		return "", pushPhaseError(err, "staging", app.Name)
 	}
+	return droplet, nil
+}
 
-	for envKey, envVal := range parsedArguments.Envs {
-		ui.Say(fmt.Sprintf("set environment-variable %s", envKey))
-		args = []string{"v3-set-env", parsedArguments.AppName, envKey, envVal}
-		err = resource.Executor.Execute(args)
-		if err != nil {
-			ui.Failed("could not set environment variable with key: %s to application: %s", envKey, parsedArguments.AppName)
-		}
+//SetEnvironmentVariables set the passed environment variables to the application
+func (resource *ResourcesData) SetEnvironmentVariables(ctx context.Context, app *ccv3.App, envs map[string]string) error {
+	if len(envs) == 0 {
+		return nil
 	}
 
+	for envKey := range envs {
+		resource.Output.Say("set environment-variable %s", envKey)
+	}
+	err := resource.Client.UpdateAppEnvironmentVariables(ctx, app.GUID, envs)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("could not set environment variables to application: %s", app.Name))
+	}
 	return nil
 }
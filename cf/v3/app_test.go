@@ -1,11 +1,25 @@
 package v3_test
 
 import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"code.cloudfoundry.org/cli/cf/appfiles"
 	"code.cloudfoundry.org/cli/plugin/pluginfakes"
 	"github.com/happytobi/cf-puppeteer/arguments"
+	"github.com/happytobi/cf-puppeteer/cf/ccv3"
 	"github.com/happytobi/cf-puppeteer/cf/cli"
 	v3 "github.com/happytobi/cf-puppeteer/cf/v3"
-	"testing"
+	appmanifest "github.com/happytobi/cf-puppeteer/manifest"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -21,12 +35,39 @@ var _ = Describe("cf-app test", func() {
 		cliConn       *pluginfakes.FakeCliConnection
 		resourcesData *v3.ResourcesData
 		fakeExecutor  *cli.FakeExecutor
+		server        *httptest.Server
+		requestPaths  []string
+		requestBodies []map[string]interface{}
+		responseBody  string
 	)
 
 	BeforeEach(func() {
+		requestPaths = nil
+		requestBodies = nil
+		responseBody = `{"guid": "app-guid", "name": "myTestApp"}`
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestPaths = append(requestPaths, r.Method+" "+r.URL.Path)
+			body, _ := ioutil.ReadAll(r.Body)
+			requestBody := map[string]interface{}{}
+			_ = json.Unmarshal(body, &requestBody)
+			requestBodies = append(requestBodies, requestBody)
+			_, _ = w.Write([]byte(responseBody))
+		}))
+
 		cliConn = &pluginfakes.FakeCliConnection{}
+		cliConn.ApiEndpointReturns(server.URL, nil)
+		cliConn.AccessTokenReturns("bearer token", nil)
 		fakeExecutor = &cli.FakeExecutor{}
-		resourcesData = &v3.ResourcesData{Connection: cliConn, Cli: cli.NewCli(cliConn, false), Executor: fakeExecutor.NewFakeExecutor()}
+		resourcesData = &v3.ResourcesData{
+			Connection: cliConn,
+			Cli:        cli.NewCli(cliConn, false),
+			Client:     ccv3.NewClient(cli.NewHttpClient(cliConn, false, 30, false)),
+			Executor:   fakeExecutor.NewFakeExecutor(),
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
 	})
 
 	Describe("CreateApp v3", func() {
@@ -34,12 +75,14 @@ var _ = Describe("cf-app test", func() {
 			arguments := &arguments.ParserArguments{
 				AppName: "myTestApp",
 			}
-			err := resourcesData.CreateApp(arguments)
+			app, err := resourcesData.CreateApp(context.Background(), "space-guid", arguments)
 
-			argumentsOutput := []string{"v3-create-app", "myTestApp"}
-			Expect(fakeExecutor.ExecutorCallCount()).To(Equal(1))
-			Expect(fakeExecutor.ExecutorArgumentsOutput()[0]).To(Equal(argumentsOutput))
 			Expect(err).ToNot(HaveOccurred())
+			Expect(app.GUID).To(Equal("app-guid"))
+			Expect(fakeExecutor.ExecutorCallCount()).To(Equal(0))
+			Expect(requestPaths).To(Equal([]string{"POST /v3/apps"}))
+			Expect(requestBodies[0]["name"]).To(Equal("myTestApp"))
+			Expect(requestBodies[0]).ToNot(HaveKey("lifecycle"))
 		})
 
 		It("push docker image", func() {
@@ -48,74 +91,378 @@ var _ = Describe("cf-app test", func() {
 				DockerImage:    "myDockerImage",
 				DockerUserName: "mySecretDockerUser",
 			}
-			err := resourcesData.CreateApp(arguments)
+			_, err := resourcesData.CreateApp(context.Background(), "space-guid", arguments)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(requestPaths).To(Equal([]string{"POST /v3/apps"}))
+			Expect(requestBodies[0]["lifecycle"]).To(HaveKeyWithValue("type", "docker"))
+		})
+
+		It("returns cloud controller error", func() {
+			server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				_, _ = w.Write([]byte(`{"errors": [{"code": 10008, "title": "CF-UnprocessableEntity", "detail": "Name must be unique in space"}]}`))
+			})
+			_, err := resourcesData.CreateApp(context.Background(), "space-guid", &arguments.ParserArguments{AppName: "myTestApp"})
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("CF-UnprocessableEntity (10008): Name must be unique in space"))
+		})
+	})
+
+	Describe("SetEnvironmentVariables v3", func() {
+		It("patch all envs with one request", func() {
+			envsMap := map[string]string{"key": "value", "newKey": "newValue"}
+			err := resourcesData.SetEnvironmentVariables(context.Background(), &ccv3.App{GUID: "app-guid"}, envsMap)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(requestPaths).To(Equal([]string{"PATCH /v3/apps/app-guid/environment_variables"}))
+			Expect(requestBodies[0]["var"]).To(Equal(map[string]interface{}{"key": "value", "newKey": "newValue"}))
+		})
+
+		It("skip request without envs", func() {
+			err := resourcesData.SetEnvironmentVariables(context.Background(), &ccv3.App{GUID: "app-guid"}, nil)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(requestPaths).To(BeEmpty())
+		})
+	})
+
+	Describe("SetHealthCheck v3", func() {
+		It("set http health check on web process", func() {
+			responseBody = `{"guid": "process-guid", "type": "web"}`
+			err := resourcesData.SetHealthCheck(context.Background(), &ccv3.App{GUID: "app-guid"}, "http", "/health", 5, "")
 
-			argumentsOutput := []string{"v3-create-app", "myTestApp", "--app-type", "docker"}
-			Expect(fakeExecutor.ExecutorCallCount()).To(Equal(1))
-			Expect(fakeExecutor.ExecutorArgumentsOutput()[0]).To(Equal(argumentsOutput))
 			Expect(err).ToNot(HaveOccurred())
+			Expect(requestPaths).To(Equal([]string{"GET /v3/apps/app-guid/processes/web", "PATCH /v3/processes/process-guid"}))
+			Expect(requestBodies[1]["health_check"]).To(Equal(map[string]interface{}{
+				"type": "http",
+				"data": map[string]interface{}{"endpoint": "/health", "invocation_timeout": float64(5)},
+			}))
+		})
+	})
+
+	Describe("SwitchRoutes v3", func() {
+		It("return the error of a route that could not be mapped", func() {
+			server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requestPaths = append(requestPaths, r.Method+" "+r.URL.Path)
+				if r.URL.Path == "/v3/domains" {
+					_, _ = w.Write([]byte(`{"resources": [{"guid": "domain-guid", "name": "example.com"}]}`))
+					return
+				}
+				w.WriteHeader(http.StatusServiceUnavailable)
+			})
+			err := resourcesData.SwitchRoutesOnly(context.Background(), "myTestApp-venerable", true, "myTestApp", []map[string]string{{"route": "myapp.example.com"}})
+
+			Expect(err).To(MatchError(ContainSubstring("could not map route myapp.example.com to application myTestApp")))
+			Expect(cli.IsTransientError(err)).To(BeTrue())
+			Expect(requestPaths).To(Equal([]string{"GET /v3/domains", "GET /v3/apps"}))
+		})
+
+		It("only maps the routes if the venerable application does not exist", func() {
+			var appNames []string
+			server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requestPaths = append(requestPaths, r.Method+" "+r.URL.Path)
+				switch r.Method + " " + r.URL.Path {
+				case "GET /v3/domains":
+					_, _ = w.Write([]byte(`{"resources": [{"guid": "domain-guid", "name": "example.com"}]}`))
+				case "GET /v3/apps":
+					appNames = append(appNames, r.URL.Query().Get("names"))
+					if r.URL.Query().Get("names") != "myTestApp" {
+						_, _ = w.Write([]byte(`{"resources": []}`))
+						return
+					}
+					_, _ = w.Write([]byte(`{"resources": [{"guid": "app-guid", "name": "myTestApp", "relationships": {"space": {"data": {"guid": "space-guid"}}}}]}`))
+				case "GET /v3/routes":
+					_, _ = w.Write([]byte(`{"resources": [{"guid": "route-guid", "host": "myapp"}]}`))
+				default:
+					_, _ = w.Write([]byte(`{}`))
+				}
+			})
+			err := resourcesData.SwitchRoutesOnly(context.Background(), "myTestApp-venerable", false, "myTestApp", []map[string]string{{"route": "myapp.example.com"}})
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(appNames).To(Equal([]string{"myTestApp"}))
+			Expect(requestPaths).To(ContainElement("POST /v3/routes/route-guid/destinations"))
 		})
 	})
 
 	Describe("PushApp v3", func() {
-		It("push buildpack with routes", func() {
+		var (
+			appDir      string
+			buildState  string
+			uploadForm  map[string][]string
+			uploadFiles []string
+			uploadedZip []string
+			matchBody   string
+			matchInput  string
+			deployment  string
+			manifest    string
+		)
+
+		BeforeEach(func() {
+			ccv3.PollInterval = time.Millisecond
+			buildState = "STAGED"
+			uploadForm = nil
+			uploadFiles = nil
+			uploadedZip = nil
+			matchBody = `{"resources": []}`
+			deployment = `"state": "DEPLOYED", "status": {"value": "FINALIZED", "reason": "DEPLOYED"}`
+			appDir, _ = ioutil.TempDir("", "puppeteer-app")
+			_ = ioutil.WriteFile(filepath.Join(appDir, "index.html"), []byte("hello"), 0644)
+
+			server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requestPaths = append(requestPaths, r.Method+" "+r.URL.Path)
+				switch r.Method + " " + r.URL.Path {
+				case "POST /v3/packages":
+					body, _ := ioutil.ReadAll(r.Body)
+					requestBody := map[string]interface{}{}
+					_ = json.Unmarshal(body, &requestBody)
+					requestBodies = append(requestBodies, requestBody)
+					_, _ = w.Write([]byte(`{"guid": "package-guid", "state": "AWAITING_UPLOAD"}`))
+				case "POST /v3/packages/package-guid/upload":
+					_ = r.ParseMultipartForm(1024 * 1024)
+					uploadForm = r.MultipartForm.Value
+					for name, files := range r.MultipartForm.File {
+						uploadFiles = append(uploadFiles, name)
+						file, _ := files[0].Open()
+						content, _ := ioutil.ReadAll(file)
+						zipReader, _ := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+						for _, zipFile := range zipReader.File {
+							uploadedZip = append(uploadedZip, zipFile.Name)
+						}
+					}
+					_, _ = w.Write([]byte(`{"guid": "package-guid", "state": "PROCESSING_UPLOAD"}`))
+				case "POST /v3/resource_matches":
+					body, _ := ioutil.ReadAll(r.Body)
+					matchInput = string(body)
+					_, _ = w.Write([]byte(matchBody))
+				case "GET /v3/packages/package-guid":
+					_, _ = w.Write([]byte(`{"guid": "package-guid", "state": "READY"}`))
+				case "POST /v3/builds":
+					_, _ = w.Write([]byte(`{"guid": "build-guid", "state": "STAGING"}`))
+				case "GET /":
+					_, _ = w.Write([]byte(`{"links": {"log_cache": {"href": "http://` + r.Host + `"}}}`))
+				case "GET /api/v1/read/app-guid":
+					_, _ = w.Write([]byte(`{"envelopes": {"batch": [{"timestamp": "1", "tags": {"source_type": "STG"}, "log": {"payload": "ZG93bmxvYWRpbmcgYnVpbGRwYWNr"}}]}}`))
+				case "GET /v3/builds/build-guid":
+					_, _ = w.Write([]byte(`{"guid": "build-guid", "state": "` + buildState + `", "error": "buildpack compile failed", "droplet": {"guid": "droplet-guid"}}`))
+				case "PATCH /v3/apps/app-guid/relationships/current_droplet":
+					body, _ := ioutil.ReadAll(r.Body)
+					requestBody := map[string]interface{}{}
+					_ = json.Unmarshal(body, &requestBody)
+					requestBodies = append(requestBodies, requestBody)
+					_, _ = w.Write([]byte(`{}`))
+				case "GET /v3/apps":
+					_, _ = w.Write([]byte(`{"resources": [{"guid": "app-guid", "name": "myTestApp"}]}`))
+				case "POST /v3/apps":
+					_, _ = w.Write([]byte(`{"guid": "app-guid", "name": "myTestApp"}`))
+				case "PATCH /v3/apps/app-guid/environment_variables":
+					_, _ = w.Write([]byte(`{}`))
+				case "POST /v3/spaces/space-guid/actions/apply_manifest":
+					body, _ := ioutil.ReadAll(r.Body)
+					manifest = string(body)
+					w.WriteHeader(http.StatusAccepted)
+				case "POST /v3/deployments":
+					body, _ := ioutil.ReadAll(r.Body)
+					requestBody := map[string]interface{}{}
+					_ = json.Unmarshal(body, &requestBody)
+					requestBodies = append(requestBodies, requestBody)
+					_, _ = w.Write([]byte(`{"guid": "deployment-guid", "state": "DEPLOYING"}`))
+				case "GET /v3/deployments/deployment-guid":
+					_, _ = w.Write([]byte(`{"guid": "deployment-guid", ` + deployment + `}`))
+				case "POST /v3/deployments/deployment-guid/actions/cancel":
+					_, _ = w.Write([]byte(`{}`))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			})
+			resourcesData.Zipper = &appfiles.ApplicationZipper{}
+			resourcesData.AppFiles = &appfiles.ApplicationFiles{}
+		})
+
+		AfterEach(func() {
+			_ = os.RemoveAll(appDir)
+		})
+
+		It("upload bits, stage them and set the droplet", func() {
 			arguments := &arguments.ParserArguments{
 				AppName: "myTestApp",
+				AppPath: appDir,
 			}
-			err := resourcesData.PushApp(arguments)
+			err := resourcesData.PushApp(context.Background(), &ccv3.App{GUID: "app-guid", Name: "myTestApp"}, arguments)
 
-			argumentsOutput := []string{"v3-push", "myTestApp", "--no-start"}
-			Expect(fakeExecutor.ExecutorCallCount()).To(Equal(1))
-			Expect(fakeExecutor.ExecutorArgumentsOutput()[0]).To(Equal(argumentsOutput))
 			Expect(err).ToNot(HaveOccurred())
+			Expect(fakeExecutor.ExecutorCallCount()).To(Equal(0))
+			Expect(uploadFiles).To(Equal([]string{"bits"}))
+			Expect(uploadedZip).To(ContainElement("index.html"))
+			Expect(uploadForm["resources"]).To(Equal([]string{"[]"}))
+			Expect(requestPaths).To(ContainElement("GET /api/v1/read/app-guid"))
+			Expect(requestPaths[len(requestPaths)-1]).To(Equal("PATCH /v3/apps/app-guid/relationships/current_droplet"))
+			Expect(requestBodies[0]["type"]).To(Equal("bits"))
+			Expect(requestBodies[1]["data"]).To(Equal(map[string]interface{}{"guid": "droplet-guid"}))
 		})
 
-		It("push buildpack without routes", func() {
+		It("set the environment variables before the application is staged", func() {
+			manifestPath := filepath.Join(appDir, "manifest.yml")
+			_ = ioutil.WriteFile(manifestPath, []byte("applications:\n- name: myTestApp\n"), 0644)
+			arguments := &arguments.ParserArguments{
+				AppName:             "myTestApp",
+				AppPath:             appDir,
+				NoRouteManifestPath: manifestPath,
+				Envs:                map[string]string{"key": "value"},
+			}
+			err := resourcesData.PushApplication(context.Background(), "myTestApp-venerable", "space-guid", arguments, nil)
+
+			Expect(err).ToNot(HaveOccurred())
+			indexOf := func(request string) int {
+				for index, requestPath := range requestPaths {
+					if requestPath == request {
+						return index
+					}
+				}
+				return -1
+			}
+			Expect(indexOf("PATCH /v3/apps/app-guid/environment_variables")).To(BeNumerically(">", indexOf("POST /v3/apps")))
+			Expect(indexOf("PATCH /v3/apps/app-guid/environment_variables")).To(BeNumerically("<", indexOf("POST /v3/builds")))
+		})
+
+		It("roll out the staged droplet with a deployment", func() {
+			arguments := &arguments.ParserArguments{
+				AppName:             "myTestApp",
+				AppPath:             appDir,
+				ApplicationManifest: appmanifest.Application{Name: "myTestApp"},
+			}
+			err := resourcesData.RollingPushApplication(context.Background(), "space-guid", arguments)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(requestPaths).ToNot(ContainElement("PATCH /v3/apps/app-guid/relationships/current_droplet"))
+			Expect(requestPaths[len(requestPaths)-1]).To(Equal("GET /v3/deployments/deployment-guid"))
+			Expect(requestBodies[1]["droplet"]).To(Equal(map[string]interface{}{"guid": "droplet-guid"}))
+			Expect(requestBodies[1]["strategy"]).To(Equal("rolling"))
+		})
+
+		It("keep the routes of the running application when the manifest is applied", func() {
 			arguments := &arguments.ParserArguments{
 				AppName: "myTestApp",
-				NoRoute: true,
+				AppPath: appDir,
+				ApplicationManifest: appmanifest.Application{
+					Name:    "myTestApp",
+					Memory:  "256M",
+					Routes:  []map[string]string{{"route": "myapp.example.com"}},
+					NoRoute: true,
+				},
 			}
-			err := resourcesData.PushApp(arguments)
+			err := resourcesData.RollingPushApplication(context.Background(), "space-guid", arguments)
 
-			argumentsOutput := []string{"v3-push", "myTestApp", "--no-start", "--no-route"}
-			Expect(fakeExecutor.ExecutorCallCount()).To(Equal(1))
-			Expect(fakeExecutor.ExecutorArgumentsOutput()[0]).To(Equal(argumentsOutput))
 			Expect(err).ToNot(HaveOccurred())
+			Expect(manifest).To(ContainSubstring("name: myTestApp"))
+			Expect(manifest).To(ContainSubstring("memory: 256M"))
+			Expect(manifest).ToNot(ContainSubstring("no-route"))
+			Expect(manifest).ToNot(ContainSubstring("routes"))
 		})
 
-		It("push buildpack with routes and envs", func() {
-			envsMap := make(map[string]string, 2)
-			envsMap["key"] = "value"
-			envsMap["newKey"] = "newValue"
+		It("cancel a failed deployment", func() {
+			deployment = `"state": "CANCELED", "status": {"value": "FINALIZED", "reason": "CANCELED"}`
+			arguments := &arguments.ParserArguments{
+				AppName:             "myTestApp",
+				AppPath:             appDir,
+				ApplicationManifest: appmanifest.Application{Name: "myTestApp"},
+			}
+			err := resourcesData.RollingPushApplication(context.Background(), "space-guid", arguments)
 
+			Expect(err).To(MatchError(ContainSubstring("deployment phase of application myTestApp failed")))
+			Expect(requestPaths[len(requestPaths)-1]).To(Equal("POST /v3/deployments/deployment-guid/actions/cancel"))
+		})
+
+		It("upload only files that are not cached", func() {
+			_ = ioutil.WriteFile(filepath.Join(appDir, "cached.jar"), []byte("cached"), 0644)
+			matchBody = `{"resources": [{"checksum": {"value": "6b1a1fc8d8ab1dd4e0e2a4e8e3b4c1e2ad5ef5a1"}, "size_in_bytes": 6, "path": "cached.jar", "mode": "644"}]}`
 			arguments := &arguments.ParserArguments{
 				AppName: "myTestApp",
-				Envs:    envsMap,
+				AppPath: appDir,
 			}
-			err := resourcesData.PushApp(arguments)
+			err := resourcesData.PushApp(context.Background(), &ccv3.App{GUID: "app-guid", Name: "myTestApp"}, arguments)
 
-			Expect(fakeExecutor.ExecutorCallCount()).To(Equal(3)) //called for each env
 			Expect(err).ToNot(HaveOccurred())
+			Expect(uploadedZip).To(ContainElement("index.html"))
+			Expect(uploadedZip).ToNot(ContainElement("cached.jar"))
+			Expect(uploadForm["resources"][0]).To(ContainSubstring(`"path":"cached.jar"`))
 		})
 
-		It("push docker image", func() {
+		It("upload without bits if all files are cached", func() {
+			matchBody = `{"resources": [{"checksum": {"value": "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"}, "size_in_bytes": 5, "path": "index.html", "mode": "644"}]}`
+			arguments := &arguments.ParserArguments{
+				AppName: "myTestApp",
+				AppPath: appDir,
+			}
+			err := resourcesData.PushApp(context.Background(), &ccv3.App{GUID: "app-guid", Name: "myTestApp"}, arguments)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(uploadFiles).To(BeEmpty())
+			Expect(uploadForm["resources"][0]).To(ContainSubstring(`"path":"index.html"`))
+		})
+
+		It("upload without bits if all files in the directories are cached", func() {
+			_ = os.Mkdir(filepath.Join(appDir, "static"), 0755)
+			_ = ioutil.WriteFile(filepath.Join(appDir, "static", "app.js"), []byte("hello"), 0644)
+			matchBody = `{"resources": [
+				{"checksum": {"value": "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"}, "size_in_bytes": 5, "path": "index.html", "mode": "644"},
+				{"checksum": {"value": "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"}, "size_in_bytes": 5, "path": "static/app.js", "mode": "644"}]}`
+			arguments := &arguments.ParserArguments{
+				AppName: "myTestApp",
+				AppPath: appDir,
+			}
+			err := resourcesData.PushApp(context.Background(), &ccv3.App{GUID: "app-guid", Name: "myTestApp"}, arguments)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(matchInput).ToNot(ContainSubstring(`"path":"static"`))
+			Expect(uploadFiles).To(BeEmpty())
+			Expect(uploadForm["resources"][0]).To(ContainSubstring(`"path":"static/app.js"`))
+		})
+
+		It("upload the directories with the files that are not cached", func() {
+			_ = os.Mkdir(filepath.Join(appDir, "static"), 0755)
+			_ = ioutil.WriteFile(filepath.Join(appDir, "static", "app.js"), []byte("new"), 0644)
+			matchBody = `{"resources": [{"checksum": {"value": "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"}, "size_in_bytes": 5, "path": "index.html", "mode": "644"}]}`
+			arguments := &arguments.ParserArguments{
+				AppName: "myTestApp",
+				AppPath: appDir,
+			}
+			err := resourcesData.PushApp(context.Background(), &ccv3.App{GUID: "app-guid", Name: "myTestApp"}, arguments)
+
+			Expect(err).ToNot(HaveOccurred())
+			Expect(uploadedZip).To(ContainElement("static/app.js"))
+			Expect(uploadedZip).ToNot(ContainElement("index.html"))
+		})
+
+		It("push docker image without upload", func() {
 			arguments := &arguments.ParserArguments{
 				AppName:        "myTestApp",
 				DockerImage:    "myDockerImage",
 				DockerUserName: "mySecretDockerUser",
 			}
-			err := resourcesData.PushApp(arguments)
-
-			argumentsOutput := []string{"v3-push",
-				"myTestApp",
-				"--no-start",
-				"--docker-image",
-				"myDockerImage",
-				"--docker-username",
-				"mySecretDockerUser"}
-			Expect(fakeExecutor.ExecutorCallCount()).To(Equal(1))
-			Expect(fakeExecutor.ExecutorArgumentsOutput()[0]).To(Equal(argumentsOutput))
+			err := resourcesData.PushApp(context.Background(), &ccv3.App{GUID: "app-guid", Name: "myTestApp"}, arguments)
+
 			Expect(err).ToNot(HaveOccurred())
+			Expect(requestPaths).ToNot(ContainElement("POST /v3/packages/package-guid/upload"))
+			Expect(requestBodies[0]["type"]).To(Equal("docker"))
+			Expect(requestBodies[0]["data"]).To(HaveKeyWithValue("image", "myDockerImage"))
+			Expect(requestBodies[0]["data"]).To(HaveKeyWithValue("username", "mySecretDockerUser"))
+		})
+
+		It("report the failed staging phase", func() {
+			buildState = "FAILED"
+			arguments := &arguments.ParserArguments{
+				AppName: "myTestApp",
+				AppPath: appDir,
+			}
+			err := resourcesData.PushApp(context.Background(), &ccv3.App{GUID: "app-guid", Name: "myTestApp"}, arguments)
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("staging phase of application myTestApp failed"))
+			Expect(err.Error()).To(ContainSubstring("buildpack compile failed"))
+			Expect(requestPaths).ToNot(ContainElement("PATCH /v3/apps/app-guid/relationships/current_droplet"))
 		})
 	})
 })
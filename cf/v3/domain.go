@@ -1,121 +1,41 @@
 package v3
 
 import (
-	"encoding/json"
-	"fmt"
+	"context"
 	"sort"
 	"strings"
-	"time"
-)
-
-type Routes struct {
-	Host   string
-	Domain string
-	Path   string
-}
 
-//DomainResponse reponse while loading domains
-type DomainResponse struct {
-	Pagination struct {
-		TotalResults int `json:"total_results"`
-		TotalPages   int `json:"total_pages"`
-		First        struct {
-			Href string `json:"href"`
-		} `json:"first"`
-		Last struct {
-			Href string `json:"href"`
-		} `json:"last"`
-		Next struct {
-			Href string `json:"href"`
-		} `json:"next"`
-		Previous interface{} `json:"previous"`
-	} `json:"pagination"`
-	Resources []struct {
-		GUID          string    `json:"guid"`
-		CreatedAt     time.Time `json:"created_at"`
-		UpdatedAt     time.Time `json:"updated_at"`
-		Name          string    `json:"name"`
-		Internal      bool      `json:"internal"`
-		Relationships struct {
-			Organization struct {
-				Data interface{} `json:"data"`
-			} `json:"organization"`
-			SharedOrganizations struct {
-				Data []interface{} `json:"data"`
-			} `json:"shared_organizations"`
-		} `json:"relationships"`
-		Links struct {
-			Self struct {
-				Href string `json:"href"`
-			} `json:"self"`
-		} `json:"links"`
-	} `json:"resources"`
-}
+	"github.com/happytobi/cf-puppeteer/cf/ccv3"
 
-func (resource *ResourcesData) GetDomain(domains []map[string]string) (*[]Routes, error) {
-	path := fmt.Sprintf(`/v3/domains`)
+	v2 "github.com/happytobi/cf-puppeteer/cf/v2"
+)
 
-	response, err := resource.getDomain(path)
-	if err != nil {
-		return nil, err
-	}
+//Routes host, domain and path of a manifest route, the same as the legacy push resolves
+type Routes = v2.Routes
 
+//GetDomain resolve host, domain and path of the manifest routes, pages through the domains until all routes are resolved
+func (resource *ResourcesData) GetDomain(ctx context.Context, domains []map[string]string) (*[]Routes, error) {
 	domainGUID := make(map[string]Routes)
-
-	for _, domainRes := range response.Resources {
-		for _, routes := range domains {
-			for _, domain := range routes {
-				dhp := strings.Split(domain, "/")
-				path := ""
-				if len(dhp) > 1 {
-					path = dhp[1]
-				}
-				domain = dhp[0]
-
-				_, exists := domainGUID[domain]
-				if strings.Contains(domain, domainRes.Name) && !exists {
-					hostName := strings.ReplaceAll(domain, domainRes.Name, "")
-					hostName = strings.TrimRight(hostName, ".")
-					newRoute := &Routes{
-						Host:   hostName,
-						Domain: domainRes.Name,
-						Path:   path,
-					}
-					domainGUID[domain] = *newRoute
-				}
-			}
-		}
+	routeCount := 0
+	for _, routes := range domains {
+		routeCount += len(routes)
 	}
 
-	for response.Pagination.Next.Href != "" && len(domainGUID) <= 0 {
-		response, err := resource.getDomain(response.Pagination.Next.Href)
+	path := "/v3/domains"
+	for len(path) > 0 && len(domainGUID) < routeCount {
+		response, err := resource.Client.GetDomainPage(ctx, path)
 		if err != nil {
 			return nil, err
 		}
 
-		for _, domainRes := range response.Resources {
-			for _, routes := range domains {
-				for _, domain := range routes {
-					dhp := strings.Split(domain, "/")
-					path := ""
-					if len(dhp) > 1 {
-						path = dhp[1]
-					}
-					domain = dhp[0]
+		sort.Slice(response.Resources, func(i, j int) bool {
+			return response.Resources[i].Name < response.Resources[j].Name
+		})
+		matchDomains(domainGUID, domains, response.Resources)
 
-					_, exists := domainGUID[domain]
-					if strings.Contains(domain, domainRes.Name) && !exists {
-						hostName := strings.ReplaceAll(domain, domainRes.Name, "")
-						hostName = strings.TrimRight(hostName, ".")
-						newRoute := &Routes{
-							Host:   hostName,
-							Domain: domainRes.Name,
-							Path:   path,
-						}
-						domainGUID[domain] = *newRoute
-					}
-				}
-			}
+		path = ""
+		if response.Pagination.Next != nil {
+			path = response.Pagination.Next.Href
 		}
 	}
 
@@ -124,24 +44,33 @@ func (resource *ResourcesData) GetDomain(domains []map[string]string) (*[]Routes
 		domainsFound = append(domainsFound, v)
 	}
 
-	return &domainsFound, err
+	return &domainsFound, nil
 }
 
-func (resource *ResourcesData) getDomain(path string) (*DomainResponse, error) {
-	var response DomainResponse
-
-	jsonResult, err := resource.Cli.GetJSON(path)
-	if err != nil {
-		return nil, err
-	}
+//matchDomains adds the routes that belong to one of the domains
+func matchDomains(domainGUID map[string]Routes, domains []map[string]string, domainResources []ccv3.Domain) {
+	for _, domainRes := range domainResources {
+		for _, routes := range domains {
+			for _, route := range routes {
+				dhp := strings.SplitN(route, "/", 2)
+				path := ""
+				if len(dhp) > 1 {
+					path = dhp[1]
+				}
+				domain := dhp[0]
 
-	err = json.Unmarshal([]byte(jsonResult), &response)
-	if err != nil {
-		return nil, err
+				_, exists := domainGUID[route]
+				if exists || (domain != domainRes.Name && !strings.HasSuffix(domain, "."+domainRes.Name)) {
+					continue
+				}
+				hostName := strings.TrimSuffix(domain, domainRes.Name)
+				hostName = strings.TrimRight(hostName, ".")
+				domainGUID[route] = Routes{
+					Host:   hostName,
+					Domain: domainRes.Name,
+					Path:   path,
+				}
+			}
+		}
 	}
-
-	sort.Slice(response.Resources, func(i, j int) bool {
-		return response.Resources[i].Name < response.Resources[j].Name
-	})
-	return &response, nil
 }
@@ -1,9 +1,15 @@
 package v3_test
 
 import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"code.cloudfoundry.org/cli/plugin/pluginfakes"
+	"github.com/happytobi/cf-puppeteer/cf/ccv3"
 	"github.com/happytobi/cf-puppeteer/cf/cli"
 	v3 "github.com/happytobi/cf-puppeteer/cf/v3"
 
@@ -20,16 +26,23 @@ var _ = Describe("cf-domain test", func() {
 	var (
 		cliConn       *pluginfakes.FakeCliConnection
 		resourcesData *v3.ResourcesData
+		server        *httptest.Server
+		requestCount  int
 	)
 
 	BeforeEach(func() {
+		requestCount = 0
 		cliConn = &pluginfakes.FakeCliConnection{}
-		resourcesData = &v3.ResourcesData{Connection: cliConn, Cli: cli.NewCli(cliConn, false)}
+		resourcesData = &v3.ResourcesData{Connection: cliConn, Cli: cli.NewCli(cliConn, false), Client: ccv3.NewClient(cli.NewHttpClient(cliConn, false, 30, false))}
+	})
 
+	AfterEach(func() {
+		server.Close()
 	})
-	Describe("Domain actions with curl v3 api", func() {
+
+	Describe("Domain actions with v3 api", func() {
 		It("use v3 domain api", func() {
-			response := []string{`{
+			firstPage := `{
 				"pagination": {
 				  "total_results": 3,
 				  "total_pages": 2,
@@ -95,14 +108,27 @@ var _ = Describe("cf-domain test", func() {
 				  }
 				]
 			  }
-			`}
+			`
+			secondPage := `{
+				"pagination": {"total_results": 3, "total_pages": 2, "next": null},
+				"resources": [{"guid": "3a5d3d89-3f89-4f05-8188-8a2b298c79d9", "name": "other-domain.com", "internal": false}]
+			}`
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requestCount++
+				if r.URL.Query().Get("page") == "2" {
+					_, _ = w.Write([]byte(secondPage))
+					return
+				}
+				_, _ = w.Write([]byte(strings.ReplaceAll(firstPage, "https://api.example.org", "http://"+r.Host)))
+			}))
+			cliConn.ApiEndpointReturns(server.URL, nil)
 
-			cliConn.CliCommandWithoutTerminalOutputReturns(response, nil)
+			var routes = []map[string]string{0: {"route": "url.test-domain.com"}, 1: {"route": "foo.example.com"}, 2: {"route": "boo.example.com/api"}, 3: {"route": "www.other-domain.com"}}
+			domainResponse, err := resourcesData.GetDomain(context.Background(), routes)
 
-			var routes = []map[string]string{0: {"key": "route", "value": "url.test-domain.com"}, 1: {"key": "route", "value": "foo.example.com"}, 2: {"key": "route", "value": "boo.example.com/api"}}
-			domainResponse, err := resourcesData.GetDomain(routes)
-
-			Expect(cliConn.CliCommandWithoutTerminalOutputCallCount()).To(Equal(1))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(requestCount).To(Equal(2))
+			Expect(cliConn.CliCommandWithoutTerminalOutputCallCount()).To(Equal(0))
 
 			checkMap := make(map[string]string, len(*domainResponse))
 			checkPath := make(map[string]string, len(*domainResponse))
@@ -114,12 +140,28 @@ var _ = Describe("cf-domain test", func() {
 			Expect(checkMap["foo"]).To(Equal("example.com"))
 			Expect(checkMap["url"]).To(Equal("test-domain.com"))
 			Expect(checkMap["boo"]).To(Equal("example.com"))
+			Expect(checkMap["www"]).To(Equal("other-domain.com"))
 
 			Expect(checkPath["foo"]).To(Equal(""))
 			Expect(checkPath["url"]).To(Equal(""))
 			Expect(checkPath["boo"]).To(Equal("api"))
+		})
+
+		It("stop paging when all routes are resolved", func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requestCount++
+				_, _ = w.Write([]byte(fmt.Sprintf(`{
+					"pagination": {"total_results": 2, "total_pages": 2, "next": {"href": "%s/v3/domains?page=2"}},
+					"resources": [{"guid": "guid", "name": "example.com", "internal": false}]
+				}`, "http://"+r.Host)))
+			}))
+			cliConn.ApiEndpointReturns(server.URL, nil)
+
+			domainResponse, err := resourcesData.GetDomain(context.Background(), []map[string]string{{"route": "foo.example.com"}})
 
 			Expect(err).ToNot(HaveOccurred())
+			Expect(requestCount).To(Equal(1))
+			Expect(*domainResponse).To(Equal([]v3.Routes{{Host: "foo", Domain: "example.com"}}))
 		})
 	})
 })
@@ -0,0 +1,235 @@
+package v3
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/bytefmt"
+	"code.cloudfoundry.org/cli/cf/models"
+	"github.com/happytobi/cf-puppeteer/arguments"
+	"github.com/happytobi/cf-puppeteer/cf/ccv3"
+	"github.com/happytobi/cf-puppeteer/ui"
+	"github.com/pkg/errors"
+)
+
+//uploadProgressStep percentage between two upload progress messages
+const uploadProgressStep = 20
+
+//pushPhaseError adds the failed push phase to the error
+func pushPhaseError(err error, phase string, appName string) error {
+	return errors.Wrap(err, fmt.Sprintf("%s phase of application %s failed", phase, appName))
+}
+
+//appBitsPath returns the path of the application bits, out of the arguments or relative to the manifest
+func appBitsPath(parsedArguments *arguments.ParserArguments) string {
+	if len(parsedArguments.AppPath) > 0 {
+		return parsedArguments.AppPath
+	}
+	manifestPath := parsedArguments.ApplicationManifest.Path
+	if len(manifestPath) == 0 {
+		return "."
+	}
+	if filepath.IsAbs(manifestPath) || len(parsedArguments.ManifestPath) == 0 {
+		return manifestPath
+	}
+	return filepath.Join(filepath.Dir(parsedArguments.ManifestPath), manifestPath)
+}
+
+//createPackage creates a docker package with the image or a bits package for the application files
+func (resource *ResourcesData) createPackage(ctx context.Context, app *ccv3.App, parsedArguments *arguments.ParserArguments) (*ccv3.Package, error) {
+	if len(parsedArguments.DockerImage) == 0 {
+		return resource.Client.CreatePackage(ctx, app.GUID, nil)
+	}
+
+	resource.Output.Say("create docker package with image %s for application %s", parsedArguments.DockerImage, app.Name)
+	docker := &ccv3.DockerData{Image: parsedArguments.DockerImage}
+	if len(parsedArguments.DockerUserName) > 0 {
+		docker.Username = parsedArguments.DockerUserName
+		docker.Password = os.Getenv("CF_DOCKER_PASSWORD")
+	}
+	return resource.Client.CreatePackage(ctx, app.GUID, docker)
+}
+
+//uploadBits zip the application files that are not cached by the cloud controller and upload them to the package
+func (resource *ResourcesData) uploadBits(ctx context.Context, app *ccv3.App, appPackage *ccv3.Package, bitsPath string) error {
+	//archives like jar files have to be extracted to match their files
+	appDir := bitsPath
+	if resource.Zipper.IsZipFile(bitsPath) {
+		extractDir, err := ioutil.TempDir("", "puppeteer-app")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(extractDir)
+
+		err = resource.Zipper.Unzip(bitsPath, extractDir)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("could not extract application archive %s", bitsPath))
+		}
+		appDir = extractDir
+	}
+
+	appFiles, err := resource.AppFiles.AppFilesInDir(appDir)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("could not read application files of %s", bitsPath))
+	}
+
+	matched, unmatchedFiles := resource.matchResources(ctx, app, appDir, appFiles)
+	if len(unmatchedFiles) == 0 {
+		resource.Output.Say("all files of application %s are cached, nothing to upload", app.Name)
+		return resource.Client.UploadPackageBits(ctx, appPackage.GUID, matched, nil, 0, nil)
+	}
+
+	uploadDir, err := ioutil.TempDir("", "puppeteer-upload")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(uploadDir)
+
+	err = resource.AppFiles.CopyFiles(unmatchedFiles, appDir, uploadDir)
+	if err != nil {
+		return err
+	}
+
+	zipFile, err := ioutil.TempFile("", "puppeteer-bits-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(zipFile.Name())
+	defer zipFile.Close()
+
+	resource.Output.Say("zip application files of %s", bitsPath)
+	err = resource.Zipper.Zip(uploadDir, zipFile)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("could not zip application files of %s", bitsPath))
+	}
+
+	size, err := resource.Zipper.GetZipSize(zipFile)
+	if err != nil {
+		return err
+	}
+	_, err = zipFile.Seek(0, 0)
+	if err != nil {
+		return err
+	}
+
+	resource.Output.Say("upload %s of application %s", bytefmt.ByteSize(uint64(size)), app.Name)
+	start := time.Now()
+	nextProgress := uploadProgressStep
+	err = resource.Client.UploadPackageBits(ctx, appPackage.GUID, matched, zipFile, size, func(uploaded int64, total int64) {
+		if total <= 0 {
+			return
+		}
+		percentage := int(uploaded * 100 / total)
+		if percentage < nextProgress {
+			return
+		}
+		resource.Output.Say("uploaded %d%% (%s of %s) of application %s", percentage, bytefmt.ByteSize(uint64(uploaded)), bytefmt.ByteSize(uint64(total)), app.Name)
+		nextProgress = (percentage/uploadProgressStep + 1) * uploadProgressStep
+	})
+	if err != nil {
+		return err
+	}
+	resource.Output.Say("upload of application %s finished in %s", app.Name, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+//matchResources returns the resources that are cached by the cloud controller and the files that have to be uploaded,
+//if the resource match fails all files will be uploaded
+func (resource *ResourcesData) matchResources(ctx context.Context, app *ccv3.App, appDir string, appFiles []models.AppFileFields) ([]ccv3.Resource, []models.AppFileFields) {
+	var resources []ccv3.Resource
+	for _, appFile := range appFiles {
+		//directories have no checksum
+		if appFile.Sha1 == "0" {
+			continue
+		}
+		mode := "644"
+		fileInfo, err := os.Stat(filepath.Join(appDir, filepath.FromSlash(appFile.Path)))
+		if err == nil {
+			mode = fmt.Sprintf("%o", fileInfo.Mode().Perm())
+		}
+		resources = append(resources, ccv3.Resource{
+			Checksum:    ccv3.Checksum{Value: appFile.Sha1},
+			SizeInBytes: appFile.Size,
+			Path:        appFile.Path,
+			Mode:        mode,
+		})
+	}
+	if len(resources) == 0 {
+		return nil, appFiles
+	}
+
+	matched, err := resource.Client.MatchResources(ctx, resources)
+	if err != nil {
+		resource.Output.Warn("could not match cached resources of application %s, upload all files: %s", app.Name, err)
+		return nil, appFiles
+	}
+
+	matchedPaths := make(map[string]bool, len(matched))
+	var matchedSize int64
+	for _, matchedResource := range matched {
+		matchedPaths[matchedResource.Path] = true
+		matchedSize += matchedResource.SizeInBytes
+	}
+
+	//directories are not part of the resource match, they are only uploaded with the files that are not cached
+	var unmatchedFiles, directories []models.AppFileFields
+	for _, appFile := range appFiles {
+		if appFile.Sha1 == "0" {
+			directories = append(directories, appFile)
+		} else if !matchedPaths[appFile.Path] {
+			unmatchedFiles = append(unmatchedFiles, appFile)
+		}
+	}
+	resource.Output.Say("%d of %d files (%s) of application %s are cached and will not be uploaded", len(matched), len(resources), bytefmt.ByteSize(uint64(matchedSize)), app.Name)
+	if len(unmatchedFiles) == 0 {
+		return matched, nil
+	}
+	return matched, append(directories, unmatchedFiles...)
+}
+
+//stagePackage create a build of the package, prints the staging logs and returns the guid of the staged droplet
+func (resource *ResourcesData) stagePackage(ctx context.Context, app *ccv3.App, appPackage *ccv3.Package) (string, error) {
+	resource.Output.Say("stage application %s", app.Name)
+	start := time.Now()
+	build, err := resource.Client.CreateBuild(ctx, appPackage.GUID)
+	if err != nil {
+		return "", err
+	}
+
+	//staging logs are optional, the staging result does not depend on them
+	logCacheURL, err := resource.Client.GetLogCacheURL(ctx)
+	if err != nil {
+		ui.DebugMessage("could not read staging logs of application %s: %s", app.Name, err)
+	}
+	logStartTime := start.UnixNano()
+	printStagingLogs := func() {
+		if len(logCacheURL) == 0 {
+			return
+		}
+		messages, err := resource.Client.ReadLogs(ctx, logCacheURL, app.GUID, logStartTime)
+		if err != nil {
+			ui.DebugMessage("could not read staging logs of application %s: %s", app.Name, err)
+			return
+		}
+		for _, message := range messages {
+			logStartTime = message.Timestamp + 1
+			if message.SourceType == "STG" {
+				resource.Output.Say("   %s", message.Message)
+			}
+		}
+	}
+
+	build, err = resource.Client.WaitForBuild(ctx, build.GUID, printStagingLogs)
+	if err != nil {
+		return "", err
+	}
+	if build.Droplet == nil {
+		return "", fmt.Errorf("build %s has no droplet", build.GUID)
+	}
+	resource.Output.Say("staging of application %s finished in %s", app.Name, time.Since(start).Round(time.Second))
+	return build.Droplet.GUID, nil
+}
@@ -3,128 +3,208 @@ package v3
 import (
 	"code.cloudfoundry.org/cli/cf/appfiles"
 	"code.cloudfoundry.org/cli/plugin"
+	"context"
 	"fmt"
 	"github.com/happytobi/cf-puppeteer/arguments"
+	"github.com/happytobi/cf-puppeteer/cf/ccv3"
 	"github.com/happytobi/cf-puppeteer/cf/cli"
 	v2 "github.com/happytobi/cf-puppeteer/cf/v2"
+	"github.com/happytobi/cf-puppeteer/manifest"
 	"github.com/happytobi/cf-puppeteer/ui"
 	"github.com/pkg/errors"
-	"strconv"
+	"os"
+	"strings"
 )
 
 //Push interface with all v3 actions
 type Push interface {
-	PushApplication(venAppName string, spaceGUID string, parsedArguments *arguments.ParserArguments, v2Resources v2.Resources) error
-	SwitchRoutesOnly(venAppName string, appName string, routes []map[string]string) error
+	PushApplication(ctx context.Context, venAppName string, spaceGUID string, parsedArguments *arguments.ParserArguments, v2Resources v2.Resources) error
+	SwitchRoutesOnly(ctx context.Context, venAppName string, venAppExists bool, appName string, routes []map[string]string) error
+	RollingPushApplication(ctx context.Context, spaceGUID string, parsedArguments *arguments.ParserArguments) error
 }
 
 //ResourcesData internal struct with connection an tracing options etc
 type ResourcesData struct {
-	zipper     appfiles.Zipper
+	Zipper     appfiles.Zipper
+	AppFiles   appfiles.AppFiles
 	Cli        cli.Calls
-	httpClient cli.HttpCalls
+	Client     *ccv3.Client
 	Connection plugin.CliConnection
 	Executor   cli.CfExecutor
+	Output     ui.Prefixed
 }
 
 //NewV3Push constructor
 func NewV3Push(conn plugin.CliConnection, traceLogging bool) *ResourcesData {
 	return &ResourcesData{
-		zipper:     &appfiles.ApplicationZipper{},
+		Zipper:     &appfiles.ApplicationZipper{},
+		AppFiles:   &appfiles.ApplicationFiles{},
 		Cli:        cli.NewCli(conn, traceLogging),
-		httpClient: cli.NewHttpClient(conn, traceLogging, 30, true),
+		Client:     ccv3.NewClientFromConnection(conn, traceLogging),
 		Connection: conn,
 		Executor:   cli.NewExecutor(traceLogging),
 	}
-
 }
 
 //PushApplication call all methods to push a complete application
-func (resource *ResourcesData) PushApplication(venAppName, spaceGUID string, parsedArguments *arguments.ParserArguments, v2Resources v2.Resources) error {
+func (resource *ResourcesData) PushApplication(ctx context.Context, venAppName, spaceGUID string, parsedArguments *arguments.ParserArguments, v2Resources v2.Resources) error {
 
-	ui.Say("create application %s", parsedArguments.AppName)
-	err := resource.CreateApp(parsedArguments)
+	resource.Output.Say("create application %s", parsedArguments.AppName)
+	app, err := resource.CreateApp(ctx, spaceGUID, parsedArguments)
 	if err != nil {
 		return err
 	}
 
-	ui.Say("generate manifest without routes...")
+	resource.Output.Say("apply manifest file without routes to application %s", parsedArguments.AppName)
+	err = resource.AssignAppManifest(ctx, spaceGUID, parsedArguments.NoRouteManifestPath)
+	if err != nil {
+		return err
+	}
 
-	ui.Say("apply manifest file")
-	err = resource.AssignAppManifest(parsedArguments.NoRouteManifestPath)
+	//the environment variables have to be set before the application is staged
+	err = resource.SetEnvironmentVariables(ctx, app, parsedArguments.Envs)
 	if err != nil {
 		return err
 	}
 
-	ui.Say("push application")
-	err = resource.PushApp(parsedArguments)
+	resource.Output.Say("push application %s", parsedArguments.AppName)
+	err = resource.PushApp(ctx, app, parsedArguments)
 	if err != nil {
 		return err
 	}
 
-	ui.Say("set health-check with type: %s for application %s", parsedArguments.HealthCheckType, parsedArguments.AppName)
-	err = resource.SetHealthCheck(parsedArguments.AppName, parsedArguments.HealthCheckType, parsedArguments.HealthCheckHTTPEndpoint, parsedArguments.InvocationTimeout, parsedArguments.Process)
+	resource.Output.Say("set health-check with type: %s for application %s", parsedArguments.HealthCheckType, parsedArguments.AppName)
+	err = resource.SetHealthCheck(ctx, app, parsedArguments.HealthCheckType, parsedArguments.HealthCheckHTTPEndpoint, parsedArguments.InvocationTimeout, parsedArguments.Process)
 	if err != nil {
 		return err
 	}
-	ui.Ok()
+	resource.Output.Ok()
 
 	return nil
 }
 
+//RollingPushApplication stage a new droplet for the existing application and roll it out with a deployment of the
+//cloud controller, the application keeps its guid. A failed deployment will be canceled
+func (resource *ResourcesData) RollingPushApplication(ctx context.Context, spaceGUID string, parsedArguments *arguments.ParserArguments) error {
+	app, err := resource.Client.GetApp(ctx, parsedArguments.AppName, spaceGUID)
+	if err != nil {
+		return err
+	}
+
+	//no-route would remove all routes of the running application, so the manifest leaves the routes out completely
+	manifestPath, err := manifest.GenerateApplicationKeepRoutesYml(parsedArguments.ApplicationManifest)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(manifestPath)
+
+	resource.Output.Say("apply manifest file without route changes to application %s", parsedArguments.AppName)
+	err = resource.AssignAppManifest(ctx, spaceGUID, manifestPath)
+	if err != nil {
+		return err
+	}
+
+	err = resource.SetEnvironmentVariables(ctx, app, parsedArguments.Envs)
+	if err != nil {
+		return err
+	}
+
+	resource.Output.Say("set health-check with type: %s for application %s", parsedArguments.HealthCheckType, parsedArguments.AppName)
+	err = resource.SetHealthCheck(ctx, app, parsedArguments.HealthCheckType, parsedArguments.HealthCheckHTTPEndpoint, parsedArguments.InvocationTimeout, parsedArguments.Process)
+	if err != nil {
+		return err
+	}
+
+	resource.Output.Say("push application %s", parsedArguments.AppName)
+	droplet, err := resource.StageApp(ctx, app, parsedArguments)
+	if err != nil {
+		return err
+	}
+
+	resource.Output.Say("roll out droplet %s to application %s", droplet, parsedArguments.AppName)
+	deployment, err := resource.Client.CreateDeployment(ctx, app.GUID, droplet)
+	if err != nil {
+		return pushPhaseError(err, "deployment", app.Name)
+	}
+	lastState := ""
+	_, err = resource.Client.WaitForDeployment(ctx, deployment.GUID, func(deployment *ccv3.Deployment) {
+		state := deployment.State + " " + deployment.Status.Reason
+		if state != lastState {
+			resource.Output.Say("deployment of application %s is %s", app.Name, strings.ToLower(strings.TrimSpace(state)))
+			lastState = state
+		}
+	})
+	if err != nil {
+		//the deployment is canceled even if the context is done, so the instances go back to the previous droplet
+		cancelErr := resource.Client.CancelDeployment(context.Background(), deployment.GUID)
+		if cancelErr != nil {
+			resource.Output.Warn("could not cancel deployment %s of application %s - error: %s", deployment.GUID, app.Name, cancelErr)
+		}
+		return pushPhaseError(err, "deployment", app.Name)
+	}
+	resource.Output.Ok()
+	return nil
+}
+
 //SwitchRoutes switch route interface method to provide switch routes only option
-func (resource *ResourcesData) SwitchRoutesOnly(venAppName string, appName string, routes []map[string]string) (err error) {
-	return resource.SwitchRoutes(venAppName, appName, routes)
+func (resource *ResourcesData) SwitchRoutesOnly(ctx context.Context, venAppName string, venAppExists bool, appName string, routes []map[string]string) (err error) {
+	return resource.SwitchRoutes(ctx, venAppName, venAppExists, appName, routes)
 }
 
-//SwitchRoutes add new routes and switch "old" one from venerable app to the one
-func (resource *ResourcesData) SwitchRoutes(venAppName string, appName string, routes []map[string]string) (err error) {
-	domains, err := resource.GetDomain(routes)
+//SwitchRoutes add new routes and switch "old" one from venerable app to the one, the routes are only removed from the
+//venerable app if it exists
+func (resource *ResourcesData) SwitchRoutes(ctx context.Context, venAppName string, venAppExists bool, appName string, routes []map[string]string) (err error) {
+	domains, err := resource.GetDomain(ctx, routes)
 	if err != nil {
 		return err
 	}
 
-	ui.Say("map routes to new application %s", appName)
+	resource.Output.Say("map routes to new application %s", appName)
 	for _, route := range *domains {
-		err = resource.MapRoute(appName, route.Host, route.Domain)
+		err = resource.MapRoute(ctx, appName, route.Host, route.Domain, route.Path)
 		if err != nil {
-			//loop through
-			ui.Warn("could not map route %s.%s to application", route.Host, route.Domain, appName)
+			return errors.Wrap(err, fmt.Sprintf("could not map route %s.%s to application %s", route.Host, route.Domain, appName))
 		}
 	}
 
-	ui.Say("remove routes from venerable application %s", venAppName)
-	for _, route := range *domains {
-		err = resource.UnMapRoute(venAppName, route.Host, route.Domain)
-		if err != nil {
-			//loop through
-			ui.Warn("could not remove route %s.%s from application", route.Host, route.Domain, venAppName)
+	if venAppExists {
+		resource.Output.Say("remove routes from venerable application %s", venAppName)
+		for _, route := range *domains {
+			err = resource.UnMapRoute(ctx, venAppName, route.Host, route.Domain, route.Path)
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("could not remove route %s.%s from application %s", route.Host, route.Domain, venAppName))
+			}
 		}
 	}
 
 	return nil
 }
 
-// SetHealthCheckV3 sets the health check for the specified application using the given health check configuration
-func (resource *ResourcesData) SetHealthCheck(appName string, healthCheckType string, healthCheckHTTPEndpoint string, invocationTimeout int, process string) (err error) {
+//SetHealthCheck sets the health check of the application process, web is used if no process type was passed
+func (resource *ResourcesData) SetHealthCheck(ctx context.Context, app *ccv3.App, healthCheckType string, healthCheckHTTPEndpoint string, invocationTimeout int, process string) (err error) {
 	if healthCheckType == "" {
 		return nil
 	}
 
-	args := []string{"v3-set-health-check", appName}
+	processType := process
+	if len(processType) == 0 {
+		processType = "web"
+	}
+
+	appProcess, err := resource.Client.GetAppProcess(ctx, app.GUID, processType)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("could not find process %s of application %s", processType, app.Name))
+	}
 
+	healthCheck := ccv3.HealthCheck{Type: healthCheckType}
 	if healthCheckType == "http" && healthCheckHTTPEndpoint != "" {
-		args = append(args, healthCheckType, "--endpoint", healthCheckHTTPEndpoint)
+		healthCheck.Data.Endpoint = &healthCheckHTTPEndpoint
 		if invocationTimeout >= 0 {
-			args = append(args, "--invocation-timeout", strconv.Itoa(invocationTimeout))
+			healthCheck.Data.InvocationTimeout = &invocationTimeout
 		}
-	} else if process != "" && healthCheckType == "process" {
-		args = append(args, healthCheckType, "--process", process)
-	} else if healthCheckType == "port" {
-		args = append(args, healthCheckType)
 	}
 
-	err = resource.Executor.Execute(args)
+	err = resource.Client.UpdateProcessHealthCheck(ctx, appProcess.GUID, healthCheck)
 	if err != nil {
 		return errors.Wrap(err, fmt.Sprintf("could not set healthcheck with type: %s - endpoint: %s - invocationTimeout %v", healthCheckType, healthCheckHTTPEndpoint, invocationTimeout))
 	}
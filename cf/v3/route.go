@@ -1,27 +1,81 @@
 package v3
 
 import (
-	"fmt"
+	"context"
+	"github.com/happytobi/cf-puppeteer/cf/ccv3"
+	"github.com/happytobi/cf-puppeteer/ui"
 )
 
-//MapRoute map route to application
-func (resource *ResourcesData) MapRoute(appName string, host string, domain string) (err error) {
-	args := []string{"map-route", appName, domain, "--hostname", host}
-	fmt.Printf("map route %v", args)
-	err = resource.Executor.Execute(args)
+//MapRoute map route to application, the route will be created if it does not exist
+func (resource *ResourcesData) MapRoute(ctx context.Context, appName string, host string, domain string, path string) (err error) {
+	ui.DebugMessage("map route %s.%s/%s to application %s", host, domain, path, appName)
+	app, domainGUID, err := resource.routeTarget(ctx, appName, domain)
 	if err != nil {
 		return err
 	}
-	return nil
+
+	route, err := resource.Client.GetRoute(ctx, domainGUID, host, routePath(path))
+	if err == ccv3.ErrRouteNotFound {
+		route, err = resource.Client.CreateRoute(ctx, app.Relationships.Space.Data.GUID, domainGUID, host, routePath(path))
+	}
+	if err != nil {
+		return err
+	}
+	return resource.Client.MapRoute(ctx, route.GUID, app.GUID)
 }
 
 //UnMapRoute remove route from application
-func (resource *ResourcesData) UnMapRoute(appName string, host string, domain string) (err error) {
-	args := []string{"unmap-route", appName, domain, "--hostname", host}
-	fmt.Printf("map route %v", args)
-	err = resource.Executor.Execute(args)
+func (resource *ResourcesData) UnMapRoute(ctx context.Context, appName string, host string, domain string, path string) (err error) {
+	ui.DebugMessage("unmap route %s.%s/%s from application %s", host, domain, path, appName)
+	app, domainGUID, err := resource.routeTarget(ctx, appName, domain)
+	if err != nil {
+		return err
+	}
+
+	route, err := resource.Client.GetRoute(ctx, domainGUID, host, routePath(path))
+	if err != nil {
+		return err
+	}
+
+	destinations, err := resource.Client.GetRouteDestinations(ctx, route.GUID)
 	if err != nil {
 		return err
 	}
+	for _, destination := range destinations {
+		if destination.App.GUID != app.GUID {
+			continue
+		}
+		err = resource.Client.UnmapRoute(ctx, route.GUID, destination.GUID)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+//routeTarget returns the application in the current space and the guid of the domain
+func (resource *ResourcesData) routeTarget(ctx context.Context, appName string, domain string) (*ccv3.App, string, error) {
+	space, err := resource.Connection.GetCurrentSpace()
+	if err != nil {
+		return nil, "", err
+	}
+
+	app, err := resource.Client.GetApp(ctx, appName, space.Guid)
+	if err != nil {
+		return nil, "", err
+	}
+
+	domainResource, err := resource.Client.GetDomain(ctx, domain)
+	if err != nil {
+		return nil, "", err
+	}
+	return app, domainResource.GUID, nil
+}
+
+//routePath the api expects route paths with a leading slash
+func routePath(path string) string {
+	if len(path) == 0 || path[0] == '/' {
+		return path
+	}
+	return "/" + path
+}
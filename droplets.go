@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/happytobi/cf-puppeteer/arguments"
+	"github.com/happytobi/cf-puppeteer/cf/ccv3"
+	"github.com/happytobi/cf-puppeteer/rewind"
+	"github.com/happytobi/cf-puppeteer/ui"
+
+	v2 "github.com/happytobi/cf-puppeteer/cf/v2"
+)
+
+//previousDropletAnnotation annotation of the application with the guid of the droplet a droplet rollback goes back to
+const previousDropletAnnotation = journalLabelPrefix + "previous-droplet"
+
+//ErrNoPreviousDroplet error when the application has no droplet to roll back to
+var ErrNoPreviousDroplet = errors.New("no previous droplet found, deploy the application with --keep-droplet first")
+
+//getKeepDropletAction copies the droplet of the venerable application to the new application before the venerable
+//action deletes it, the copy is remembered as annotation of the application
+func getKeepDropletAction(deployment *applicationDeployment) rewind.Action {
+	appRepo := deployment.appRepo
+	appName := deployment.parsedArguments.AppName
+	venName := deployment.venName
+	output := deployment.output
+
+	return rewind.Action{
+		Name:  "keep previous droplet",
+		Retry: deployment.retryPolicy(),
+		Forward: func(ctx context.Context) error {
+			venApp, err := appRepo.v2Resources.GetAppMetadata(ctx, venName)
+			if err == v2.ErrAppNotFound {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			app, err := appRepo.v2Resources.GetAppMetadata(ctx, appName)
+			if err != nil {
+				return err
+			}
+
+			droplet, err := appRepo.v3Client.GetCurrentDroplet(ctx, venApp.Metadata.GUID)
+			if err != nil {
+				return err
+			}
+			output.Say("copy droplet %s of application %s to application %s", droplet.GUID, venName, appName)
+			copiedDroplet, err := appRepo.v3Client.CopyDroplet(ctx, droplet.GUID, app.Metadata.GUID)
+			if err != nil {
+				return err
+			}
+			copiedDroplet, err = appRepo.v3Client.WaitForDroplet(ctx, copiedDroplet.GUID)
+			if err != nil {
+				return err
+			}
+
+			metadata := ccv3.Metadata{Annotations: map[string]string{previousDropletAnnotation: copiedDroplet.GUID}}
+			return appRepo.v3Client.UpdateAppMetadata(ctx, app.Metadata.GUID, metadata)
+		},
+	}
+}
+
+//getDropletRollbackActions rolls the application back to its previous droplet with a rolling deployment, the droplet
+//that was replaced becomes the previous droplet
+func getDropletRollbackActions(appRepo *ApplicationRepo, rollbackArguments *arguments.RollbackArguments) []rewind.Action {
+	appName := rollbackArguments.AppName
+	retry := newRetryPolicy(ui.Prefixed{})
+	var app *ccv3.App
+	var currentDroplet *ccv3.Droplet
+	var deployment *ccv3.Deployment
+
+	return []rewind.Action{
+		{
+			Name:    "get previous droplet",
+			Retry:   retry,
+			Timeout: stepTimeout,
+			Forward: func(ctx context.Context) error {
+				space, err := appRepo.conn.GetCurrentSpace()
+				if err != nil {
+					return err
+				}
+				app, err = appRepo.v3Client.GetApp(ctx, appName, space.Guid)
+				if err != nil {
+					return err
+				}
+				if len(app.Metadata.Annotations[previousDropletAnnotation]) == 0 {
+					return ErrNoPreviousDroplet
+				}
+				currentDroplet, err = appRepo.v3Client.GetCurrentDroplet(ctx, app.GUID)
+				return err
+			},
+		},
+		{
+			Name: "deploy previous droplet",
+			Forward: func(ctx context.Context) error {
+				previousDroplet := app.Metadata.Annotations[previousDropletAnnotation]
+				ui.Say("roll out droplet %s to application %s", previousDroplet, appName)
+				var err error
+				deployment, err = appRepo.v3Client.CreateDeployment(ctx, app.GUID, previousDroplet)
+				if err != nil {
+					return err
+				}
+				_, err = appRepo.v3Client.WaitForDeployment(ctx, deployment.GUID, nil)
+				return err
+			},
+			ReversePrevious: func(ctx context.Context) error {
+				if deployment == nil {
+					return nil
+				}
+				ui.FailedMessage("rollout of the previous droplet failed... cancel it")
+				return appRepo.v3Client.CancelDeployment(ctx, deployment.GUID)
+			},
+		},
+		// the replaced droplet becomes the previous one, another rollback goes back to it
+		{
+			Name:    "remember replaced droplet",
+			Retry:   retry,
+			Timeout: stepTimeout,
+			Forward: func(ctx context.Context) error {
+				metadata := ccv3.Metadata{Annotations: map[string]string{previousDropletAnnotation: currentDroplet.GUID}}
+				return appRepo.v3Client.UpdateAppMetadata(ctx, app.GUID, metadata)
+			},
+		},
+	}
+}
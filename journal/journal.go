@@ -0,0 +1,189 @@
+package journal
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+//DefaultPath journal file in the working directory
+const DefaultPath = ".cf-puppeteer-journal.json"
+
+//fileMode the journal is only readable by the owner
+const fileMode = 0600
+
+var (
+	//ErrJournalExists error when a new deployment would overwrite the journal of an interrupted deployment
+	ErrJournalExists = errors.New("a journal of an interrupted deployment exists, continue it with --resume or undo it with --rollback")
+	//ErrNoJournal error when there is no journal to resume or roll back
+	ErrNoJournal = errors.New("no journal of an interrupted deployment found")
+)
+
+//Journal persisted progress of a deployment, it is written after every completed step.
+//The args are needed to resume the deployment and may contain secrets passed with --var, so only the owner can read the file
+type Journal struct {
+	Args         []string       `json:"args"`
+	StartedAt    time.Time      `json:"started_at"`
+	Applications []*Application `json:"applications"`
+
+	path  string
+	mutex sync.Mutex
+}
+
+//Application progress of the deployment of one application
+type Application struct {
+	Name      string          `json:"name"`
+	Step      string          `json:"step"`
+	Completed int             `json:"completed"`
+	Deployed  bool            `json:"deployed"`
+	Finalized bool            `json:"finalized"`
+	State     json.RawMessage `json:"state,omitempty"`
+}
+
+//New creates a journal for the deployment with the arguments, an existing journal will not be overwritten
+func New(path string, args []string) (*Journal, error) {
+	_, err := os.Stat(path)
+	if err == nil {
+		return nil, ErrJournalExists
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return &Journal{
+		Args:         args,
+		StartedAt:    time.Now(),
+		Applications: []*Application{},
+		path:         path,
+	}, nil
+}
+
+//Load reads the journal of an interrupted deployment
+func Load(path string) (*Journal, error) {
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNoJournal
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	journal := &Journal{path: path}
+	err = json.Unmarshal(content, journal)
+	if err != nil {
+		return nil, err
+	}
+	return journal, nil
+}
+
+//Application returns the progress of the application, it will be added if it's not part of the journal
+func (journal *Journal) Application(name string) (*Application, bool) {
+	journal.mutex.Lock()
+	defer journal.mutex.Unlock()
+
+	for _, application := range journal.Applications {
+		if application.Name == name {
+			return application, true
+		}
+	}
+	application := &Application{Name: name}
+	journal.Applications = append(journal.Applications, application)
+	return application, false
+}
+
+//Checkpoint updates the progress and state of the application and writes the journal
+func (journal *Journal) Checkpoint(application *Application, step string, completed int, deployed bool, state interface{}) error {
+	stateJSON, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	journal.mutex.Lock()
+	application.Step = step
+	application.Completed = completed
+	application.Deployed = deployed
+	application.State = stateJSON
+	journal.mutex.Unlock()
+
+	return journal.Save()
+}
+
+//Finalize marks that the venerable action of the application started, the deployment can't be rolled back anymore
+func (journal *Journal) Finalize(application *Application) error {
+	journal.mutex.Lock()
+	application.Finalized = true
+	journal.mutex.Unlock()
+
+	return journal.Save()
+}
+
+//Pending checks if an application has completed steps that were neither finalized nor rolled back
+func (journal *Journal) Pending() bool {
+	journal.mutex.Lock()
+	defer journal.mutex.Unlock()
+
+	for _, application := range journal.Applications {
+		if application.Completed > 0 && !application.Finalized {
+			return true
+		}
+	}
+	return false
+}
+
+//Path returns the path of the journal file
+func (journal *Journal) Path() string {
+	return journal.path
+}
+
+//Save writes the journal, the file is replaced at once so an interrupted write does not destroy it
+func (journal *Journal) Save() error {
+	journal.mutex.Lock()
+	defer journal.mutex.Unlock()
+
+	content, err := json.MarshalIndent(journal, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := ioutil.TempFile(filepath.Dir(journal.path), ".cf-puppeteer-journal-*")
+	if err != nil {
+		return err
+	}
+	err = tmpFile.Chmod(fileMode)
+	if err == nil {
+		_, err = tmpFile.Write(content)
+	}
+	closeErr := tmpFile.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmpFile.Name())
+		return err
+	}
+	return os.Rename(tmpFile.Name(), journal.path)
+}
+
+//Remove deletes the journal after the deployment is finished or rolled back
+func (journal *Journal) Remove() error {
+	journal.mutex.Lock()
+	defer journal.mutex.Unlock()
+
+	err := os.Remove(journal.path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+//GetState reads the persisted state of the application into state
+func (application *Application) GetState(state interface{}) error {
+	if len(application.State) == 0 {
+		return nil
+	}
+	return json.Unmarshal(application.State, state)
+}
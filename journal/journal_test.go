@@ -0,0 +1,96 @@
+package journal_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/happytobi/cf-puppeteer/journal"
+)
+
+var _ = Describe("Journal", func() {
+	var journalPath string
+
+	BeforeEach(func() {
+		dir, err := ioutil.TempDir("", "journal")
+		Expect(err).ToNot(HaveOccurred())
+		journalPath = filepath.Join(dir, "journal.json")
+	})
+
+	AfterEach(func() {
+		_ = os.RemoveAll(filepath.Dir(journalPath))
+	})
+
+	It("writes the progress of every application and loads it again", func() {
+		deploymentJournal, err := journal.New(journalPath, []string{"zero-downtime-push", "-f", "manifest.yml"})
+		Expect(err).ToNot(HaveOccurred())
+
+		application, found := deploymentJournal.Application("my-app")
+		Expect(found).To(BeFalse())
+		state := map[string]bool{"renamed": true}
+		Expect(deploymentJournal.Checkpoint(application, "rename current application", 3, false, state)).To(Succeed())
+
+		loadedJournal, err := journal.Load(journalPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(loadedJournal.Args).To(Equal([]string{"zero-downtime-push", "-f", "manifest.yml"}))
+
+		loadedApplication, found := loadedJournal.Application("my-app")
+		Expect(found).To(BeTrue())
+		Expect(loadedApplication.Step).To(Equal("rename current application"))
+		Expect(loadedApplication.Completed).To(Equal(3))
+		Expect(loadedApplication.Deployed).To(BeFalse())
+
+		var loadedState map[string]bool
+		Expect(loadedApplication.GetState(&loadedState)).To(Succeed())
+		Expect(loadedState).To(Equal(state))
+	})
+
+	It("is only readable by the owner because the args may contain secrets", func() {
+		deploymentJournal, err := journal.New(journalPath, []string{"zero-downtime-push", "--var", "password=secret"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deploymentJournal.Save()).To(Succeed())
+
+		info, err := os.Stat(journalPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+	})
+
+	It("does not overwrite the journal of an interrupted deployment", func() {
+		deploymentJournal, err := journal.New(journalPath, []string{"zero-downtime-push"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deploymentJournal.Save()).To(Succeed())
+
+		_, err = journal.New(journalPath, []string{"zero-downtime-push"})
+		Expect(err).To(MatchError(journal.ErrJournalExists))
+	})
+
+	It("is pending while an application has completed steps that were neither finalized nor rolled back", func() {
+		deploymentJournal, err := journal.New(journalPath, []string{"zero-downtime-push"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deploymentJournal.Pending()).To(BeFalse())
+
+		application, _ := deploymentJournal.Application("my-app")
+		Expect(deploymentJournal.Checkpoint(application, "push application", 4, false, nil)).To(Succeed())
+		Expect(deploymentJournal.Pending()).To(BeTrue())
+
+		Expect(deploymentJournal.Checkpoint(application, "", 0, false, nil)).To(Succeed())
+		Expect(deploymentJournal.Pending()).To(BeFalse())
+
+		Expect(deploymentJournal.Checkpoint(application, "smoke test", 8, true, nil)).To(Succeed())
+		Expect(deploymentJournal.Finalize(application)).To(Succeed())
+		Expect(deploymentJournal.Pending()).To(BeFalse())
+	})
+
+	It("removes the journal", func() {
+		deploymentJournal, err := journal.New(journalPath, []string{"zero-downtime-push"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deploymentJournal.Save()).To(Succeed())
+		Expect(deploymentJournal.Remove()).To(Succeed())
+
+		_, err = journal.Load(journalPath)
+		Expect(err).To(MatchError(journal.ErrNoJournal))
+	})
+})
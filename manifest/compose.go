@@ -0,0 +1,108 @@
+package manifest
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+//applicationKeys yaml keys of an application, they can be set at the top of the manifest for all applications
+var applicationKeys = yamlKeys(reflect.TypeOf(Application{}))
+
+func yamlKeys(structType reflect.Type) map[string]bool {
+	keys := map[string]bool{}
+	for i := 0; i < structType.NumField(); i++ {
+		key := strings.Split(structType.Field(i).Tag.Get("yaml"), ",")[0]
+		if len(key) > 0 && key != "name" {
+			keys[key] = true
+		}
+	}
+	return keys
+}
+
+//resolveInheritance merges the manifest with the manifest of its inherit key like the cf cli v6 does it, the values of
+//the manifest override the inherited ones, maps are merged and lists are replaced
+func resolveInheritance(manifestFilePath string, document interface{}, visited map[string]bool) (interface{}, error) {
+	documentMap, ok := document.(map[interface{}]interface{})
+	if !ok {
+		return document, nil
+	}
+	inherit, ok := documentMap["inherit"]
+	if !ok {
+		return document, nil
+	}
+	delete(documentMap, "inherit")
+
+	parentPath, ok := inherit.(string)
+	if !ok || len(parentPath) == 0 {
+		return nil, fmt.Errorf("inherit of manifest %s has to be a path", manifestFilePath)
+	}
+	if !filepath.IsAbs(parentPath) {
+		parentPath = filepath.Join(filepath.Dir(manifestFilePath), parentPath)
+	}
+	parentPath = filepath.Clean(parentPath)
+	if visited[parentPath] {
+		return nil, fmt.Errorf("manifest %s inherits itself", parentPath)
+	}
+	visited[filepath.Clean(manifestFilePath)] = true
+	visited[parentPath] = true
+
+	parent, err := loadYmlFile(parentPath)
+	if err != nil {
+		return nil, errors.Wrap(err, fmt.Sprintf("could not load inherited manifest of %s", manifestFilePath))
+	}
+	parent, err = resolveInheritance(parentPath, parent, visited)
+	if err != nil {
+		return nil, err
+	}
+	return mergeTrees(parent, documentMap), nil
+}
+
+//mergeTrees merges the overlay into the base, maps are merged recursively and all other values are replaced
+func mergeTrees(base interface{}, overlay interface{}) interface{} {
+	baseMap, baseIsMap := base.(map[interface{}]interface{})
+	overlayMap, overlayIsMap := overlay.(map[interface{}]interface{})
+	if !baseIsMap || !overlayIsMap {
+		return overlay
+	}
+
+	merged := make(map[interface{}]interface{}, len(baseMap)+len(overlayMap))
+	for key, value := range baseMap {
+		merged[key] = value
+	}
+	for key, value := range overlayMap {
+		if baseValue, ok := merged[key]; ok {
+			value = mergeTrees(baseValue, value)
+		}
+		merged[key] = value
+	}
+	return merged
+}
+
+//applyGlobalProperties moves application keys at the top of the manifest into all applications, the values of an
+//application override the global ones
+func applyGlobalProperties(document interface{}) interface{} {
+	documentMap, ok := document.(map[interface{}]interface{})
+	if !ok {
+		return document
+	}
+	globals := map[interface{}]interface{}{}
+	for key, value := range documentMap {
+		if name, ok := key.(string); ok && applicationKeys[name] {
+			globals[key] = value
+			delete(documentMap, key)
+		}
+	}
+	applications, ok := documentMap["applications"].([]interface{})
+	if len(globals) == 0 || !ok {
+		return document
+	}
+
+	for index, application := range applications {
+		applications[index] = mergeTrees(globals, application)
+	}
+	return document
+}
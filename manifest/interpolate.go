@@ -0,0 +1,127 @@
+package manifest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//interpolator replaces the ((placeholders)) of a yaml document like the cf cli does it with bosh variables
+type interpolator struct {
+	variables Variables
+	missing   map[string]bool
+}
+
+//Interpolate replaces all placeholders in the keys and values of the unmarshalled yaml document. A value that only
+//consists of a placeholder gets the value of the variable with its type, placeholders inside of a string have to
+//be strings or numbers. ((name.key)) looks up key in the map of the variable name. All undefined variables are
+//returned in one error.
+func Interpolate(document interface{}, variables Variables) (interface{}, error) {
+	interpolator := &interpolator{variables: variables, missing: map[string]bool{}}
+	result, err := interpolator.interpolate(document)
+	if err != nil {
+		return nil, err
+	}
+	if len(interpolator.missing) > 0 {
+		var names []string
+		for name := range interpolator.missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("expected to find variables: %s", strings.Join(names, ", "))
+	}
+	return result, nil
+}
+
+func (interpolator *interpolator) interpolate(node interface{}) (interface{}, error) {
+	switch typed := node.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[interface{}]interface{}, len(typed))
+		for key, value := range typed {
+			interpolatedKey, err := interpolator.interpolate(key)
+			if err != nil {
+				return nil, err
+			}
+			interpolatedValue, err := interpolator.interpolate(value)
+			if err != nil {
+				return nil, err
+			}
+			result[interpolatedKey] = interpolatedValue
+		}
+		return result, nil
+	case []interface{}:
+		result := make([]interface{}, len(typed))
+		for index, value := range typed {
+			interpolatedValue, err := interpolator.interpolate(value)
+			if err != nil {
+				return nil, err
+			}
+			result[index] = interpolatedValue
+		}
+		return result, nil
+	case string:
+		return interpolator.interpolateString(typed)
+	}
+	return node, nil
+}
+
+func (interpolator *interpolator) interpolateString(value string) (interface{}, error) {
+	matches := interpolationRegex.FindAllStringSubmatch(value, -1)
+	if len(matches) == 0 {
+		return value, nil
+	}
+	//the whole value is replaced with the value of the variable, e.g. instances: ((instances)) becomes a number
+	if len(matches) == 1 && matches[0][0] == value {
+		variable, found := interpolator.lookup(matches[0][1])
+		if !found {
+			return value, nil
+		}
+		return variable, nil
+	}
+
+	var err error
+	result := interpolationRegex.ReplaceAllStringFunc(value, func(match string) string {
+		name := interpolationRegex.FindStringSubmatch(match)[1]
+		variable, found := interpolator.lookup(name)
+		if !found {
+			return match
+		}
+		switch variable.(type) {
+		case string, int, int64, uint64, float64:
+			return fmt.Sprintf("%v", variable)
+		}
+		if err == nil {
+			err = fmt.Errorf("invalid type '%T' for value '%v' and variable '%s', supported types for interpolation within a string are integers and strings", variable, variable, name)
+		}
+		return match
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+//lookup returns the value of the variable, nested values of maps are found with ((name.key.key))
+func (interpolator *interpolator) lookup(name string) (interface{}, bool) {
+	name = strings.TrimPrefix(name, "!")
+	path := strings.Split(name, ".")
+
+	value, found := interpolator.variables[path[0]]
+	for _, key := range path[1:] {
+		if !found {
+			break
+		}
+		switch typed := value.(type) {
+		case map[interface{}]interface{}:
+			value, found = typed[key]
+		case map[string]interface{}:
+			value, found = typed[key]
+		default:
+			found = false
+		}
+	}
+	if !found {
+		interpolator.missing[name] = true
+	}
+	return value, found
+}
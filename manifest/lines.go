@@ -0,0 +1,129 @@
+package manifest
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+//keyRegex key of a yaml block mapping line like "memory: 1G" or "env:"
+var keyRegex = regexp.MustCompile(`^("[^"]*"|'[^']*'|[^\s:#'"\-][^:#]*?|-[^\s:#][^:#]*?)\s*:(\s+|$)(.*)$`)
+
+//lineLocator finds the line of a path like applications[0].memory in the block style yaml of a manifest file
+type lineLocator struct {
+	lines map[string]int
+}
+
+type lineFrame struct {
+	indent int
+	path   []interface{}
+}
+
+//newLineLocator scans the yaml source, flow style values and block scalars are located at the line of their key
+func newLineLocator(source []byte) *lineLocator {
+	locator := &lineLocator{lines: map[string]int{}}
+	var stack []lineFrame
+	listIndexes := map[string]int{}
+	blockScalarIndent := -1
+
+	parentPath := func() []interface{} {
+		if len(stack) == 0 {
+			return nil
+		}
+		return stack[len(stack)-1].path
+	}
+	push := func(indent int, element interface{}, line int) {
+		parent := parentPath()
+		path := make([]interface{}, len(parent), len(parent)+1)
+		copy(path, parent)
+		path = append(path, element)
+		stack = append(stack, lineFrame{indent: indent, path: path})
+		if _, ok := locator.lines[formatPath(path)]; !ok {
+			locator.lines[formatPath(path)] = line
+		}
+	}
+	pushKey := func(indent int, content string, line int) {
+		match := keyRegex.FindStringSubmatch(content)
+		if match == nil {
+			return
+		}
+		push(indent, strings.Trim(match[1], `"'`), line)
+		value := strings.TrimSpace(match[3])
+		if strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">") {
+			blockScalarIndent = indent
+		}
+	}
+
+	for index, rawLine := range strings.Split(string(source), "\n") {
+		line := index + 1
+		content := strings.TrimSpace(rawLine)
+		indent := len(rawLine) - len(strings.TrimLeft(rawLine, " "))
+		if blockScalarIndent >= 0 {
+			if len(content) == 0 || indent > blockScalarIndent {
+				continue
+			}
+			blockScalarIndent = -1
+		}
+		if len(content) == 0 || strings.HasPrefix(content, "#") || strings.HasPrefix(content, "---") {
+			continue
+		}
+
+		if content == "-" || strings.HasPrefix(content, "- ") {
+			//a list can have the same indent as its key
+			for len(stack) > 0 && (stack[len(stack)-1].indent > indent || (stack[len(stack)-1].indent == indent && isIndex(stack[len(stack)-1].path))) {
+				stack = stack[:len(stack)-1]
+			}
+			listKey := formatPath(parentPath())
+			listIndex := listIndexes[listKey]
+			listIndexes[listKey] = listIndex + 1
+			push(indent, listIndex, line)
+
+			item := strings.TrimSpace(strings.TrimPrefix(content, "-"))
+			if len(item) > 0 {
+				pushKey(indent+len(content)-len(item), item, line)
+			}
+			continue
+		}
+
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		pushKey(indent, content, line)
+	}
+	return locator
+}
+
+func isIndex(path []interface{}) bool {
+	if len(path) == 0 {
+		return false
+	}
+	_, ok := path[len(path)-1].(int)
+	return ok
+}
+
+//formatPath formats a path like applications[0].routes[1].route
+func formatPath(path []interface{}) string {
+	var formatted strings.Builder
+	for _, element := range path {
+		switch typed := element.(type) {
+		case int:
+			formatted.WriteString(fmt.Sprintf("[%d]", typed))
+		default:
+			if formatted.Len() > 0 {
+				formatted.WriteString(".")
+			}
+			formatted.WriteString(fmt.Sprintf("%v", typed))
+		}
+	}
+	return formatted.String()
+}
+
+//line returns the line of the path or of its nearest parent that is in the file, 0 if nothing was found
+func (locator *lineLocator) line(path []interface{}) int {
+	for length := len(path); length > 0; length-- {
+		if line, ok := locator.lines[formatPath(path[:length])]; ok {
+			return line
+		}
+	}
+	return 0
+}
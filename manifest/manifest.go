@@ -4,8 +4,8 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
-	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -13,30 +13,92 @@ import (
 )
 
 /*
-Application yaml represents the complete yaml structure
+Application yaml represents the complete yaml structure of an application of the v3 manifest
 type is always string because you can use a vars placeholder in all attributes.
+Keys that are not part of the struct are kept in Unknown and written back, so nothing gets lost when puppeteer
+generates a new manifest.
 */
 type Application struct {
-	Name                    string              `yaml:"name"`
-	Instances               string              `yaml:"instances,omitempty"`
-	Memory                  string              `yaml:"memory,omitempty"`
-	DiskQuota               string              `yaml:"disk_quota,omitempty"`
-	Routes                  []map[string]string `yaml:"routes,omitempty"`
-	NoRoute                 bool                `yaml:"no-route,omitempty"`
-	Buildpacks              []string            `yaml:"buildpacks,omitempty"`
-	Command                 string              `yaml:"command,omitempty"`
-	Env                     map[string]string   `yaml:"env,omitempty"`
-	Services                []string            `yaml:"services,omitempty"`
-	Stack                   string              `yaml:"stack,omitempty"`
-	Path                    string              `yaml:"path,omitempty"`
-	Timeout                 string              `yaml:"timeout,omitempty"`
-	HealthCheckType         string              `yaml:"health-check-type,omitempty"`
-	HealthCheckHTTPEndpoint string              `yaml:"health-check-http-endpoint,omitempty"`
+	Name                                  string                 `yaml:"name"`
+	Instances                             string                 `yaml:"instances,omitempty"`
+	Memory                                string                 `yaml:"memory,omitempty"`
+	DiskQuota                             string                 `yaml:"disk_quota,omitempty"`
+	LogRateLimitPerSecond                 string                 `yaml:"log-rate-limit-per-second,omitempty"`
+	Routes                                []map[string]string    `yaml:"routes,omitempty"`
+	NoRoute                               bool                   `yaml:"no-route,omitempty"`
+	RandomRoute                           bool                   `yaml:"random-route,omitempty"`
+	DefaultRoute                          bool                   `yaml:"default-route,omitempty"`
+	Buildpack                             string                 `yaml:"buildpack,omitempty"`
+	Buildpacks                            []string               `yaml:"buildpacks,omitempty"`
+	Docker                                *Docker                `yaml:"docker,omitempty"`
+	Lifecycle                             string                 `yaml:"lifecycle,omitempty"`
+	Command                               string                 `yaml:"command,omitempty"`
+	Env                                   map[string]string      `yaml:"env,omitempty"`
+	Services                              []interface{}          `yaml:"services,omitempty"`
+	Stack                                 string                 `yaml:"stack,omitempty"`
+	Path                                  string                 `yaml:"path,omitempty"`
+	Timeout                               string                 `yaml:"timeout,omitempty"`
+	HealthCheckType                       string                 `yaml:"health-check-type,omitempty"`
+	HealthCheckHTTPEndpoint               string                 `yaml:"health-check-http-endpoint,omitempty"`
+	HealthCheckInvocationTimeout          string                 `yaml:"health-check-invocation-timeout,omitempty"`
+	HealthCheckInterval                   string                 `yaml:"health-check-interval,omitempty"`
+	ReadinessHealthCheckType              string                 `yaml:"readiness-health-check-type,omitempty"`
+	ReadinessHealthCheckHTTPEndpoint      string                 `yaml:"readiness-health-check-http-endpoint,omitempty"`
+	ReadinessHealthCheckInvocationTimeout string                 `yaml:"readiness-health-check-invocation-timeout,omitempty"`
+	ReadinessHealthCheckInterval          string                 `yaml:"readiness-health-check-interval,omitempty"`
+	Processes                             []Process              `yaml:"processes,omitempty"`
+	Sidecars                              []Sidecar              `yaml:"sidecars,omitempty"`
+	Metadata                              *Metadata              `yaml:"metadata,omitempty"`
+	Unknown                               map[string]interface{} `yaml:",inline"`
+}
+
+//Process yaml of a process type like web or worker of the application
+type Process struct {
+	Type                                  string                 `yaml:"type"`
+	Command                               string                 `yaml:"command,omitempty"`
+	Instances                             string                 `yaml:"instances,omitempty"`
+	Memory                                string                 `yaml:"memory,omitempty"`
+	DiskQuota                             string                 `yaml:"disk_quota,omitempty"`
+	LogRateLimitPerSecond                 string                 `yaml:"log-rate-limit-per-second,omitempty"`
+	Timeout                               string                 `yaml:"timeout,omitempty"`
+	HealthCheckType                       string                 `yaml:"health-check-type,omitempty"`
+	HealthCheckHTTPEndpoint               string                 `yaml:"health-check-http-endpoint,omitempty"`
+	HealthCheckInvocationTimeout          string                 `yaml:"health-check-invocation-timeout,omitempty"`
+	HealthCheckInterval                   string                 `yaml:"health-check-interval,omitempty"`
+	ReadinessHealthCheckType              string                 `yaml:"readiness-health-check-type,omitempty"`
+	ReadinessHealthCheckHTTPEndpoint      string                 `yaml:"readiness-health-check-http-endpoint,omitempty"`
+	ReadinessHealthCheckInvocationTimeout string                 `yaml:"readiness-health-check-invocation-timeout,omitempty"`
+	ReadinessHealthCheckInterval          string                 `yaml:"readiness-health-check-interval,omitempty"`
+	Unknown                               map[string]interface{} `yaml:",inline"`
+}
+
+//Sidecar yaml of a sidecar that runs next to the processes of the application
+type Sidecar struct {
+	Name         string                 `yaml:"name"`
+	ProcessTypes []string               `yaml:"process_types,omitempty"`
+	Command      string                 `yaml:"command,omitempty"`
+	Memory       string                 `yaml:"memory,omitempty"`
+	Unknown      map[string]interface{} `yaml:",inline"`
+}
+
+//Docker yaml of the docker image the application is pushed with
+type Docker struct {
+	Image    string                 `yaml:"image,omitempty"`
+	Username string                 `yaml:"username,omitempty"`
+	Unknown  map[string]interface{} `yaml:",inline"`
+}
+
+//Metadata yaml of the labels and annotations of the application
+type Metadata struct {
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
 }
 
 // Manifest struct represents the application manifest.
 type Manifest struct {
-	ApplicationManifests []Application `yaml:"applications"`
+	Version              int                    `yaml:"version,omitempty"`
+	ApplicationManifests []Application          `yaml:"applications"`
+	Unknown              map[string]interface{} `yaml:",inline"`
 }
 
 //VarsFile
@@ -45,70 +107,94 @@ type Variables map[string]interface{}
 //regex pattern - @see cf cli code
 var (
 	interpolationRegex = regexp.MustCompile(`\(\((!?[-/\.\w\pL]+)\)\)`)
+	memoryRegex        = regexp.MustCompile(`^(\d+)\s*([KMGT])B?$`)
+)
+
+var (
+	//ErrInvalidMemory error when the memory of the manifest has no valid size like 512M or 1G
+	ErrInvalidMemory = errors.New("memory has to be a number with a unit like M, MB, G or GB")
+	//ErrNoWildcardSupport error when a wildcard was in the path directive
+	ErrNoWildcardSupport = errors.New("wildcard expressions within the path directive in the application manifest are not supported - delete this path directive and pass the artifact path by using the -p option")
 )
 
 //ParseAndReplaceWithVars parse a manifest and vars file.
 // get all values from vars file and put them into the manifest file so there will be a returned new manifest without
 // placeholders
 func ParseApplicationManifest(manifestFilePath string, varsFilePath string) (manifest Manifest, noRouteManifestPath string, err error) {
-	document, err := loadYmlFile(manifestFilePath)
+	var varsFilePaths []string
+	if len(varsFilePath) > 0 {
+		varsFilePaths = append(varsFilePaths, varsFilePath)
+	}
+	variables, err := LoadVariables(varsFilePaths, nil)
+	if err != nil {
+		return Manifest{}, "", err
+	}
+	manifest, err = ComposeApplicationManifest(manifestFilePath, nil, variables)
+	if err != nil {
+		return Manifest{}, "", err
+	}
 
-	if err != nil || document.ApplicationManifests == nil {
-		return Manifest{}, "", fmt.Errorf("could not parse file, file not valid")
-	}
-
-	//if there's no vars file, we can return the parsed manifest direct
-	if len(varsFilePath) <= 0 {
-		//generate file when no vars file was passed
-		noRouteManifestPath, err = GenerateNoRouteYml(document)
-		return document, noRouteManifestPath, err
-	}
-
-	varsFile, err := loadVarsFile(varsFilePath)
-	if err != nil {
-		return Manifest{}, "", fmt.Errorf("could not parse vars file, file not valid")
-	}
-
-	//iterate through all the applications an check if vars are existing
-	for aI, app := range document.ApplicationManifests {
-		appManifestElement := reflect.ValueOf(&app).Elem()
-		for i := 0; i < appManifestElement.NumField(); i++ {
-			appElementField := appManifestElement.Field(i)
-			fieldValue := fmt.Sprintf("%v", appElementField.Interface())
-			for _, match := range interpolationRegex.FindAllSubmatch([]byte(fieldValue), -1) {
-				//get variable name
-				matchedVar := strings.TrimPrefix(string(match[1]), "!")
-				varsValue := varsFile[matchedVar]
-				//change string fields
-				if appElementField.Kind() == reflect.String {
-					replacedVar := strings.ReplaceAll(fieldValue, fmt.Sprintf("((%v))", matchedVar), fmt.Sprintf("%v", varsValue))
-					appElementField.SetString(replacedVar)
-				}
-				//change slices
-				if appElementField.Kind() == reflect.Slice {
-					for y := 0; y < appElementField.Len(); y++ {
-						sliceElementField := appElementField.Index(y)
-						if sliceElementField.Kind() == reflect.Map {
-							for _, mv := range sliceElementField.MapKeys() {
-								x := sliceElementField.MapIndex(mv)
-								nx := strings.ReplaceAll(x.String(), fmt.Sprintf("((%v))", matchedVar), fmt.Sprintf("%v", varsValue))
-								sliceElementField.SetMapIndex(mv, reflect.ValueOf(nx))
-							}
-						}
-					}
-				}
-			}
+	noRouteManifestPath, err = GenerateNoRouteYml(manifest)
+	if err != nil {
+		return Manifest{}, "", errors.Wrap(err, "could not generate no route manifest")
+	}
+	return manifest, noRouteManifestPath, nil
+}
+
+//LoadVariables merges the vars files in the passed order and the vars, like the cf cli later files override the
+//variables of earlier ones and vars override all files
+func LoadVariables(varsFilePaths []string, vars map[string]string) (Variables, error) {
+	variables := Variables{}
+	for _, varsFilePath := range varsFilePaths {
+		varsFile, err := loadVarsFile(varsFilePath)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse vars file %s, file not valid", varsFilePath)
+		}
+		for name, value := range varsFile {
+			variables[name] = value
 		}
-		document.ApplicationManifests[aI] = app
 	}
+	for name, value := range vars {
+		variables[name] = value
+	}
+	return variables, nil
+}
 
-	//generate new temp file when vars parsed because placeholders are resolved
-	noRouteManifestPath, err = GenerateNoRouteYml(document)
+//ComposeApplicationManifest parse a manifest with the manifests it inherits, apply the operations of the ops files in
+//order and replace its placeholders with the variables
+func ComposeApplicationManifest(manifestFilePath string, opsFilePaths []string, variables Variables) (manifest Manifest, err error) {
+	rawDocument, err := loadYmlFile(manifestFilePath)
 	if err != nil {
-		return Manifest{}, "", errors.Wrap(err, "could not generate no route manifest")
+		return Manifest{}, fmt.Errorf("could not parse file, file not valid: %s", err)
+	}
+	rawDocument, err = resolveInheritance(manifestFilePath, rawDocument, map[string]bool{})
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	for _, opsFilePath := range opsFilePaths {
+		operations, err := LoadOpsFile(opsFilePath)
+		if err != nil {
+			return Manifest{}, err
+		}
+		rawDocument, err = ApplyOperations(rawDocument, operations)
+		if err != nil {
+			return Manifest{}, errors.Wrap(err, fmt.Sprintf("could not apply ops file %s", opsFilePath))
+		}
+	}
+
+	//placeholders are replaced in the yaml tree, so a variable can replace a whole value like a number or a list
+	interpolatedDocument, err := Interpolate(rawDocument, variables)
+	if err != nil {
+		return Manifest{}, errors.Wrap(err, fmt.Sprintf("could not interpolate manifest %s", manifestFilePath))
 	}
 
-	return document, noRouteManifestPath, nil
+	document, err := convertDocument(applyGlobalProperties(interpolatedDocument))
+	if err != nil || document.ApplicationManifests == nil {
+		return Manifest{}, fmt.Errorf("could not parse file, file not valid")
+	}
+
+	return document, nil
 }
 
 //load the vars file an throw errors then there is a issue
@@ -126,22 +212,31 @@ func loadVarsFile(varsFilePath string) (variables Variables, err error) {
 	return variables, nil
 }
 
-//load the application yml file an throw errors then there is a issue
-func loadYmlFile(manifestFilePath string) (manifest Manifest, err error) {
+//load the application yml file as yaml tree an throw errors then there is a issue
+func loadYmlFile(manifestFilePath string) (document interface{}, err error) {
 	fileBytes, err := ioutil.ReadFile(manifestFilePath)
 	if err != nil {
-		return Manifest{}, fmt.Errorf("error while reading manifest: %s", manifestFilePath)
+		return nil, fmt.Errorf("error while reading manifest: %s", manifestFilePath)
 	}
 
-	var document Manifest
 	err = yaml.Unmarshal(fileBytes, &document)
 	if err != nil {
-		return Manifest{}, fmt.Errorf("error while parsing the manifest %s error: %v", manifestFilePath, err)
+		return nil, fmt.Errorf("error while parsing the manifest %s error: %v", manifestFilePath, err)
 	}
 
 	return document, nil
 }
 
+//convertDocument converts the yaml tree into the manifest
+func convertDocument(document interface{}) (manifest Manifest, err error) {
+	documentBytes, err := yaml.Marshal(document)
+	if err != nil {
+		return Manifest{}, err
+	}
+	err = yaml.Unmarshal(documentBytes, &manifest)
+	return manifest, err
+}
+
 //WriteYmlFile write yml file to specified path and return them parsed
 func WriteYmlFile(manifestFilePath string, manifest Manifest) (err error) {
 	mManifest, err := yaml.Marshal(&manifest)
@@ -155,21 +250,54 @@ func WriteYmlFile(manifestFilePath string, manifest Manifest) (err error) {
 //GenerateNoRouteYml generate temp manifest without routes to skip route creation
 func GenerateNoRouteYml(originalManifest Manifest) (tempManifestPath string, err error) {
 	//Clone manifest to change them without side effects
-	newTempManifest := Manifest{ApplicationManifests: make([]Application, len(originalManifest.ApplicationManifests))}
+	newTempManifest := Manifest{Version: originalManifest.Version, ApplicationManifests: make([]Application, len(originalManifest.ApplicationManifests)), Unknown: originalManifest.Unknown}
 
-	//copy important information into no route yml (only resources are important)
+	//the application is copied completely, only the routes are removed
 	for index, app := range originalManifest.ApplicationManifests {
-		newApp := Application{Name: app.Name, Instances: app.Instances, Memory: app.Memory, DiskQuota: app.DiskQuota, NoRoute: true, Routes: []map[string]string{}}
-		newTempManifest.ApplicationManifests[index] = newApp
+		app.Routes = []map[string]string{}
+		app.NoRoute = true
+		app.RandomRoute = false
+		app.DefaultRoute = false
+		newTempManifest.ApplicationManifests[index] = app
 	}
 
-	manifestPathTemp := GenerateTempFile(originalManifest.ApplicationManifests[0].Name, "yml")
-	err = WriteYmlFile(manifestPathTemp, newTempManifest)
+	return writeTempYmlFile(originalManifest.ApplicationManifests[0].Name, newTempManifest)
+}
+
+//GenerateApplicationKeepRoutesYml generate temp manifest that only contains the passed application without any route
+//settings, so the route mappings of an existing application stay untouched when the manifest is applied
+func GenerateApplicationKeepRoutesYml(application Application) (tempManifestPath string, err error) {
+	application.Routes = nil
+	application.NoRoute = false
+	application.RandomRoute = false
+	application.DefaultRoute = false
 
+	return writeTempYmlFile(application.Name+"-keep-routes", Manifest{ApplicationManifests: []Application{application}})
+}
+
+//GenerateApplicationNoRouteYml generate temp manifest without routes that only contains the passed application
+func GenerateApplicationNoRouteYml(application Application) (tempManifestPath string, err error) {
+	return GenerateNoRouteYml(Manifest{ApplicationManifests: []Application{application}})
+}
+
+//writeTempYmlFile writes the manifest to a new temp file with a unique name, so manifests of the same application
+//don't overwrite each other. The caller removes the file
+func writeTempYmlFile(fileName string, manifest Manifest) (tempManifestPath string, err error) {
+	tempFile, err := ioutil.TempFile("", strings.ReplaceAll(fileName, "/", "-")+"-*.yml")
+	if err != nil {
+		return "", err
+	}
+	err = tempFile.Close()
 	if err != nil {
 		return "", err
 	}
-	return manifestPathTemp, nil
+
+	err = WriteYmlFile(tempFile.Name(), manifest)
+	if err != nil {
+		_ = os.Remove(tempFile.Name())
+		return "", err
+	}
+	return tempFile.Name(), nil
 }
 
 func GenerateTempFile(fileName string, fileExtension string) (zipFile string) {
@@ -180,3 +308,46 @@ func GenerateTempFile(fileName string, fileExtension string) (zipFile string) {
 	}
 	return fmt.Sprintf(pathFormat, tempDir, fileName, fileExtension)
 }
+
+//MemoryInMB returns the memory of the application in megabyte, 0 is returned if the manifest has no memory
+func (app Application) MemoryInMB() (int, error) {
+	return parseMegabytes(app.Memory)
+}
+
+//parseMegabytes returns the size like 512M or 1G in megabyte, 0 is returned for an empty size
+func parseMegabytes(value string) (int, error) {
+	size := strings.ToUpper(strings.TrimSpace(value))
+	if len(size) == 0 {
+		return 0, nil
+	}
+	match := memoryRegex.FindStringSubmatch(size)
+	if match == nil {
+		return 0, ErrInvalidMemory
+	}
+	megabytes, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0, ErrInvalidMemory
+	}
+	switch match[2] {
+	case "K":
+		return megabytes / 1024, nil
+	case "G":
+		return megabytes * 1024, nil
+	case "T":
+		return megabytes * 1024 * 1024, nil
+	}
+	return megabytes, nil
+}
+
+//InstanceCount returns the instances of the application, 0 is returned if the manifest has no instances
+func (app Application) InstanceCount() (int, error) {
+	instances := strings.TrimSpace(app.Instances)
+	if len(instances) == 0 {
+		return 0, nil
+	}
+	count, err := strconv.Atoi(instances)
+	if err != nil || count < 0 {
+		return 0, fmt.Errorf("instances %s of application %s is not a valid number", app.Instances, app.Name)
+	}
+	return count, nil
+}
@@ -137,3 +137,209 @@ var _ = Describe("Test temp file generation without routes", func() {
 		Expect(manifest.ApplicationManifests[0].Memory).To(Equal(noRouteYml.ApplicationManifests[0].Memory))
 	})
 })
+
+var _ = Describe("Test temp file generation for a single application", func() {
+	It("contains only the passed application", func() {
+		manifest, _, err := ParseApplicationManifest("../fixtures/multiManifest.yml", "")
+		Expect(err).ToNot(HaveOccurred())
+
+		noRouteYmlPath, err := GenerateApplicationNoRouteYml(manifest.ApplicationManifests[1])
+		Expect(err).ToNot(HaveOccurred())
+
+		noRouteYml, _, err := ParseApplicationManifest(noRouteYmlPath, "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(len(noRouteYml.ApplicationManifests)).To(Equal(1))
+		Expect(noRouteYml.ApplicationManifests[0].Name).To(Equal("myApp2"))
+		Expect(len(noRouteYml.ApplicationManifests[0].Routes)).To(Equal(0))
+	})
+
+	It("writes every manifest of the same application to its own file", func() {
+		application := Application{Name: "myApp"}
+		noRouteYmlPath, err := GenerateApplicationNoRouteYml(application)
+		Expect(err).ToNot(HaveOccurred())
+		defer os.Remove(noRouteYmlPath)
+		otherNoRouteYmlPath, err := GenerateApplicationNoRouteYml(application)
+		Expect(err).ToNot(HaveOccurred())
+		defer os.Remove(otherNoRouteYmlPath)
+		keepRoutesYmlPath, err := GenerateApplicationKeepRoutesYml(application)
+		Expect(err).ToNot(HaveOccurred())
+		defer os.Remove(keepRoutesYmlPath)
+
+		Expect(noRouteYmlPath).ToNot(Equal(otherNoRouteYmlPath))
+		Expect(keepRoutesYmlPath).ToNot(Equal(noRouteYmlPath))
+		Expect(keepRoutesYmlPath).ToNot(Equal(otherNoRouteYmlPath))
+	})
+})
+
+var _ = Describe("Application resources", func() {
+	It("parses the memory in megabyte", func() {
+		for memory, expected := range map[string]int{"": 0, "512M": 512, "256mb": 256, "1G": 1024, "2GB": 2048, "1T": 1024 * 1024} {
+			memoryInMB, err := Application{Memory: memory}.MemoryInMB()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(memoryInMB).To(Equal(expected))
+		}
+	})
+
+	It("rejects memory without unit", func() {
+		_, err := Application{Memory: "1024"}.MemoryInMB()
+		Expect(err).To(Equal(ErrInvalidMemory))
+	})
+
+	It("parses the instances", func() {
+		instances, err := Application{Instances: "3"}.InstanceCount()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(instances).To(Equal(3))
+
+		_, err = Application{Name: "app", Instances: "many"}.InstanceCount()
+		Expect(err).To(MatchError("instances many of application app is not a valid number"))
+	})
+})
+
+var _ = Describe("Parse full Manifest", func() {
+	It("parses all keys of the v3 manifest", func() {
+		manifest, _, err := ParseApplicationManifest("../fixtures/fullManifest.yml", "")
+		Expect(err).ToNot(HaveOccurred())
+
+		app := manifest.ApplicationManifests[0]
+		Expect(manifest.Version).To(Equal(1))
+		Expect(app.Buildpack).To(Equal("java_buildpack"))
+		Expect(app.LogRateLimitPerSecond).To(Equal("16K"))
+		Expect(app.RandomRoute).To(BeTrue())
+		Expect(app.ReadinessHealthCheckHTTPEndpoint).To(Equal("/ready"))
+		Expect(app.Services[0]).To(Equal("service1"))
+		Expect(app.Metadata.Labels["team"]).To(Equal("payments"))
+		Expect(app.Processes[1].Command).To(Equal("./worker"))
+		Expect(app.Processes[1].Unknown["x-process-note"]).To(Equal("kept"))
+		Expect(app.Sidecars[0].ProcessTypes).To(Equal([]string{"web"}))
+		Expect(app.Unknown).To(HaveKey("x-custom"))
+		Expect(manifest.Unknown["x-team"]).To(Equal("payments"))
+	})
+
+	It("keeps everything but the routes in the no route manifest", func() {
+		manifest, noRouteYmlPath, err := ParseApplicationManifest("../fixtures/fullManifest.yml", "")
+		Expect(err).ToNot(HaveOccurred())
+		noRouteYml, _, err := ParseApplicationManifest(noRouteYmlPath, "")
+		Expect(err).ToNot(HaveOccurred())
+
+		app := noRouteYml.ApplicationManifests[0]
+		Expect(app.Routes).To(BeEmpty())
+		Expect(app.NoRoute).To(BeTrue())
+		Expect(app.RandomRoute).To(BeFalse())
+		Expect(app.Services).To(Equal(manifest.ApplicationManifests[0].Services))
+		Expect(app.Processes).To(Equal(manifest.ApplicationManifests[0].Processes))
+		Expect(app.Sidecars).To(Equal(manifest.ApplicationManifests[0].Sidecars))
+		Expect(app.Metadata).To(Equal(manifest.ApplicationManifests[0].Metadata))
+		Expect(app.Unknown).To(Equal(manifest.ApplicationManifests[0].Unknown))
+		Expect(noRouteYml.Unknown).To(Equal(manifest.Unknown))
+	})
+})
+
+var _ = Describe("Interpolate Manifest", func() {
+	It("replaces nested variables, lists, env and services", func() {
+		manifest, _, err := ParseApplicationManifest("../fixtures/manifest_nested_vars.yml", "../fixtures/nested_vars_file.yml")
+		Expect(err).ToNot(HaveOccurred())
+
+		app := manifest.ApplicationManifests[0]
+		Expect(app.Name).To(Equal("myApp"))
+		Expect(app.Memory).To(Equal("1G"))
+		Expect(app.Instances).To(Equal("3"))
+		Expect(app.Buildpacks).To(Equal([]string{"java_buildpack", "go_buildpack"}))
+		Expect(app.Routes[0]["route"]).To(Equal("myApp.test.com"))
+		Expect(app.Services).To(Equal([]interface{}{"my-db"}))
+		Expect(app.Env).To(Equal(map[string]string{"STAGE": "production", "DB_POOL": "pool-10"}))
+	})
+
+	It("returns all undefined variables", func() {
+		_, _, err := ParseApplicationManifest("../fixtures/manifest_nested_vars.yml", "../fixtures/valid_vars_file.yml")
+		Expect(err).To(MatchError(ContainSubstring("expected to find variables: app.memory, app.name, buildpacks, database, domain, env_key, env_value, pool")))
+	})
+
+	It("keeps the type of a variable that replaces a whole value", func() {
+		document, err := Interpolate(map[interface{}]interface{}{"instances": "((instances))"}, Variables{"instances": 3})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(document).To(Equal(map[interface{}]interface{}{"instances": 3}))
+	})
+
+	It("rejects maps inside of a string", func() {
+		_, err := Interpolate("url-((app))", Variables{"app": map[interface{}]interface{}{"name": "myApp"}})
+		Expect(err).To(MatchError(ContainSubstring("variable 'app'")))
+	})
+})
+
+var _ = Describe("Compose Manifest", func() {
+	It("merges the inherited manifest and the global properties into the applications", func() {
+		manifest, _, err := ParseApplicationManifest("../fixtures/inheritManifest.yml", "")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(manifest.ApplicationManifests).To(HaveLen(1))
+		app := manifest.ApplicationManifests[0]
+		Expect(app.Memory).To(Equal("1G"))
+		Expect(app.Instances).To(Equal("2"))
+		Expect(app.Env).To(Equal(map[string]string{"COMMON": "shared", "STAGE": "prod"}))
+		Expect(app.Routes).To(Equal([]map[string]string{{"route": "prod.test.com"}}))
+		Expect(manifest.Unknown).ToNot(HaveKey("inherit"))
+	})
+
+	It("fails if a manifest inherits itself", func() {
+		_, _, err := ParseApplicationManifest("../fixtures/inheritLoopManifest.yml", "")
+		Expect(err).To(MatchError(ContainSubstring("inherits itself")))
+	})
+
+	It("applies the ops files before the variables are replaced", func() {
+		manifest, err := ComposeApplicationManifest("../fixtures/manifest.yml", []string{"../fixtures/prodOpsFile.yml"}, Variables{"instances": 4})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(manifest.ApplicationManifests).To(HaveLen(2))
+		app := manifest.ApplicationManifests[0]
+		Expect(app.Instances).To(Equal("4"))
+		Expect(app.Env["REGION"]).To(Equal("eu"))
+		Expect(app.Services).To(Equal([]interface{}{"service2"}))
+		Expect(manifest.ApplicationManifests[1].Name).To(Equal("myWorker"))
+		Expect(manifest.ApplicationManifests[1].NoRoute).To(BeTrue())
+	})
+
+	It("fails on a path that does not exist", func() {
+		document := map[interface{}]interface{}{"applications": []interface{}{map[interface{}]interface{}{"name": "myApp"}}}
+
+		_, err := ApplyOperations(document, []Operation{{Type: "replace", Path: "/applications/name=other/memory", Value: "1G"}})
+		Expect(err).To(MatchError(ContainSubstring("found no element with name=other")))
+
+		_, err = ApplyOperations(document, []Operation{{Type: "remove", Path: "/applications/0/memory"}})
+		Expect(err).To(MatchError(ContainSubstring("key memory not found")))
+
+		_, err = ApplyOperations(document, []Operation{{Type: "remove", Path: "/applications/0/memory?"}})
+		Expect(err).ToNot(HaveOccurred())
+	})
+})
+
+var _ = Describe("Validate Manifest", func() {
+	It("returns all problems with their line", func() {
+		problems := ValidateApplicationManifest("../fixtures/problemManifest.yml", nil, Variables{}, false)
+
+		var messages []string
+		for _, problem := range problems {
+			messages = append(messages, problem.String())
+		}
+		Expect(messages).To(Equal([]string{
+			"../fixtures/problemManifest.yml:4: applications[0].memory: memory has to be a number with a unit like M, MB, G or GB",
+			"../fixtures/problemManifest.yml:5: applications[0].instances: variable ((instances)) is not defined",
+			"../fixtures/problemManifest.yml:6: applications[0].path: " + ErrNoWildcardSupport.Error(),
+			"../fixtures/problemManifest.yml:8: applications[0].health-check-http-endpoint: health-check-http-endpoint can only be used with health-check-type http",
+			"../fixtures/problemManifest.yml:10: applications[0].routes[0].route: route https://myapp.test.com is not valid, use host.domain[:port][/path] without scheme",
+			"../fixtures/problemManifest.yml:13: applications[0].env.DB: variable ((db.url)) is not defined",
+			"../fixtures/problemManifest.yml:15: applications[1].disk_quota: disk_quota has to be a number with a unit like M, MB, G or GB",
+			"../fixtures/problemManifest.yml:18: applications[1].path: path can't be combined with docker",
+		}))
+	})
+
+	It("allows wildcard paths with legacy push and resolves the variables", func() {
+		problems := ValidateApplicationManifest("../fixtures/manifest_vars.yml", nil, Variables{"instances": 2, "memory": "1G", "host": "myHost", "domain_suffix": ".test.com"}, true)
+		Expect(problems).To(BeEmpty())
+	})
+
+	It("returns the line of a yaml syntax error", func() {
+		problems := ValidateApplicationManifest("../fixtures/invalidSyntaxManifest.yml", nil, Variables{}, false)
+		Expect(problems).To(HaveLen(1))
+		Expect(problems[0].Line).To(Equal(4))
+	})
+})
@@ -0,0 +1,259 @@
+package manifest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+//Operation of an ops file like bosh go-patch uses them, replace sets the value at the path and remove deletes it
+type Operation struct {
+	Type  string      `yaml:"type"`
+	Path  string      `yaml:"path"`
+	Value interface{} `yaml:"value,omitempty"`
+}
+
+//pathSegment one part of an operation path like /applications/name=app/env/KEY?
+type pathSegment struct {
+	key        string
+	index      int
+	isIndex    bool
+	isAppend   bool
+	matchKey   string
+	matchValue string
+	isMatch    bool
+	optional   bool
+}
+
+//LoadOpsFile reads the operations of an ops file
+func LoadOpsFile(opsFilePath string) ([]Operation, error) {
+	fileBytes, err := ioutil.ReadFile(opsFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading ops file: %s", opsFilePath)
+	}
+
+	var operations []Operation
+	err = yaml.Unmarshal(fileBytes, &operations)
+	if err != nil {
+		return nil, fmt.Errorf("error while parsing the ops file %s error: %v", opsFilePath, err)
+	}
+	return operations, nil
+}
+
+//ApplyOperations applies the operations in order to the yaml tree. A path segment with ? and all segments after it
+//are optional and will be created by replace, - appends to a list and key=value selects the map of a list with the value.
+func ApplyOperations(document interface{}, operations []Operation) (interface{}, error) {
+	for index, operation := range operations {
+		segments, err := parsePath(operation.Path)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d: %s", index+1, err)
+		}
+
+		switch operation.Type {
+		case "replace":
+			document, err = replaceAt(document, segments, operation.Value, false)
+		case "remove":
+			if len(segments) == 0 {
+				err = fmt.Errorf("the whole document can't be removed")
+			} else {
+				document, err = removeAt(document, segments, false)
+			}
+		default:
+			err = fmt.Errorf("unknown type %s, use replace or remove", operation.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("operation %d with path %s: %s", index+1, operation.Path, err)
+		}
+	}
+	return document, nil
+}
+
+func parsePath(path string) ([]pathSegment, error) {
+	if path == "/" || len(path) == 0 {
+		return nil, nil
+	}
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("path %s has to start with /", path)
+	}
+
+	replacer := strings.NewReplacer("~1", "/", "~0", "~")
+	var segments []pathSegment
+	for _, part := range strings.Split(path[1:], "/") {
+		segment := pathSegment{}
+		if strings.HasSuffix(part, "?") {
+			segment.optional = true
+			part = strings.TrimSuffix(part, "?")
+		}
+		part = replacer.Replace(part)
+
+		if part == "-" {
+			segment.isAppend = true
+		} else if index, err := strconv.Atoi(part); err == nil {
+			segment.index = index
+			segment.isIndex = true
+		} else if keyValue := strings.SplitN(part, "=", 2); len(keyValue) == 2 {
+			segment.matchKey = keyValue[0]
+			segment.matchValue = keyValue[1]
+			segment.isMatch = true
+		} else {
+			segment.key = part
+		}
+		segments = append(segments, segment)
+	}
+	return segments, nil
+}
+
+//findMatch returns the index of the map in the list that has the value at the key of the segment or -1
+func findMatch(list []interface{}, segment pathSegment) int {
+	for index, element := range list {
+		elementMap, ok := element.(map[interface{}]interface{})
+		if ok && fmt.Sprintf("%v", elementMap[segment.matchKey]) == segment.matchValue {
+			return index
+		}
+	}
+	return -1
+}
+
+func replaceAt(node interface{}, segments []pathSegment, value interface{}, optional bool) (interface{}, error) {
+	if len(segments) == 0 {
+		return value, nil
+	}
+	segment := segments[0]
+	optional = optional || segment.optional
+
+	if segment.isAppend || segment.isIndex || segment.isMatch {
+		list, ok := node.([]interface{})
+		if !ok && !(node == nil && optional) {
+			return nil, fmt.Errorf("expected a list for %s", segmentName(segment))
+		}
+
+		switch {
+		case segment.isAppend:
+			element, err := replaceAt(nil, segments[1:], value, true)
+			if err != nil {
+				return nil, err
+			}
+			return append(list, element), nil
+		case segment.isIndex:
+			if segment.index < 0 || segment.index >= len(list) {
+				return nil, fmt.Errorf("index %d is out of the list with %d elements", segment.index, len(list))
+			}
+			element, err := replaceAt(list[segment.index], segments[1:], value, optional)
+			if err != nil {
+				return nil, err
+			}
+			list[segment.index] = element
+			return list, nil
+		}
+
+		index := findMatch(list, segment)
+		if index < 0 {
+			if !optional {
+				return nil, fmt.Errorf("found no element with %s=%s", segment.matchKey, segment.matchValue)
+			}
+			list = append(list, map[interface{}]interface{}{segment.matchKey: segment.matchValue})
+			index = len(list) - 1
+		}
+		element, err := replaceAt(list[index], segments[1:], value, optional)
+		if err != nil {
+			return nil, err
+		}
+		list[index] = element
+		return list, nil
+	}
+
+	nodeMap, ok := node.(map[interface{}]interface{})
+	if !ok {
+		if node != nil || !optional {
+			return nil, fmt.Errorf("expected a map for key %s", segment.key)
+		}
+		nodeMap = map[interface{}]interface{}{}
+	}
+	child, found := nodeMap[segment.key]
+	if !found && !optional {
+		return nil, fmt.Errorf("key %s not found, mark it as optional with %s?", segment.key, segment.key)
+	}
+	element, err := replaceAt(child, segments[1:], value, optional)
+	if err != nil {
+		return nil, err
+	}
+	nodeMap[segment.key] = element
+	return nodeMap, nil
+}
+
+func removeAt(node interface{}, segments []pathSegment, optional bool) (interface{}, error) {
+	segment := segments[0]
+	optional = optional || segment.optional
+	last := len(segments) == 1
+
+	if segment.isAppend {
+		return nil, fmt.Errorf("- can't be used to remove a value")
+	}
+	if segment.isIndex || segment.isMatch {
+		list, ok := node.([]interface{})
+		if !ok {
+			if node == nil && optional {
+				return node, nil
+			}
+			return nil, fmt.Errorf("expected a list for %s", segmentName(segment))
+		}
+
+		index := segment.index
+		if segment.isMatch {
+			index = findMatch(list, segment)
+		}
+		if index < 0 || index >= len(list) {
+			if optional {
+				return list, nil
+			}
+			return nil, fmt.Errorf("element %s not found", segmentName(segment))
+		}
+		if last {
+			return append(list[:index], list[index+1:]...), nil
+		}
+		element, err := removeAt(list[index], segments[1:], optional)
+		if err != nil {
+			return nil, err
+		}
+		list[index] = element
+		return list, nil
+	}
+
+	nodeMap, ok := node.(map[interface{}]interface{})
+	if !ok {
+		if node == nil && optional {
+			return node, nil
+		}
+		return nil, fmt.Errorf("expected a map for key %s", segment.key)
+	}
+	child, found := nodeMap[segment.key]
+	if !found {
+		if optional {
+			return nodeMap, nil
+		}
+		return nil, fmt.Errorf("key %s not found, mark it as optional with %s?", segment.key, segment.key)
+	}
+	if last {
+		delete(nodeMap, segment.key)
+		return nodeMap, nil
+	}
+	element, err := removeAt(child, segments[1:], optional)
+	if err != nil {
+		return nil, err
+	}
+	nodeMap[segment.key] = element
+	return nodeMap, nil
+}
+
+func segmentName(segment pathSegment) string {
+	if segment.isAppend {
+		return "-"
+	}
+	if segment.isMatch {
+		return segment.matchKey + "=" + segment.matchValue
+	}
+	return strconv.Itoa(segment.index)
+}
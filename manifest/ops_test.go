@@ -0,0 +1,100 @@
+package manifest_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+
+	. "github.com/happytobi/cf-puppeteer/manifest"
+)
+
+//opsDocument manifest the operations are applied to
+const opsDocument = `
+applications:
+- name: myApp
+  memory: 512M
+  env:
+    REGION: us
+  services:
+  - database
+  - cache
+- name: myWorker
+  instances: 2
+`
+
+//applyOperation applies one operation to the document and returns the result as yaml
+func applyOperation(operation Operation) (string, error) {
+	var document interface{}
+	Expect(yaml.Unmarshal([]byte(opsDocument), &document)).To(Succeed())
+
+	result, err := ApplyOperations(document, []Operation{operation})
+	if err != nil {
+		return "", err
+	}
+	content, err := yaml.Marshal(result)
+	Expect(err).ToNot(HaveOccurred())
+	return string(content), nil
+}
+
+var _ = Describe("Ops file", func() {
+	It("reads the operations of an ops file", func() {
+		operations, err := LoadOpsFile("../fixtures/prodOpsFile.yml")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(operations).ToNot(BeEmpty())
+		Expect(operations[0].Type).To(Equal("replace"))
+	})
+
+	table.DescribeTable("applies the operation",
+		func(operation Operation, expectedContent string) {
+			content, err := applyOperation(operation)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(content).To(ContainSubstring(expectedContent))
+		},
+		table.Entry("replaces the value of the application with the name",
+			Operation{Type: "replace", Path: "/applications/name=myWorker/instances", Value: 4}, "- instances: 4\n  name: myWorker"),
+		table.Entry("replaces the value at the index",
+			Operation{Type: "replace", Path: "/applications/0/services/1", Value: "queue"}, "  - database\n  - queue\n"),
+		table.Entry("appends to the list with -",
+			Operation{Type: "replace", Path: "/applications/0/services/-", Value: "queue"}, "  - database\n  - cache\n  - queue\n"),
+		table.Entry("appends a new application with -",
+			Operation{Type: "replace", Path: "/applications/-", Value: map[string]string{"name": "myJob"}}, "- name: myJob"),
+		table.Entry("creates an optional key",
+			Operation{Type: "replace", Path: "/applications/name=myApp/env/ZONE?", Value: "b"}, "    REGION: us\n    ZONE: b\n"),
+		table.Entry("creates all keys after an optional one",
+			Operation{Type: "replace", Path: "/applications/name=myWorker/env?/REGION", Value: "eu"}, "- env:\n    REGION: eu\n  instances: 2\n  name: myWorker\n"),
+		table.Entry("adds an optional application with the name",
+			Operation{Type: "replace", Path: "/applications/name=myJob?/instances", Value: 1}, "- instances: 1\n  name: myJob"),
+		table.Entry("removes the key",
+			Operation{Type: "remove", Path: "/applications/name=myApp/env/REGION"}, "- env: {}\n  memory: 512M\n"),
+		table.Entry("removes the list element at the index",
+			Operation{Type: "remove", Path: "/applications/0/services/0"}, "  services:\n  - cache\n"),
+		table.Entry("removes the application with the name",
+			Operation{Type: "remove", Path: "/applications/name=myApp"}, "applications:\n- instances: 2\n  name: myWorker\n"),
+		table.Entry("ignores a missing optional key",
+			Operation{Type: "remove", Path: "/applications/name=myWorker/env?/REGION"}, "- instances: 2\n  name: myWorker\n"),
+	)
+
+	table.DescribeTable("fails on the operation",
+		func(operation Operation, expectedError string) {
+			_, err := applyOperation(operation)
+			Expect(err).To(MatchError(ContainSubstring(expectedError)))
+		},
+		table.Entry("replace of a missing key that is not optional",
+			Operation{Type: "replace", Path: "/applications/name=myWorker/env/REGION", Value: "eu"}, "key env not found, mark it as optional with env?"),
+		table.Entry("replace at an index out of the list",
+			Operation{Type: "replace", Path: "/applications/2/memory", Value: "1G"}, "index 2 is out of the list with 2 elements"),
+		table.Entry("remove at an index out of the list",
+			Operation{Type: "remove", Path: "/applications/0/services/5"}, "element 5 not found"),
+		table.Entry("remove with -",
+			Operation{Type: "remove", Path: "/applications/-"}, "- can't be used to remove a value"),
+		table.Entry("remove of the whole document",
+			Operation{Type: "remove", Path: "/"}, "the whole document can't be removed"),
+		table.Entry("a list segment on a map",
+			Operation{Type: "replace", Path: "/applications/0/env/0", Value: "x"}, "expected a list for 0"),
+		table.Entry("a path without leading /",
+			Operation{Type: "replace", Path: "applications", Value: "x"}, "path applications has to start with /"),
+		table.Entry("an unknown type",
+			Operation{Type: "move", Path: "/applications"}, "unknown type move, use replace or remove"),
+	)
+})
@@ -0,0 +1,263 @@
+package manifest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	yamlErrorLineRegex = regexp.MustCompile(`line (\d+):`)
+	routeRegex         = regexp.MustCompile(`(?i)^(\*|[a-z0-9_]([a-z0-9_-]*[a-z0-9_])?)(\.[a-z0-9_]([a-z0-9_-]*[a-z0-9_])?)+(:\d{1,5})?(/\S*)?$`)
+)
+
+//Problem of a manifest with its location, the line is 0 if the value is not in the manifest file
+type Problem struct {
+	File    string
+	Line    int
+	Path    string
+	Message string
+}
+
+func (problem Problem) String() string {
+	location := problem.File
+	if problem.Line > 0 {
+		location = fmt.Sprintf("%s:%d", location, problem.Line)
+	}
+	if len(problem.Path) > 0 {
+		return fmt.Sprintf("%s: %s: %s", location, problem.Path, problem.Message)
+	}
+	return fmt.Sprintf("%s: %s", location, problem.Message)
+}
+
+type validator struct {
+	file     string
+	locator  *lineLocator
+	problems []Problem
+}
+
+func (validator *validator) add(path []interface{}, format string, a ...interface{}) {
+	validator.problems = append(validator.problems, Problem{
+		File:    validator.file,
+		Line:    validator.locator.line(path),
+		Path:    formatPath(path),
+		Message: fmt.Sprintf(format, a...),
+	})
+}
+
+//ValidateApplicationManifest composes the manifest like ComposeApplicationManifest and returns all problems of it
+//with the line in the manifest file. Undefined variables, sizes, numbers, health checks, routes, docker and path
+//conflicts and wildcard paths are checked.
+func ValidateApplicationManifest(manifestFilePath string, opsFilePaths []string, variables Variables, legacyPush bool) []Problem {
+	validator := &validator{file: manifestFilePath, locator: newLineLocator(nil)}
+	source, err := ioutil.ReadFile(manifestFilePath)
+	if err != nil {
+		validator.add(nil, "could not read manifest: %s", err)
+		return validator.problems
+	}
+	validator.locator = newLineLocator(source)
+
+	var document interface{}
+	err = yaml.Unmarshal(source, &document)
+	if err != nil {
+		problem := Problem{File: manifestFilePath, Message: err.Error()}
+		if match := yamlErrorLineRegex.FindStringSubmatch(err.Error()); match != nil {
+			problem.Line, _ = strconv.Atoi(match[1])
+		}
+		return append(validator.problems, problem)
+	}
+	document, err = resolveInheritance(manifestFilePath, document, map[string]bool{})
+	if err != nil {
+		validator.add([]interface{}{"inherit"}, "%s", err)
+		return validator.problems
+	}
+	for _, opsFilePath := range opsFilePaths {
+		operations, err := LoadOpsFile(opsFilePath)
+		if err == nil {
+			document, err = ApplyOperations(document, operations)
+		}
+		if err != nil {
+			validator.problems = append(validator.problems, Problem{File: opsFilePath, Message: err.Error()})
+			return validator.problems
+		}
+	}
+
+	//undefined variables are reported where they are used, the validation continues with their placeholders
+	interpolator := &interpolator{variables: variables, missing: map[string]bool{}}
+	validator.validateVariables(document, nil, interpolator)
+	interpolatedDocument, err := interpolator.interpolate(document)
+	if err != nil {
+		validator.add(nil, "%s", err)
+		return validator.problems
+	}
+
+	manifest, err := convertDocument(applyGlobalProperties(interpolatedDocument))
+	if err != nil {
+		validator.add(nil, "%s", err)
+		return validator.problems
+	}
+	if len(manifest.ApplicationManifests) == 0 {
+		validator.add([]interface{}{"applications"}, "the manifest does not contain any application")
+	}
+	for index, application := range manifest.ApplicationManifests {
+		validator.validateApplication([]interface{}{"applications", index}, application, legacyPush)
+	}
+
+	sort.Slice(validator.problems, func(i, j int) bool {
+		first, second := validator.problems[i], validator.problems[j]
+		if first.Line != second.Line {
+			return first.Line < second.Line
+		}
+		return first.String() < second.String()
+	})
+	return validator.problems
+}
+
+func (validator *validator) validateVariables(node interface{}, path []interface{}, interpolator *interpolator) {
+	switch typed := node.(type) {
+	case map[interface{}]interface{}:
+		for key, value := range typed {
+			validator.validateVariables(key, path, interpolator)
+			validator.validateVariables(value, appendPath(path, fmt.Sprintf("%v", key)), interpolator)
+		}
+	case []interface{}:
+		for index, value := range typed {
+			validator.validateVariables(value, appendPath(path, index), interpolator)
+		}
+	case string:
+		for _, match := range interpolationRegex.FindAllStringSubmatch(typed, -1) {
+			if _, found := interpolator.lookup(match[1]); !found {
+				validator.add(path, "variable %s is not defined", match[0])
+			}
+		}
+	}
+}
+
+func appendPath(path []interface{}, elements ...interface{}) []interface{} {
+	newPath := make([]interface{}, 0, len(path)+len(elements))
+	newPath = append(newPath, path...)
+	return append(newPath, elements...)
+}
+
+//resolved returns false for empty values and values with undefined variables that were reported already
+func resolved(value string) bool {
+	return len(strings.TrimSpace(value)) > 0 && !interpolationRegex.MatchString(value)
+}
+
+func (validator *validator) validateSize(path []interface{}, key string, value string) {
+	if !resolved(value) {
+		return
+	}
+	if _, err := parseMegabytes(value); err != nil {
+		validator.add(appendPath(path, key), "%s has to be a number with a unit like M, MB, G or GB", key)
+	}
+}
+
+func (validator *validator) validateNumber(path []interface{}, key string, value string) {
+	if !resolved(value) {
+		return
+	}
+	if number, err := strconv.Atoi(strings.TrimSpace(value)); err != nil || number < 0 {
+		validator.add(appendPath(path, key), "%s has to be a positive number", key)
+	}
+}
+
+//validateHealthCheck checks the type and that the endpoint is only used with the http type, prefix is health-check or readiness-health-check
+func (validator *validator) validateHealthCheck(path []interface{}, prefix string, checkType string, endpoint string, types []string) {
+	if resolved(checkType) {
+		valid := false
+		for _, validType := range types {
+			valid = valid || checkType == validType
+		}
+		if !valid {
+			validator.add(appendPath(path, prefix+"-type"), "%s-type has to be one of %s", prefix, strings.Join(types, ", "))
+		}
+	}
+	if !resolved(endpoint) {
+		return
+	}
+	if checkType != "http" && !interpolationRegex.MatchString(checkType) {
+		validator.add(appendPath(path, prefix+"-http-endpoint"), "%s-http-endpoint can only be used with %s-type http", prefix, prefix)
+	}
+	if !strings.HasPrefix(endpoint, "/") {
+		validator.add(appendPath(path, prefix+"-http-endpoint"), "%s-http-endpoint has to be a path like /health", prefix)
+	}
+}
+
+func (validator *validator) validateApplication(path []interface{}, application Application, legacyPush bool) {
+	if len(strings.TrimSpace(application.Name)) == 0 {
+		validator.add(path, "name is required")
+	}
+	validator.validateSize(path, "memory", application.Memory)
+	validator.validateSize(path, "disk_quota", application.DiskQuota)
+	validator.validateNumber(path, "instances", application.Instances)
+	validator.validateNumber(path, "timeout", application.Timeout)
+	validator.validateNumber(path, "health-check-invocation-timeout", application.HealthCheckInvocationTimeout)
+	validator.validateNumber(path, "health-check-interval", application.HealthCheckInterval)
+	validator.validateHealthCheck(path, "health-check", application.HealthCheckType, application.HealthCheckHTTPEndpoint, []string{"port", "process", "http", "none"})
+	validator.validateHealthCheck(path, "readiness-health-check", application.ReadinessHealthCheckType, application.ReadinessHealthCheckHTTPEndpoint, []string{"port", "process", "http"})
+
+	for index, route := range application.Routes {
+		routePath := appendPath(path, "routes", index)
+		value, ok := route["route"]
+		if !ok {
+			validator.add(routePath, "route is required")
+			continue
+		}
+		if resolved(value) && !routeRegex.MatchString(value) {
+			validator.add(appendPath(routePath, "route"), "route %s is not valid, use host.domain[:port][/path] without scheme", value)
+		}
+	}
+	if len(application.Routes) > 0 && application.NoRoute {
+		validator.add(appendPath(path, "no-route"), "no-route can't be combined with routes")
+	}
+	if len(application.Routes) > 0 && application.RandomRoute {
+		validator.add(appendPath(path, "random-route"), "random-route can't be combined with routes")
+	}
+
+	if application.Docker != nil {
+		if len(strings.TrimSpace(application.Docker.Image)) == 0 {
+			validator.add(appendPath(path, "docker"), "docker needs an image")
+		}
+		if len(application.Path) > 0 {
+			validator.add(appendPath(path, "path"), "path can't be combined with docker")
+		}
+		if len(application.Buildpack) > 0 || len(application.Buildpacks) > 0 {
+			validator.add(appendPath(path, "docker"), "docker can't be combined with buildpacks")
+		}
+	}
+	if !legacyPush && strings.ContainsAny(application.Path, "*") {
+		validator.add(appendPath(path, "path"), "%s", ErrNoWildcardSupport)
+	}
+
+	for index, process := range application.Processes {
+		processPath := appendPath(path, "processes", index)
+		if len(strings.TrimSpace(process.Type)) == 0 {
+			validator.add(processPath, "type of the process is required")
+		}
+		validator.validateSize(processPath, "memory", process.Memory)
+		validator.validateSize(processPath, "disk_quota", process.DiskQuota)
+		validator.validateNumber(processPath, "instances", process.Instances)
+		validator.validateNumber(processPath, "timeout", process.Timeout)
+		validator.validateHealthCheck(processPath, "health-check", process.HealthCheckType, process.HealthCheckHTTPEndpoint, []string{"port", "process", "http", "none"})
+		validator.validateHealthCheck(processPath, "readiness-health-check", process.ReadinessHealthCheckType, process.ReadinessHealthCheckHTTPEndpoint, []string{"port", "process", "http"})
+	}
+	for index, sidecar := range application.Sidecars {
+		sidecarPath := appendPath(path, "sidecars", index)
+		if len(strings.TrimSpace(sidecar.Name)) == 0 {
+			validator.add(sidecarPath, "name of the sidecar is required")
+		}
+		if len(strings.TrimSpace(sidecar.Command)) == 0 {
+			validator.add(sidecarPath, "command of the sidecar is required")
+		}
+		if len(sidecar.ProcessTypes) == 0 {
+			validator.add(sidecarPath, "process_types of the sidecar are required")
+		}
+		validator.validateSize(sidecarPath, "memory", sidecar.Memory)
+	}
+}
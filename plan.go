@@ -0,0 +1,316 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/happytobi/cf-puppeteer/arguments"
+	"github.com/happytobi/cf-puppeteer/report"
+	"github.com/happytobi/cf-puppeteer/ui"
+
+	v2 "github.com/happytobi/cf-puppeteer/cf/v2"
+)
+
+//deploymentPlan steps a deployment of one application would run
+type deploymentPlan struct {
+	appName  string
+	strategy string
+	steps    []string
+}
+
+func (plan *deploymentPlan) add(format string, a ...interface{}) {
+	plan.steps = append(plan.steps, fmt.Sprintf(format, a...))
+}
+
+func (plan *deploymentPlan) addMapRoutes(routes []string, appName string) {
+	for _, route := range routes {
+		plan.add("map route %s to application %s", route, appName)
+	}
+}
+
+func (plan *deploymentPlan) addUnmapRoutes(routes []string, appName string) {
+	for _, route := range routes {
+		plan.add("unmap route %s from application %s", route, appName)
+	}
+}
+
+//addPush adds the push of the new application with the docker image or the manifest
+func (plan *deploymentPlan) addPush(parsedArguments *arguments.ParserArguments, appName string) {
+	if len(parsedArguments.DockerImage) > 0 {
+		plan.add("create application %s with docker image %s", appName, parsedArguments.DockerImage)
+		return
+	}
+	plan.add("create application %s and push it with manifest %s", appName, parsedArguments.ManifestPath)
+}
+
+//addRenameCurrent adds the rename of the current application, a venerable application of an earlier deployment
+//is deleted first
+func (plan *deploymentPlan) addRenameCurrent(appName string, venName string, venAppExists bool) {
+	if venAppExists {
+		plan.add("delete application %s", venName)
+	}
+	plan.add("rename application %s to %s", appName, venName)
+}
+
+//addSmokeTest adds the smoke test step if a smoke test was passed
+func (plan *deploymentPlan) addSmokeTest(parsedArguments *arguments.ParserArguments, appName string) {
+	if parsedArguments.SmokeTest.Enabled() && !parsedArguments.NoStart {
+		plan.add("run smoke test for application %s", appName)
+	}
+}
+
+//lookupApp returns the application or nil if it does not exist
+func lookupApp(ctx context.Context, resources v2.Resources, appName string) (*v2.AppResourcesEntity, error) {
+	app, err := resources.GetAppMetadata(ctx, appName)
+	if err == v2.ErrAppNotFound {
+		return nil, nil
+	}
+	return app, err
+}
+
+//planDeployment looks up the current and venerable application and resolves the routes to return the steps of
+//the deployment, steps that would do nothing are left out. Nothing will be changed
+func planDeployment(ctx context.Context, deployment *applicationDeployment) (*deploymentPlan, error) {
+	appRepo := deployment.appRepo
+	parsedArguments := deployment.parsedArguments
+
+	curApp, err := lookupApp(ctx, appRepo.v2Resources, parsedArguments.AppName)
+	if err != nil {
+		return nil, err
+	}
+	venApp, err := lookupApp(ctx, appRepo.v2Resources, deployment.venName)
+	if err != nil {
+		return nil, err
+	}
+	routes, err := deployment.push().ResolveRoutes(ctx, deployment.routes(), parsedArguments.LegacyPush)
+	if err != nil {
+		return nil, err
+	}
+
+	//the plan works on a copy with the state the first steps of the deployment would look up
+	planned := *deployment
+	planned.state = deploymentState{CurApp: curApp, VenApp: venApp}
+	plan := &deploymentPlan{appName: parsedArguments.AppName, strategy: parsedArguments.Strategy}
+
+	var venAppExists bool
+	switch parsedArguments.Strategy {
+	case arguments.StrategyBlueGreen:
+		venAppExists, err = plan.addBlueGreenSteps(ctx, &planned, routes)
+		if err != nil {
+			return nil, err
+		}
+	case arguments.StrategyCanary:
+		venAppExists = plan.addCanarySteps(&planned, routes)
+	case arguments.StrategyRolling:
+		venAppExists = plan.addRollingSteps(&planned, routes)
+	default:
+		venAppExists = plan.addVenerableSteps(&planned, routes)
+	}
+
+	err = plan.addVenerableActionSteps(ctx, &planned, venAppExists)
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+//addVenerableSteps adds the steps of the venerable strategy, returns if the venerable application exists afterwards
+func (plan *deploymentPlan) addVenerableSteps(deployment *applicationDeployment, routes []string) bool {
+	parsedArguments := deployment.parsedArguments
+	appName := parsedArguments.AppName
+	venName := deployment.venName
+	curApp := deployment.state.CurApp
+	venAppExists := deployment.state.VenApp != nil
+
+	if parsedArguments.AddRoutes {
+		if parsedArguments.NoStart {
+			return venAppExists
+		}
+		if curApp == nil || curApp.Entity.State != "STARTED" {
+			plan.add("start application %s", appName)
+		}
+		if !parsedArguments.NoRoute {
+			plan.addMapRoutes(routes, appName)
+			if venAppExists {
+				plan.addUnmapRoutes(routes, venName)
+			}
+		}
+		return venAppExists
+	}
+
+	if curApp != nil && curApp.Entity.State != "STARTED" {
+		plan.add("delete application %s because it is not started", appName)
+	} else if curApp != nil {
+		plan.addRenameCurrent(appName, venName, venAppExists)
+		venAppExists = true
+	}
+	plan.addPush(parsedArguments, appName)
+	if parsedArguments.NoStart {
+		return venAppExists
+	}
+	plan.add("start application %s", appName)
+	if !parsedArguments.NoRoute {
+		plan.addMapRoutes(routes, appName)
+		if venAppExists {
+			plan.addUnmapRoutes(routes, venName)
+		}
+	}
+	plan.addSmokeTest(parsedArguments, appName)
+	return venAppExists
+}
+
+//addBlueGreenSteps adds the steps of the blue-green strategy, returns if the venerable application exists afterwards
+func (plan *deploymentPlan) addBlueGreenSteps(ctx context.Context, deployment *applicationDeployment, routes []string) (bool, error) {
+	parsedArguments := deployment.parsedArguments
+	appName := parsedArguments.AppName
+	venName := deployment.venName
+	greenName := greenAppName(appName)
+	curApp := deployment.state.CurApp
+	venAppExists := deployment.state.VenApp != nil
+
+	greenApp, err := lookupApp(ctx, deployment.appRepo.v2Resources, greenName)
+	if err != nil {
+		return false, err
+	}
+	temporaryDomain := parsedArguments.TemporaryRouteDomain
+	if len(temporaryDomain) == 0 {
+		if len(deployment.routes()) == 0 {
+			return false, fmt.Errorf("no route found in manifest for application %s, pass --temporary-route-domain", appName)
+		}
+		temporaryDomain, err = deployment.push().ResolveDomain(ctx, deployment.routes()[0], parsedArguments.LegacyPush)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if greenApp != nil {
+		plan.add("delete application %s left over by an earlier deployment", greenName)
+	}
+	plan.addPush(parsedArguments, greenName)
+	plan.add("start application %s", greenName)
+	plan.add("map temporary route %s.%s to application %s", greenName, temporaryDomain, greenName)
+	plan.addSmokeTest(parsedArguments, greenName)
+	plan.addMapRoutes(routes, greenName)
+	if curApp != nil {
+		plan.addUnmapRoutes(routes, appName)
+	}
+	plan.add("unmap temporary route %s.%s from application %s", greenName, temporaryDomain, greenName)
+	if curApp != nil {
+		plan.addRenameCurrent(appName, venName, venAppExists)
+		venAppExists = true
+	}
+	plan.add("rename application %s to %s", greenName, appName)
+	return venAppExists, nil
+}
+
+//addCanarySteps adds the steps of the canary strategy, returns if the venerable application exists afterwards
+func (plan *deploymentPlan) addCanarySteps(deployment *applicationDeployment, routes []string) bool {
+	parsedArguments := deployment.parsedArguments
+	appName := parsedArguments.AppName
+	venName := deployment.venName
+	curApp := deployment.state.CurApp
+	venAppExists := deployment.state.VenApp != nil
+
+	if curApp == nil {
+		plan.addPush(parsedArguments, appName)
+		plan.add("start application %s", appName)
+		plan.addMapRoutes(routes, appName)
+		plan.addSmokeTest(parsedArguments, appName)
+		return venAppExists
+	}
+
+	plan.addRenameCurrent(appName, venName, venAppExists)
+	plan.addPush(parsedArguments, appName)
+	plan.add("start application %s with 1 instance", appName)
+	plan.addMapRoutes(routes, appName)
+	totalInstances := deployment.totalInstances()
+	for _, percentage := range parsedArguments.CanarySteps {
+		newInstances := canaryInstances(totalInstances, percentage)
+		plan.add("canary step %d%%: scale application %s to %d of %d instances and wait %d seconds", percentage, appName, newInstances, totalInstances, parsedArguments.CanaryInterval)
+		if percentage < 100 {
+			venInstances := totalInstances - newInstances
+			if venInstances < 1 {
+				venInstances = 1
+			}
+			plan.add("scale application %s down to %d instances", venName, venInstances)
+		}
+	}
+	plan.addUnmapRoutes(routes, venName)
+	plan.addSmokeTest(parsedArguments, appName)
+	return true
+}
+
+//addRollingSteps adds the steps of the rolling strategy, returns if the venerable application exists afterwards
+func (plan *deploymentPlan) addRollingSteps(deployment *applicationDeployment, routes []string) bool {
+	parsedArguments := deployment.parsedArguments
+	appName := parsedArguments.AppName
+
+	if deployment.state.CurApp != nil {
+		plan.add("apply the manifest to application %s without changing its routes and stage a new droplet", appName)
+		plan.add("roll out the new droplet to application %s with a rolling deployment", appName)
+	} else {
+		plan.addPush(parsedArguments, appName)
+		plan.add("start application %s", appName)
+	}
+	plan.addMapRoutes(routes, appName)
+	plan.addSmokeTest(parsedArguments, appName)
+	return deployment.state.VenApp != nil
+}
+
+//addVenerableActionSteps adds the steps that keep the droplet and the release or stop or delete the venerable
+//application
+func (plan *deploymentPlan) addVenerableActionSteps(ctx context.Context, deployment *applicationDeployment, venAppExists bool) error {
+	parsedArguments := deployment.parsedArguments
+	appName := parsedArguments.AppName
+	venName := deployment.venName
+
+	if parsedArguments.KeepDroplet && venAppExists {
+		plan.add("copy the droplet of application %s to application %s", venName, appName)
+	}
+	if parsedArguments.KeepReleases > 0 {
+		releases, err := listReleases(ctx, deployment.appRepo, appName)
+		if err != nil {
+			return err
+		}
+		if venAppExists {
+			number := nextReleaseNumber(releases)
+			plan.add("stop application %s and keep it as release %s", venName, releaseAppName(appName, number))
+			releases = append([]release{{Name: releaseAppName(appName, number), Number: number}}, releases...)
+		}
+		for _, prunedRelease := range prunedReleases(releases, parsedArguments.KeepReleases) {
+			plan.add("delete release %s", prunedRelease.Name)
+		}
+		return nil
+	}
+
+	if !venAppExists {
+		return nil
+	}
+	switch strings.ToLower(parsedArguments.VenerableAction) {
+	case "stop":
+		plan.add("stop application %s", venName)
+	case "delete":
+		plan.add("delete application %s", venName)
+	}
+	return nil
+}
+
+//printDeploymentPlans prints the plan of all applications of the manifest
+func printDeploymentPlans(ctx context.Context, appRepo *ApplicationRepo, parsedArguments *arguments.ParserArguments) error {
+	for _, applicationArguments := range parsedArguments.Applications {
+		plan, err := planDeployment(ctx, newApplicationDeployment(appRepo, applicationArguments, "", report.New()))
+		if err != nil {
+			return err
+		}
+
+		ui.Say("")
+		ui.Say("deployment plan for application %s with strategy %s:", plan.appName, plan.strategy)
+		for i, step := range plan.steps {
+			ui.Say("  %d. %s", i+1, step)
+		}
+	}
+	ui.Say("")
+	ui.InfoMessage("dry run finished, nothing was changed")
+	return nil
+}
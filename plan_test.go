@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	"code.cloudfoundry.org/cli/plugin/models"
+	"code.cloudfoundry.org/cli/plugin/pluginfakes"
+	"github.com/happytobi/cf-puppeteer/arguments"
+	"github.com/happytobi/cf-puppeteer/cf/ccv3"
+	"github.com/happytobi/cf-puppeteer/cf/cli"
+	"github.com/happytobi/cf-puppeteer/manifest"
+	"github.com/happytobi/cf-puppeteer/report"
+	"github.com/happytobi/cf-puppeteer/smoketest"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+
+	v2 "github.com/happytobi/cf-puppeteer/cf/v2"
+)
+
+//fakeAppResources returns the metadata of the existing applications
+type fakeAppResources struct {
+	v2.Resources
+	apps map[string]*v2.AppResourcesEntity
+}
+
+func (resources *fakeAppResources) GetAppMetadata(ctx context.Context, appName string) (*v2.AppResourcesEntity, error) {
+	app, found := resources.apps[appName]
+	if !found {
+		return nil, v2.ErrAppNotFound
+	}
+	return app, nil
+}
+
+func existingApp(state string, instances int) *v2.AppResourcesEntity {
+	return &v2.AppResourcesEntity{Entity: v2.Entity{State: state, Instances: instances}}
+}
+
+var _ = Describe("plan", func() {
+	var (
+		server    *httptest.Server
+		appRepo   *ApplicationRepo
+		resources *fakeAppResources
+	)
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/v3/domains":
+				_, _ = w.Write([]byte(`{"pagination": {}, "resources": [{"guid": "domain-guid", "name": "example.com"}]}`))
+			case "/v3/apps":
+				_, _ = w.Write([]byte(`{"pagination": {}, "resources": [
+					{"guid": "v1-guid", "name": "my-app-v1", "metadata": {"labels": {"cf-puppeteer/release": "1"}}},
+					{"guid": "v2-guid", "name": "my-app-v2", "metadata": {"labels": {"cf-puppeteer/release": "2"}}}]}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		cliConn := &pluginfakes.FakeCliConnection{}
+		cliConn.ApiEndpointReturns(server.URL, nil)
+		cliConn.AccessTokenReturns("bearer token", nil)
+		cliConn.GetCurrentSpaceReturns(plugin_models.Space{SpaceFields: plugin_models.SpaceFields{Guid: "space-guid", Name: "space"}}, nil)
+		resources = &fakeAppResources{apps: map[string]*v2.AppResourcesEntity{}}
+		appRepo = &ApplicationRepo{conn: cliConn, v2Resources: resources, v3Client: ccv3.NewClient(cli.NewHttpClient(cliConn, false, 30, false))}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	table.DescribeTable("steps of the deployment",
+		func(parsedArguments arguments.ParserArguments, apps map[string]*v2.AppResourcesEntity, expectedSteps []string) {
+			parsedArguments.AppName = "my-app"
+			parsedArguments.ManifestPath = "manifest.yml"
+			parsedArguments.ApplicationManifest = manifest.Application{Routes: []map[string]string{{"route": "my-app.example.com"}}}
+			resources.apps = apps
+
+			plan, err := planDeployment(context.Background(), newApplicationDeployment(appRepo, &parsedArguments, "", report.New()))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(plan.steps).To(Equal(expectedSteps))
+		},
+		table.Entry("venerable strategy on the first deployment",
+			arguments.ParserArguments{VenerableAction: "delete"},
+			map[string]*v2.AppResourcesEntity{},
+			[]string{
+				"create application my-app and push it with manifest manifest.yml",
+				"start application my-app",
+				"map route my-app.example.com to application my-app",
+			}),
+		table.Entry("venerable strategy replaces the current application",
+			arguments.ParserArguments{VenerableAction: "stop", SmokeTest: smoketest.SmokeTest{URL: "/health"}},
+			map[string]*v2.AppResourcesEntity{"my-app": existingApp("STARTED", 2), "my-app-venerable": existingApp("STOPPED", 1)},
+			[]string{
+				"delete application my-app-venerable",
+				"rename application my-app to my-app-venerable",
+				"create application my-app and push it with manifest manifest.yml",
+				"start application my-app",
+				"map route my-app.example.com to application my-app",
+				"unmap route my-app.example.com from application my-app-venerable",
+				"run smoke test for application my-app",
+				"stop application my-app-venerable",
+			}),
+		table.Entry("venerable strategy deletes the current application that is not started",
+			arguments.ParserArguments{VenerableAction: "none"},
+			map[string]*v2.AppResourcesEntity{"my-app": existingApp("STOPPED", 1)},
+			[]string{
+				"delete application my-app because it is not started",
+				"create application my-app and push it with manifest manifest.yml",
+				"start application my-app",
+				"map route my-app.example.com to application my-app",
+			}),
+		table.Entry("venerable strategy keeps the venerable application as release",
+			arguments.ParserArguments{KeepReleases: 2},
+			map[string]*v2.AppResourcesEntity{"my-app": existingApp("STARTED", 1)},
+			[]string{
+				"rename application my-app to my-app-venerable",
+				"create application my-app and push it with manifest manifest.yml",
+				"start application my-app",
+				"map route my-app.example.com to application my-app",
+				"unmap route my-app.example.com from application my-app-venerable",
+				"stop application my-app-venerable and keep it as release my-app-v3",
+				"delete release my-app-v1",
+			}),
+		table.Entry("blue-green strategy replaces the current application",
+			arguments.ParserArguments{Strategy: arguments.StrategyBlueGreen, VenerableAction: "delete"},
+			map[string]*v2.AppResourcesEntity{"my-app": existingApp("STARTED", 2), "my-app-green": existingApp("CRASHED", 1)},
+			[]string{
+				"delete application my-app-green left over by an earlier deployment",
+				"create application my-app-green and push it with manifest manifest.yml",
+				"start application my-app-green",
+				"map temporary route my-app-green.example.com to application my-app-green",
+				"map route my-app.example.com to application my-app-green",
+				"unmap route my-app.example.com from application my-app",
+				"unmap temporary route my-app-green.example.com from application my-app-green",
+				"rename application my-app to my-app-venerable",
+				"rename application my-app-green to my-app",
+				"delete application my-app-venerable",
+			}),
+		table.Entry("canary strategy shifts the instances of the current application",
+			arguments.ParserArguments{Strategy: arguments.StrategyCanary, CanarySteps: []int{50, 100}, CanaryInterval: 30, VenerableAction: "delete"},
+			map[string]*v2.AppResourcesEntity{"my-app": existingApp("STARTED", 4)},
+			[]string{
+				"rename application my-app to my-app-venerable",
+				"create application my-app and push it with manifest manifest.yml",
+				"start application my-app with 1 instance",
+				"map route my-app.example.com to application my-app",
+				"canary step 50%: scale application my-app to 2 of 4 instances and wait 30 seconds",
+				"scale application my-app-venerable down to 2 instances",
+				"canary step 100%: scale application my-app to 4 of 4 instances and wait 30 seconds",
+				"unmap route my-app.example.com from application my-app-venerable",
+				"delete application my-app-venerable",
+			}),
+		table.Entry("canary strategy on the first deployment",
+			arguments.ParserArguments{Strategy: arguments.StrategyCanary, CanarySteps: []int{50, 100}, VenerableAction: "delete"},
+			map[string]*v2.AppResourcesEntity{},
+			[]string{
+				"create application my-app and push it with manifest manifest.yml",
+				"start application my-app",
+				"map route my-app.example.com to application my-app",
+			}),
+		table.Entry("rolling strategy rolls out a new droplet of the current application",
+			arguments.ParserArguments{Strategy: arguments.StrategyRolling, VenerableAction: "delete"},
+			map[string]*v2.AppResourcesEntity{"my-app": existingApp("STARTED", 2)},
+			[]string{
+				"apply the manifest to application my-app without changing its routes and stage a new droplet",
+				"roll out the new droplet to application my-app with a rolling deployment",
+				"map route my-app.example.com to application my-app",
+			}),
+		table.Entry("route only mode maps the routes to the current application",
+			arguments.ParserArguments{AddRoutes: true, VenerableAction: "none"},
+			map[string]*v2.AppResourcesEntity{"my-app": existingApp("STARTED", 2)},
+			[]string{
+				"map route my-app.example.com to application my-app",
+			}),
+	)
+
+	It("does not change the state of the deployment", func() {
+		resources.apps = map[string]*v2.AppResourcesEntity{"my-app": existingApp("STARTED", 2)}
+		parsedArguments := &arguments.ParserArguments{AppName: "my-app", Strategy: arguments.StrategyCanary, CanarySteps: []int{100}}
+		deployment := newApplicationDeployment(appRepo, parsedArguments, "", report.New())
+
+		_, err := planDeployment(context.Background(), deployment)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deployment.state).To(Equal(deploymentState{}))
+	})
+
+	It("fails on a route without a known domain", func() {
+		parsedArguments := &arguments.ParserArguments{AppName: "my-app", ApplicationManifest: manifest.Application{Routes: []map[string]string{{"route": "my-app.unknown.org"}}}}
+
+		_, err := planDeployment(context.Background(), newApplicationDeployment(appRepo, parsedArguments, "", report.New()))
+		Expect(err).To(MatchError("could not find a domain for route my-app.unknown.org"))
+	})
+})
@@ -2,14 +2,22 @@ package main
 
 import (
 	"code.cloudfoundry.org/cli/plugin"
+	"context"
+	"errors"
 	"fmt"
 	"github.com/happytobi/cf-puppeteer/arguments"
 	"github.com/happytobi/cf-puppeteer/cf"
+	"github.com/happytobi/cf-puppeteer/cf/ccv3"
+	"github.com/happytobi/cf-puppeteer/cf/cli"
 	v2 "github.com/happytobi/cf-puppeteer/cf/v2"
+	"github.com/happytobi/cf-puppeteer/journal"
+	"github.com/happytobi/cf-puppeteer/report"
 	"github.com/happytobi/cf-puppeteer/rewind"
 	"github.com/happytobi/cf-puppeteer/ui"
 	"os"
 	"strings"
+	"sync"
+	"time"
 )
 
 func fatalIf(err error) {
@@ -25,25 +33,154 @@ func main() {
 
 type CfPuppeteerPlugin struct{}
 
+//retries of steps that failed because the cloud controller was not available
+var (
+	retryAttempts   = 5
+	retryBackoff    = 2 * time.Second
+	retryMaxBackoff = 30 * time.Second
+)
+
+//stepTimeout timeout of steps that only call the cloud controller, a hanging call fails the step instead of blocking
+var stepTimeout = 2 * time.Minute
+
 func venerableAppName(appName string) string {
 	return fmt.Sprintf("%s-venerable", appName)
 }
 
-func getActionsForApp(appRepo *ApplicationRepo, parsedArguments *arguments.ParserArguments) []rewind.Action {
-	venName := venerableAppName(parsedArguments.AppName)
-	puppeteerPush := cf.NewApplicationPush(appRepo.conn, appRepo.traceLogging)
+//applicationDeployment holds the state of one application of the manifest while it gets deployed
+type applicationDeployment struct {
+	appRepo         *ApplicationRepo
+	parsedArguments *arguments.ParserArguments
+	venName         string
+	state           deploymentState
+	output          ui.Prefixed
+	report          *report.Application
+	actions         *rewind.Actions
+	//journal persists the progress, resumed is set if the deployment was interrupted before
+	journal      *journal.Journal
+	journalEntry *journal.Application
+	resumed      bool
+}
+
+//deploymentState state of a deployment the steps and their rollback depend on, it is persisted in the journal
+type deploymentState struct {
+	CurApp         *v2.AppResourcesEntity `json:"current_app,omitempty"`
+	VenApp         *v2.AppResourcesEntity `json:"venerable_app,omitempty"`
+	Renamed        bool                   `json:"renamed,omitempty"`
+	CurrentRenamed bool                   `json:"current_renamed,omitempty"`
+	GreenRenamed   bool                   `json:"green_renamed,omitempty"`
+	RoutesSwitched bool                   `json:"routes_switched,omitempty"`
+	//instances of the venerable app before it was scaled down by a canary deployment
+	VenerableInstances int    `json:"venerable_instances,omitempty"`
+	TemporaryDomain    string `json:"temporary_domain,omitempty"`
+	//droplet of the application before a rolling deployment replaced it
+	PreviousDroplet string `json:"previous_droplet,omitempty"`
+	RolledOut       bool   `json:"rolled_out,omitempty"`
+}
+
+func newApplicationDeployment(appRepo *ApplicationRepo, parsedArguments *arguments.ParserArguments, outputPrefix string, deploymentReport *report.Report) *applicationDeployment {
+	return &applicationDeployment{
+		appRepo:         appRepo.withOutputPrefix(outputPrefix),
+		parsedArguments: parsedArguments,
+		venName:         venerableAppName(parsedArguments.AppName),
+		output:          ui.Prefixed{Prefix: outputPrefix},
+		report:          deploymentReport.Application(parsedArguments.AppName, parsedArguments.Strategy),
+	}
+}
+
+//push generate the cf puppeteer push for the deployment
+func (deployment *applicationDeployment) push() *cf.ApplicationPushData {
+	puppeteerPush := cf.NewApplicationPush(deployment.appRepo.conn, deployment.appRepo.traceLogging)
+	puppeteerPush.OutputPrefix = deployment.output.Prefix
+	return puppeteerPush
+}
+
+//routes returns the routes of the deployed application out of the manifest
+func (deployment *applicationDeployment) routes() []map[string]string {
+	return deployment.parsedArguments.ApplicationManifest.Routes
+}
+
+//appURL returns the url of the first manifest route
+func (deployment *applicationDeployment) appURL() string {
+	for _, route := range deployment.routes() {
+		if len(route["route"]) > 0 {
+			return fmt.Sprintf("https://%s", strings.TrimSpace(route["route"]))
+		}
+	}
+	return ""
+}
+
+//retryPolicy retries network bound steps that fail because the cloud controller is not available for a moment,
+//like while the foundation gets upgraded
+func (deployment *applicationDeployment) retryPolicy() *rewind.RetryPolicy {
+	return newRetryPolicy(deployment.output)
+}
+
+//newRetryPolicy retries errors of an unavailable cloud controller and prints a warning before each retry
+func newRetryPolicy(output ui.Prefixed) *rewind.RetryPolicy {
+	return &rewind.RetryPolicy{
+		MaxAttempts: retryAttempts,
+		Backoff:     retryBackoff,
+		MaxBackoff:  retryMaxBackoff,
+		Retryable:   cli.IsTransientError,
+		OnRetry: func(attempt int, err error, wait time.Duration) {
+			output.Warn("attempt %d failed because the cloud controller is not available - error: %s - retry in %s", attempt, err, wait)
+		},
+	}
+}
+
+//smokeTest runs the smoke test against the new application if one was passed
+func (deployment *applicationDeployment) smokeTest(ctx context.Context, appName string, appURL string) error {
+	smokeTest := deployment.parsedArguments.SmokeTest
+	if !smokeTest.Enabled() || deployment.parsedArguments.NoStart {
+		return nil
+	}
+	deployment.output.Say("run smoke test for application %s", appName)
+	err := smokeTest.Run(ctx, appName, appURL, deployment.output)
+	if err != nil {
+		return err
+	}
+	deployment.output.InfoMessage(fmt.Sprintf("smoke test for application %s passed", appName))
+	return nil
+}
+
+func getActionsForApp(deployment *applicationDeployment) []rewind.Action {
+	switch deployment.parsedArguments.Strategy {
+	case arguments.StrategyBlueGreen:
+		return getBlueGreenActionsForApp(deployment)
+	case arguments.StrategyCanary:
+		return getCanaryActionsForApp(deployment)
+	case arguments.StrategyRolling:
+		return getRollingActionsForApp(deployment)
+	}
+
+	appRepo := deployment.appRepo
+	parsedArguments := deployment.parsedArguments
+	venName := deployment.venName
+	output := deployment.output
+	puppeteerPush := deployment.push()
+	retry := deployment.retryPolicy()
 	var err error
-	var curApp *v2.AppResourcesEntity
-	var venApp *v2.AppResourcesEntity
+
+	//deleteApp removes the new application, it will be called when the push or a later step fails
+	deleteApp := func(ctx context.Context) error {
+		if parsedArguments.AddRoutes {
+			return nil
+		}
+		return appRepo.v2Resources.DeleteApplication(ctx, parsedArguments.AppName)
+	}
 
 	return []rewind.Action{
 		// get info about current app
 		{
-			Forward: func() error {
-				curApp, err = appRepo.v2Resources.GetAppMetadata(parsedArguments.AppName)
+			Name:    "get current application",
+			Retry:   retry,
+			Timeout: stepTimeout,
+			Forward: func(ctx context.Context) error {
+				deployment.state.CurApp, err = appRepo.v2Resources.GetAppMetadata(ctx, parsedArguments.AppName)
 				if err != nil {
 					if err == v2.ErrAppNotFound {
-						curApp = nil
+						deployment.state.CurApp = nil
 					} else {
 						return err
 					}
@@ -53,11 +190,14 @@ func getActionsForApp(appRepo *ApplicationRepo, parsedArguments *arguments.Parse
 		},
 		// get info about ven app
 		{
-			Forward: func() error {
-				venApp, err = appRepo.v2Resources.GetAppMetadata(venName)
+			Name:    "get venerable application",
+			Retry:   retry,
+			Timeout: stepTimeout,
+			Forward: func(ctx context.Context) error {
+				deployment.state.VenApp, err = appRepo.v2Resources.GetAppMetadata(ctx, venName)
 				if err != nil {
 					if err == v2.ErrAppNotFound {
-						venApp = nil
+						deployment.state.VenApp = nil
 					} else {
 						return err
 					}
@@ -67,7 +207,9 @@ func getActionsForApp(appRepo *ApplicationRepo, parsedArguments *arguments.Parse
 		},
 		// rename any existing app such so that next step can push to a clear space
 		{
-			Forward: func() error {
+			Name: "rename current application",
+			Forward: func(ctx context.Context) error {
+				curApp := deployment.state.CurApp
 				// If there is no current app running, that's great, we're done here
 				if curApp == nil {
 					return nil
@@ -75,92 +217,152 @@ func getActionsForApp(appRepo *ApplicationRepo, parsedArguments *arguments.Parse
 
 				// If current app isn't started, then we'll just delete it, and we're done => only if route switching was not used
 				if curApp.Entity.State != "STARTED" && parsedArguments.AddRoutes == false {
-					return appRepo.v2Resources.DeleteApplication(parsedArguments.AppName)
+					return appRepo.v2Resources.DeleteApplication(ctx, parsedArguments.AppName)
 				}
 
 				// Do we have a ven app that will stop a rename? -> normal workflow only if we dont run the add routes mode
-				if venApp != nil && parsedArguments.AddRoutes == false {
+				if deployment.state.VenApp != nil && parsedArguments.AddRoutes == false {
 					// Finally, since the current app claims to be healthy, we'll delete the venerable app, and rename the current over the top
-					err = appRepo.v2Resources.DeleteApplication(venName)
+					err = appRepo.v2Resources.DeleteApplication(ctx, venName)
 					if err != nil {
 						return err
 					}
 				}
 
 				if parsedArguments.AddRoutes == false {
-					return appRepo.v2Resources.RenameApplication(parsedArguments.AppName, venName)
+					err = appRepo.v2Resources.RenameApplication(ctx, parsedArguments.AppName, venName)
+					if err != nil {
+						return err
+					}
+					deployment.state.Renamed = true
 				}
 				return nil
 			},
+			//an interrupted deployment may have renamed the current app already
+			ReversePrevious: deployment.renameBackInterrupted,
+			Cleanup:         deployment.renameBackInterrupted,
+			Reverse: func(ctx context.Context) error {
+				if !deployment.state.Renamed {
+					return nil
+				}
+				deployment.state.Renamed = false
+				return appRepo.v2Resources.RenameApplication(ctx, venName, parsedArguments.AppName)
+			},
 		},
 		// push
 		{
-			Forward: func() error {
-				venAppExists := venApp != nil
+			Name: "push application",
+			Forward: func(ctx context.Context) error {
+				venAppExists := deployment.state.VenApp != nil
 				space, err := appRepo.conn.GetCurrentSpace()
 				if err != nil {
 					return err
 				}
 				if parsedArguments.AddRoutes == false {
-					return puppeteerPush.PushApplication(venName, venAppExists, space.Guid, parsedArguments)
+					return puppeteerPush.PushApplication(ctx, venName, venAppExists, space.Guid, parsedArguments)
 				}
 				return nil
 			},
 			//When upload fails the new application will be deleted and ven app will be renamed
-			ReversePrevious: func() error {
-				ui.FailedMessage("error while uploading / deploying the application... roll everything back")
-				_ = appRepo.v2Resources.DeleteApplication(parsedArguments.AppName)
-				_ = appRepo.v2Resources.RenameApplication(venName, parsedArguments.AppName)
-				return nil
+			ReversePrevious: func(ctx context.Context) error {
+				output.FailedMessage("error while uploading / deploying the application... roll everything back")
+				return deleteApp(ctx)
 			},
+			Reverse: deleteApp,
 		},
 		// start
 		{
-			Forward: func() error {
+			Name:  "start application",
+			Retry: retry,
+			Forward: func(ctx context.Context) error {
 				if parsedArguments.NoStart == false {
-					return appRepo.v2Resources.StartApplication(parsedArguments.AppName)
+					return appRepo.v2Resources.StartApplication(ctx, parsedArguments.AppName)
 				}
 				return nil
 			},
-			ReversePrevious: func() error {
+			ReversePrevious: func(ctx context.Context) error {
 				if parsedArguments.ShowCrashLogs {
 					//print logs before application delete
-					ui.Say("show crash logs")
-					_ = appRepo.v2Resources.ShowCrashLogs(parsedArguments.AppName)
+					output.Say("show crash logs")
+					_ = appRepo.v2Resources.ShowCrashLogs(ctx, parsedArguments.AppName)
 				}
-
-				// If the app cannot start we'll have a lingering application
-				// We delete this application so that the rename can succeed
-				_ = appRepo.v2Resources.DeleteApplication(parsedArguments.AppName)
-				return appRepo.v2Resources.RenameApplication(venName, parsedArguments.AppName)
+				return nil
 			},
 		},
 		//switch routes because new application was started correct
 		{
-			Forward: func() error {
+			Name:    "switch routes",
+			Retry:   retry,
+			Timeout: stepTimeout,
+			Forward: func(ctx context.Context) error {
 				//switch route only is application was started and route switch option was set
-				ui.Say("check if routes should be added or switched from existing one")
+				output.Say("check if routes should be added or switched from existing one")
 				if parsedArguments.NoStart == false && parsedArguments.NoRoute == false {
-					venAppExists := venApp != nil
-					return puppeteerPush.SwitchRoutes(venName, venAppExists, parsedArguments.AppName, parsedArguments.Manifest.ApplicationManifests[0].Routes, parsedArguments.LegacyPush)
+					//the current app was renamed to the venerable one or the venerable app of an earlier deployment is kept
+					venAppExists := deployment.state.Renamed || deployment.state.VenApp != nil
+					err := puppeteerPush.SwitchRoutes(ctx, venName, venAppExists, parsedArguments.AppName, deployment.routes(), parsedArguments.LegacyPush)
+					if err != nil {
+						return err
+					}
+					deployment.state.RoutesSwitched = true
+					return nil
 				}
-				ui.Say("nothing to do")
+				output.Say("nothing to do")
 				return nil
 			},
-			ReversePrevious: func() error {
-				// If the app cannot start we'll have a lingering application
-				// We delete this application so that the rename can succeed
-				_ = appRepo.v2Resources.DeleteApplication(parsedArguments.AppName)
-				return appRepo.v2Resources.RenameApplication(venName, parsedArguments.AppName)
+			Reverse: func(ctx context.Context) error {
+				//route only mode keeps the added routes, the application was not replaced
+				if !deployment.state.RoutesSwitched || !deployment.state.Renamed {
+					return nil
+				}
+				deployment.state.RoutesSwitched = false
+				return puppeteerPush.SwitchRoutes(ctx, parsedArguments.AppName, true, venName, deployment.routes(), parsedArguments.LegacyPush)
+			},
+		},
+		//smoke test the new application before the venerable action deletes or stops the old one
+		{
+			Name: "smoke test",
+			Forward: func(ctx context.Context) error {
+				if parsedArguments.AddRoutes {
+					return nil
+				}
+				return deployment.smokeTest(ctx, parsedArguments.AppName, deployment.appURL())
+			},
+			ReversePrevious: func(ctx context.Context) error {
+				output.FailedMessage("smoke test failed... put the venerable application back in place")
+				return nil
 			},
 		},
+	}
+}
+
+//getVenerableActionsForApp handles the venerable application after all applications of the manifest were deployed
+func getVenerableActionsForApp(deployment *applicationDeployment) []rewind.Action {
+	var actions []rewind.Action
+	if deployment.parsedArguments.KeepDroplet {
+		actions = append(actions, getKeepDropletAction(deployment))
+	}
+	if deployment.parsedArguments.KeepReleases > 0 {
+		return append(actions, getReleaseActionsForApp(deployment)...)
+	}
+
+	appRepo := deployment.appRepo
+	parsedArguments := deployment.parsedArguments
+	venName := deployment.venName
+	retry := deployment.retryPolicy()
+	var err error
+
+	return append(actions, []rewind.Action{
 		//check vor venerable application again -> because venerable action was set correct and ven app could exist now.
 		{
-			Forward: func() error {
-				venApp, err = appRepo.v2Resources.GetAppMetadata(venName)
+			Name:    "get venerable application",
+			Retry:   retry,
+			Timeout: stepTimeout,
+			Forward: func(ctx context.Context) error {
+				deployment.state.VenApp, err = appRepo.v2Resources.GetAppMetadata(ctx, venName)
 				if err != nil {
 					if err == v2.ErrAppNotFound {
-						venApp = nil
+						deployment.state.VenApp = nil
 					} else {
 						return err
 					}
@@ -170,23 +372,147 @@ func getActionsForApp(appRepo *ApplicationRepo, parsedArguments *arguments.Parse
 		},
 		// delete
 		{
-			Forward: func() error {
+			Name:    "venerable action",
+			Retry:   retry,
+			Timeout: stepTimeout,
+			Forward: func(ctx context.Context) error {
+				venApp := deployment.state.VenApp
 				//if venerableAction was set to stop
 				if strings.ToLower(parsedArguments.VenerableAction) == "stop" && venApp != nil {
-					return appRepo.v2Resources.StopApplication(venName)
+					return appRepo.v2Resources.StopApplication(ctx, venName)
 				} else if strings.ToLower(parsedArguments.VenerableAction) == "delete" && venApp != nil {
-					return appRepo.v2Resources.DeleteApplication(venName)
+					return appRepo.v2Resources.DeleteApplication(ctx, venName)
 				}
 				//do nothing with the ven app
 				return nil
 			},
 		},
+	}...)
+}
+
+//rollback reverses all steps of an application that was already deployed successfully
+func (deployment *applicationDeployment) rollback() error {
+	deployment.output.FailedMessage(fmt.Sprintf("roll back application %s", deployment.parsedArguments.AppName))
+	return deployment.actions.Rollback()
+}
+
+//rollbackDeployments rolls back all applications that were deployed before another application of the manifest failed
+func rollbackDeployments(deployments []*applicationDeployment) {
+	for i := len(deployments) - 1; i >= 0; i-- {
+		err := deployments[i].rollback()
+		if err != nil {
+			ui.Warn("could not roll back application %s - error: %s", deployments[i].parsedArguments.AppName, err)
+		}
+	}
+}
+
+//collectReportState adds guids, droplet and routes of the deployed application to the report
+func (deployment *applicationDeployment) collectReportState(ctx context.Context) {
+	appRepo := deployment.appRepo
+	app, err := appRepo.v2Resources.GetAppMetadata(ctx, deployment.parsedArguments.AppName)
+	if err != nil {
+		ui.DebugMessage("could not read application %s for the report: %s", deployment.parsedArguments.AppName, err)
+		return
 	}
+
+	venerableGUID := ""
+	venApp, err := appRepo.v2Resources.GetAppMetadata(ctx, deployment.venName)
+	if err == nil {
+		venerableGUID = venApp.Metadata.GUID
+	}
+
+	dropletGUID := ""
+	droplet, err := appRepo.v3Client.GetCurrentDroplet(ctx, app.Metadata.GUID)
+	if err == nil {
+		dropletGUID = droplet.GUID
+	}
+
+	var routes []string
+	appRoutes, err := appRepo.v3Client.GetAppRoutes(ctx, app.Metadata.GUID)
+	if err == nil {
+		routes = []string{}
+		for _, route := range appRoutes {
+			routes = append(routes, route.URL)
+		}
+	}
+	deployment.report.SetState(app.Metadata.GUID, venerableGUID, dropletGUID, routes)
+}
+
+//deployApplications deploys all applications of the manifest with at most parsedArguments.Parallel deployments at a time,
+//when one of them fails no further deployment will be started and all successful ones will be rolled back
+func deployApplications(ctx context.Context, appRepo *ApplicationRepo, parsedArguments *arguments.ParserArguments, deploymentReport *report.Report, deploymentJournal *journal.Journal) ([]*applicationDeployment, error) {
+	parallel := parsedArguments.Parallel > 1 && len(parsedArguments.Applications) > 1
+	slots := make(chan struct{}, parsedArguments.Parallel)
+
+	var waitGroup sync.WaitGroup
+	var mutex sync.Mutex
+	var deployments []*applicationDeployment
+	var errs []string
+
+	for _, applicationArguments := range parsedArguments.Applications {
+		slots <- struct{}{}
+		mutex.Lock()
+		failed := len(errs) > 0
+		mutex.Unlock()
+		if failed {
+			<-slots
+			break
+		}
+
+		outputPrefix := ""
+		if parallel {
+			outputPrefix = fmt.Sprintf("[%s] ", applicationArguments.AppName)
+		}
+		deployment := newApplicationDeployment(appRepo, applicationArguments, outputPrefix, deploymentReport)
+
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			defer func() { <-slots }()
+
+			deployment.actions = &rewind.Actions{
+				Actions:              getActionsForApp(deployment),
+				RewindFailureMessage: "Oh no. Something's gone wrong. I've tried to roll back but you should check to see if everything is OK.",
+				Reporter:             deployment.report.AddStep,
+				Checkpoint:           deployment.checkpoint,
+			}
+			err := deployment.useJournal(deploymentJournal)
+			if err == nil {
+				err = deployment.execute(ctx)
+			}
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				if len(parsedArguments.Applications) > 1 {
+					err = fmt.Errorf("%s: %s", deployment.parsedArguments.AppName, err)
+				}
+				errs = append(errs, err.Error())
+				return
+			}
+			deployments = append(deployments, deployment)
+		}()
+	}
+	waitGroup.Wait()
+
+	if len(errs) > 0 {
+		rollbackDeployments(deployments)
+		return nil, errors.New(strings.Join(errs, "; "))
+	}
+	return deployments, nil
 }
 
 func (plugin CfPuppeteerPlugin) Run(cliConnection plugin.CliConnection, args []string) {
 	// only handle if actually invoked, else it can't be uninstalled cleanly
-	if args[0] != "zero-downtime-push" {
+	if args[0] != "zero-downtime-push" && args[0] != "puppeteer-rollback" && args[0] != "puppeteer-validate" {
+		return
+	}
+
+	//the manifest is validated locally, no connection to cloud foundry is needed
+	if args[0] == "puppeteer-validate" {
+		validateArguments, err := arguments.ParseValidateArgs(args)
+		fatalIf(err)
+		fatalIf(validateManifest(validateArguments))
 		return
 	}
 
@@ -194,20 +520,125 @@ func (plugin CfPuppeteerPlugin) Run(cliConnection plugin.CliConnection, args []s
 	if os.Getenv("CF_TRACE") == "true" {
 		traceLogging = true
 	}
-	appRepo := NewApplicationRepo(cliConnection, traceLogging)
+	appRepo := NewApplicationRepo(cli.NewSynchronizedConnection(cliConnection), traceLogging)
+
+	if args[0] == "puppeteer-rollback" {
+		rollbackArguments, err := arguments.ParseRollbackArgs(args)
+		fatalIf(err)
+		fatalIf(rollbackApplication(appRepo, rollbackArguments))
+		ui.Say("")
+		ui.Say("Application %s was rolled back to the venerable version!", rollbackArguments.AppName)
+		ui.Say("")
+		return
+	}
+
+	//--resume and --rollback read the arguments of the interrupted deployment out of the journal
+	journalArguments, err := arguments.ParseJournalArgs(args)
+	fatalIf(err)
+	if journalArguments != nil && journalArguments.Rollback {
+		fatalIf(rollbackInterruptedDeployment(appRepo, journalArguments.JournalPath))
+		ui.Say("")
+		ui.Say("The interrupted deployment was rolled back.")
+		ui.Say("")
+		return
+	}
+
+	var deploymentJournal *journal.Journal
+	if journalArguments != nil {
+		deploymentJournal, err = journal.Load(journalArguments.JournalPath)
+		fatalIf(err)
+		args = deploymentJournal.Args
+	}
+
 	parsedArguments, err := arguments.ParseArgs(args)
 	fatalIf(err)
+	//the json report is the only output on stdout, so it can be parsed
+	if parsedArguments.ReportPath == report.Stdout {
+		ui.SetOutput(os.Stderr)
+	}
+	if deploymentJournal != nil {
+		ui.Say("resume deployment started at %s", deploymentJournal.StartedAt.Format(time.RFC3339))
+	}
 
-	fatalIf((&rewind.Actions{
-		Actions:              getActionsForApp(appRepo, parsedArguments),
-		RewindFailureMessage: "Oh no. Something's gone wrong. I've tried to roll back but you should check to see if everything is OK.",
-	}).Execute())
+	if parsedArguments.DryRun {
+		fatalIf(printDeploymentPlans(context.Background(), appRepo, parsedArguments))
+		return
+	}
+
+	//the quota is checked before anything is renamed, a resumed deployment uses parts of it already
+	if deploymentJournal == nil {
+		fatalIf(checkQuota(context.Background(), appRepo, parsedArguments))
+	}
+
+	if deploymentJournal == nil {
+		deploymentJournal, err = journal.New(parsedArguments.JournalPath, args)
+		fatalIf(err)
+	}
+
+	//-t is the deadline of the whole deployment, no further step will be started afterwards and everything is rolled back
+	ctx, cancel := context.WithCancel(context.Background())
+	if parsedArguments.Deadline > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), parsedArguments.Deadline)
+	}
+	defer cancel()
+
+	//SIGINT and SIGTERM stop the deployment of the applications and roll them back
+	deployCtx, cancelDeploy := context.WithCancel(ctx)
+	defer cancelDeploy()
+	stopTrap := trapSignals(cancelDeploy, deploymentJournal.Path())
+
+	//deploy all applications of the manifest, when one of them fails all others will be rolled back
+	deploymentReport := report.New()
+	deployments, err := deployApplications(deployCtx, appRepo, parsedArguments, deploymentReport, deploymentJournal)
+	if err == nil && deployCtx.Err() != nil {
+		//the deployment was canceled after its last step, roll it back before the venerable action runs
+		rollbackDeployments(deployments)
+		deployments, err = nil, deployCtx.Err()
+	}
+
+	//venerable applications will be touched after all applications are running
+	for _, deployment := range deployments {
+		if err != nil {
+			break
+		}
+		deployment.finalize()
+		err = (&rewind.Actions{
+			Actions:  getVenerableActionsForApp(deployment),
+			Reporter: deployment.report.AddStep,
+		}).Execute(ctx)
+	}
+	interrupted := stopTrap()
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("deployment did not finish within %s: %s", parsedArguments.Deadline, err)
+	}
+
+	if len(parsedArguments.ReportPath) > 0 {
+		for _, deployment := range deployments {
+			deployment.collectReportState(context.Background())
+		}
+		deploymentReport.Finish(err)
+		writeErr := deploymentReport.Write(parsedArguments.ReportPath)
+		if writeErr != nil {
+			ui.Warn("could not write deployment report %s - error: %s", parsedArguments.ReportPath, writeErr)
+		}
+	}
+	//the journal is only kept if the deployment gets interrupted or the rollback failed, --rollback retries it
+	if deploymentJournal.Pending() {
+		ui.Warn("the rollback did not finish, the deployment journal %s is kept - run --rollback --journal %s to undo the remaining steps", deploymentJournal.Path(), deploymentJournal.Path())
+	} else {
+		removeJournal(deploymentJournal)
+	}
+	if err != nil && interrupted != nil {
+		ui.Failed("deployment was interrupted by %s and rolled back - error: %s", interrupted, err)
+		os.Exit(signalExitCode(interrupted))
+	}
+	fatalIf(err)
 
 	ui.Say("")
 	ui.Say("A new version of your application has successfully been pushed!")
 	ui.Say("")
 
-	_ = appRepo.v2Resources.ListApplications()
+	_ = appRepo.v2Resources.ListApplications(context.Background())
 }
 
 // GetMetadata get plugin metadata
@@ -224,12 +655,12 @@ func (CfPuppeteerPlugin) GetMetadata() plugin.PluginMetadata {
 				Name:     "zero-downtime-push",
 				HelpText: "Perform a zero-downtime push of an application over the top of an old one",
 				UsageDetails: plugin.Usage{
-					Usage: "$ cf zero-downtime-push [<App-Name>] -f <Manifest.yml> [options]",
+					Usage: "$ cf zero-downtime-push [<App-Name>] -f <Manifest.yml> [options]\n   $ cf zero-downtime-push --resume | --rollback [--journal <journal.json>]",
 					Options: map[string]string{
 						"f":                           "path to application manifest",
 						"p":                           "path to application files",
 						"s":                           "name of the stack to use",
-						"t":                           "push timeout (in seconds)",
+						"t":                           "deadline of the whole deployment (in seconds), completed steps are rolled back when it is exceeded",
 						"-env":                        "add environment key value pairs dynamic; can specify multiple times",
 						"-venerable-action":           "option to delete, stop or do nothing with venerable application - default is delete",
 						"-health-check-type":          "type of health check to perform",
@@ -243,7 +674,58 @@ func (CfPuppeteerPlugin) GetMetadata() plugin.PluginMetadata {
 						"-no-start":                   "don't start application after deployment; venerable action will none",
 						"-docker-image":               "docker image url",
 						"-docker-username":            "docker repository username; used with password from env CF_DOCKER_PASSWORD",
-						"-vars-file":                  "path to a variable substitution file for manifest",
+						"-vars-file":                  "path to a variable substitution file for manifest; can specify multiple times",
+						"-var":                        "variable key value pair for variable substitution, (e.g., name=app1); can specify multiple times",
+						"-ops-file":                   "path to an ops file with replace and remove operations for the manifest; can specify multiple times",
+						"-strategy":                   "deployment strategy venerable, blue-green, canary or rolling - default is venerable",
+						"-canary-steps":               "percentage of instances of the new application for each canary step - default is 10,50,100",
+						"-canary-interval":            "seconds to wait and check the health of the new application between the canary steps - default is 60",
+						"-temporary-route-domain":     "domain of the temporary <app>-green route used by the blue-green strategy - default is the domain of the first manifest route",
+						"-smoke-test-url":             "url or path like /health on the application url that is called after the new application is started and routed, before the venerable action",
+						"-smoke-test-status":          "expected http status code of the smoke test url - default is 200",
+						"-smoke-test-body":            "regular expression the response body of the smoke test url has to match",
+						"-smoke-test-command":         "local command that runs as smoke test, the application url is passed in env PUPPETEER_APP_URL",
+						"-smoke-test-timeout":         "timeout of the smoke test in seconds - default is 60",
+						"-parallel":                   "number of applications of a multi application manifest that will be deployed in parallel - default is 1",
+						"-dry-run":                    "print the deployment plan without changing anything",
+						"-report":                     "path of a json deployment report, - writes the report to stdout and all other output to stderr",
+						"-journal":                    "path of the journal of completed steps - default is " + journal.DefaultPath,
+						"-journal-labels":             "mirror the progress of the deployment as labels on the applications",
+						"-resume":                     "continue an interrupted deployment out of the journal",
+						"-rollback":                   "undo the completed steps of an interrupted deployment out of the journal",
+						"-keep-droplet":               "copy the droplet of the venerable application to the new application for cf puppeteer-rollback --droplet",
+						"-keep-releases":              "keep the venerable application as stopped release <App-Name>-v<number> and delete all but the last n releases",
+					},
+				},
+			},
+			{
+				Name:     "puppeteer-rollback",
+				HelpText: "Put the venerable application back in place of a bad release that was pushed with --venerable-action stop or none",
+				UsageDetails: plugin.Usage{
+					Usage: "$ cf puppeteer-rollback <App-Name> [-f <Manifest.yml>] [options]",
+					Options: map[string]string{
+						"f":              "path to application manifest, the routes of the manifest are moved - default are the routes of the current application",
+						"-vars-file":     "path to a variable substitution file for manifest; can specify multiple times",
+						"-var":           "variable key value pair for variable substitution, (e.g., name=app1); can specify multiple times",
+						"-ops-file":      "path to an ops file with replace and remove operations for the manifest; can specify multiple times",
+						"-failed-action": "option to delete or stop the failed application, a stopped one is kept as venerable application - default is delete",
+						"-legacy-push":   "map and unmap the routes with the v2 api",
+						"-release":       "number of the release kept by --keep-releases to roll back to - default is the venerable application",
+						"-droplet":       "roll the application back to the droplet kept by --keep-droplet with a rolling deployment",
+					},
+				},
+			},
+			{
+				Name:     "puppeteer-validate",
+				HelpText: "Check an application manifest and print all problems with their file and line without pushing anything",
+				UsageDetails: plugin.Usage{
+					Usage: "$ cf puppeteer-validate -f <Manifest.yml> [options]",
+					Options: map[string]string{
+						"f":            "path to application manifest",
+						"-vars-file":   "path to a variable substitution file for manifest; can specify multiple times",
+						"-var":         "variable key value pair for variable substitution, (e.g., name=app1); can specify multiple times",
+						"-ops-file":    "path to an ops file with replace and remove operations for the manifest; can specify multiple times",
+						"-legacy-push": "allow wildcards in the path of the applications like the legacy push does",
 					},
 				},
 			},
@@ -255,6 +737,7 @@ type ApplicationRepo struct {
 	conn         plugin.CliConnection
 	traceLogging bool
 	v2Resources  v2.Resources
+	v3Client     *ccv3.Client
 }
 
 func NewApplicationRepo(conn plugin.CliConnection, traceLogging bool) *ApplicationRepo {
@@ -262,5 +745,17 @@ func NewApplicationRepo(conn plugin.CliConnection, traceLogging bool) *Applicati
 		conn:         conn,
 		traceLogging: traceLogging,
 		v2Resources:  v2.NewV2Resources(conn, traceLogging),
+		v3Client:     ccv3.NewClientFromConnection(conn, traceLogging),
+	}
+}
+
+//withOutputPrefix returns a repo for one application of a multi application deployment that prints the output of
+//the cf cli commands with the prefix of the application
+func (repo *ApplicationRepo) withOutputPrefix(outputPrefix string) *ApplicationRepo {
+	if len(outputPrefix) == 0 {
+		return repo
 	}
+	prefixedRepo := *repo
+	prefixedRepo.v2Resources = v2.NewPrefixedV2Resources(repo.conn, repo.traceLogging, outputPrefix)
+	return &prefixedRepo
 }
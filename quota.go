@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/happytobi/cf-puppeteer/arguments"
+	"github.com/happytobi/cf-puppeteer/cf/ccv3"
+	"github.com/happytobi/cf-puppeteer/ui"
+)
+
+//defaultMemoryInMB memory the cloud controller uses by default for an application without memory in the manifest
+const defaultMemoryInMB = 1024
+
+//quotaNeeds memory and instances a deployment needs on top of the current usage
+type quotaNeeds struct {
+	MemoryInMB int
+	Instances  int
+}
+
+func (needs *quotaNeeds) add(other quotaNeeds) {
+	needs.MemoryInMB += other.MemoryInMB
+	needs.Instances += other.Instances
+}
+
+//webProcess returns the web process of the application or nil if the application does not exist
+func webProcess(ctx context.Context, appRepo *ApplicationRepo, appName string, spaceGUID string) (*ccv3.Process, *ccv3.App, error) {
+	app, err := appRepo.v3Client.GetApp(ctx, appName, spaceGUID)
+	if err == ccv3.ErrAppNotFound {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	process, err := appRepo.v3Client.GetAppProcess(ctx, app.GUID, "web")
+	if err != nil {
+		return nil, nil, err
+	}
+	return process, app, nil
+}
+
+//applicationQuotaNeeds returns what the new application needs while the venerable application is still running,
+//values that are not in the manifest are taken from the current application
+func applicationQuotaNeeds(ctx context.Context, appRepo *ApplicationRepo, spaceGUID string, parsedArguments *arguments.ParserArguments) (quotaNeeds, error) {
+	if parsedArguments.AddRoutes || parsedArguments.NoStart {
+		return quotaNeeds{}, nil
+	}
+
+	memory, err := parsedArguments.ApplicationManifest.MemoryInMB()
+	if err != nil {
+		return quotaNeeds{}, err
+	}
+	instances, err := parsedArguments.ApplicationManifest.InstanceCount()
+	if err != nil {
+		return quotaNeeds{}, err
+	}
+	curProcess, _, err := webProcess(ctx, appRepo, parsedArguments.AppName, spaceGUID)
+	if err != nil {
+		return quotaNeeds{}, err
+	}
+	if memory == 0 {
+		memory = defaultMemoryInMB
+		if curProcess != nil {
+			memory = curProcess.MemoryInMB
+		}
+	}
+	if len(parsedArguments.ApplicationManifest.Instances) == 0 {
+		instances = 1
+		if curProcess != nil {
+			instances = curProcess.Instances
+		}
+	}
+
+	//a rolling deployment replaces one instance after another
+	if parsedArguments.Strategy == arguments.StrategyRolling && curProcess != nil {
+		return quotaNeeds{MemoryInMB: memory, Instances: 1}, nil
+	}
+	needs := quotaNeeds{MemoryInMB: memory * instances, Instances: instances}
+
+	//a venerable application of an earlier deployment is deleted before the new application is pushed
+	if parsedArguments.Strategy != arguments.StrategyBlueGreen {
+		venProcess, venApp, err := webProcess(ctx, appRepo, venerableAppName(parsedArguments.AppName), spaceGUID)
+		if err != nil {
+			return quotaNeeds{}, err
+		}
+		if venApp != nil && venApp.State == "STARTED" {
+			needs.MemoryInMB -= venProcess.MemoryInMB * venProcess.Instances
+			needs.Instances -= venProcess.Instances
+		}
+	}
+	return needs, nil
+}
+
+//exceedsQuota returns an error if the usage and the needs of the deployment are above the limits of the quota
+func exceedsQuota(scope string, quota *ccv3.Quota, usage *ccv3.UsageSummary, needs quotaNeeds) error {
+	if quota == nil || usage == nil {
+		return nil
+	}
+	if limit := quota.Apps.TotalMemoryInMB; limit != nil && usage.MemoryInMB+needs.MemoryInMB > *limit {
+		return fmt.Errorf("the deployment needs %d MB memory but the %s quota %s has only %d MB of %d MB left, the venerable and the new application have to fit into it",
+			needs.MemoryInMB, scope, quota.Name, *limit-usage.MemoryInMB, *limit)
+	}
+	if limit := quota.Apps.TotalInstances; limit != nil && usage.StartedInstances+needs.Instances > *limit {
+		return fmt.Errorf("the deployment needs %d instances but the %s quota %s has only %d of %d instances left, the venerable and the new application have to fit into it",
+			needs.Instances, scope, quota.Name, *limit-usage.StartedInstances, *limit)
+	}
+	return nil
+}
+
+//checkQuota fails if the applications of the manifest don't fit into the space and organization quota next to their
+//venerable applications, quotas and applications that can't be read are skipped with a warning
+func checkQuota(ctx context.Context, appRepo *ApplicationRepo, parsedArguments *arguments.ParserArguments) error {
+	space, err := appRepo.conn.GetCurrentSpace()
+	if err != nil {
+		return err
+	}
+	org, err := appRepo.conn.GetCurrentOrg()
+	if err != nil {
+		return err
+	}
+
+	var needs quotaNeeds
+	for _, applicationArguments := range parsedArguments.Applications {
+		//an invalid memory or instance count is an error of the manifest, the push would fail with it too
+		_, err := applicationArguments.ApplicationManifest.MemoryInMB()
+		if err != nil {
+			return err
+		}
+		_, err = applicationArguments.ApplicationManifest.InstanceCount()
+		if err != nil {
+			return err
+		}
+
+		applicationNeeds, err := applicationQuotaNeeds(ctx, appRepo, space.Guid, applicationArguments)
+		if err != nil {
+			ui.Warn("could not check the quota for application %s, it is skipped - error: %s", applicationArguments.AppName, err)
+			continue
+		}
+		needs.add(applicationNeeds)
+	}
+	if needs.MemoryInMB <= 0 && needs.Instances <= 0 {
+		return nil
+	}
+	ui.Say("check quota of space %s and organization %s for %d MB memory and %d instances", space.Name, org.Name, needs.MemoryInMB, needs.Instances)
+
+	scopes := []struct {
+		name     string
+		quota    func(ctx context.Context, guid string) (*ccv3.Quota, error)
+		usage    func(ctx context.Context, guid string) (*ccv3.UsageSummary, error)
+		guid     string
+		resource string
+	}{
+		{"space", appRepo.v3Client.GetSpaceQuota, appRepo.v3Client.GetSpaceUsage, space.Guid, space.Name},
+		{"organization", appRepo.v3Client.GetOrganizationQuota, appRepo.v3Client.GetOrganizationUsage, org.Guid, org.Name},
+	}
+	for _, scope := range scopes {
+		quota, err := scope.quota(ctx, scope.guid)
+		if err != nil {
+			ui.Warn("could not read the quota of %s %s - error: %s", scope.name, scope.resource, err)
+			continue
+		}
+		if quota == nil {
+			continue
+		}
+		usage, err := scope.usage(ctx, scope.guid)
+		if err != nil {
+			ui.Warn("could not read the usage of %s %s - error: %s", scope.name, scope.resource, err)
+			continue
+		}
+		err = exceedsQuota(scope.name, quota, usage, needs)
+		if err != nil {
+			return err
+		}
+	}
+	ui.Ok()
+	return nil
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"code.cloudfoundry.org/cli/plugin/models"
+	"code.cloudfoundry.org/cli/plugin/pluginfakes"
+	"github.com/happytobi/cf-puppeteer/arguments"
+	"github.com/happytobi/cf-puppeteer/cf/ccv3"
+	"github.com/happytobi/cf-puppeteer/cf/cli"
+	"github.com/happytobi/cf-puppeteer/manifest"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+//newQuota quota with the limits, a negative limit is unlimited
+func newQuota(memoryInMB int, instances int) *ccv3.Quota {
+	quota := &ccv3.Quota{Name: "small"}
+	if memoryInMB >= 0 {
+		quota.Apps.TotalMemoryInMB = &memoryInMB
+	}
+	if instances >= 0 {
+		quota.Apps.TotalInstances = &instances
+	}
+	return quota
+}
+
+var _ = Describe("quota", func() {
+	table.DescribeTable("deployment that exceeds the quota",
+		func(quota *ccv3.Quota, usage *ccv3.UsageSummary, needs quotaNeeds, expectedError string) {
+			err := exceedsQuota("space", quota, usage, needs)
+			if expectedError == "" {
+				Expect(err).ToNot(HaveOccurred())
+				return
+			}
+			Expect(err).To(MatchError(ContainSubstring(expectedError)))
+		},
+		table.Entry("fits into the quota", newQuota(1024, 10), &ccv3.UsageSummary{MemoryInMB: 512, StartedInstances: 4}, quotaNeeds{MemoryInMB: 512, Instances: 6}, ""),
+		table.Entry("needs too much memory", newQuota(1024, 10), &ccv3.UsageSummary{MemoryInMB: 768}, quotaNeeds{MemoryInMB: 512, Instances: 1}, "needs 512 MB memory but the space quota small has only 256 MB of 1024 MB left"),
+		table.Entry("needs too many instances", newQuota(1024, 10), &ccv3.UsageSummary{StartedInstances: 8}, quotaNeeds{Instances: 3}, "needs 3 instances but the space quota small has only 2 of 10 instances left"),
+		table.Entry("has no memory and instance limits", newQuota(-1, -1), &ccv3.UsageSummary{MemoryInMB: 4096, StartedInstances: 40}, quotaNeeds{MemoryInMB: 4096, Instances: 40}, ""),
+		table.Entry("has no quota", nil, &ccv3.UsageSummary{}, quotaNeeds{MemoryInMB: 4096}, ""),
+		table.Entry("has no usage", newQuota(1024, 10), nil, quotaNeeds{MemoryInMB: 4096}, ""),
+	)
+
+	Describe("with the cloud controller", func() {
+		var (
+			server  *httptest.Server
+			cliConn *pluginfakes.FakeCliConnection
+			appRepo *ApplicationRepo
+			apps    map[string]string
+		)
+
+		BeforeEach(func() {
+			apps = map[string]string{}
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.URL.Path == "/v3/apps":
+					app, found := apps[r.URL.Query().Get("names")]
+					if !found {
+						_, _ = w.Write([]byte(`{"resources": []}`))
+						return
+					}
+					_, _ = w.Write([]byte(`{"resources": [` + app + `]}`))
+				case strings.HasSuffix(r.URL.Path, "/processes/web"):
+					process, found := apps[strings.Split(r.URL.Path, "/")[3]]
+					if !found {
+						w.WriteHeader(http.StatusForbidden)
+						return
+					}
+					_, _ = w.Write([]byte(process))
+				case r.URL.Path == "/v3/space_quotas":
+					_, _ = w.Write([]byte(`{"resources": [{"guid": "quota-guid", "name": "small", "apps": {"total_memory_in_mb": 1024}}]}`))
+				case r.URL.Path == "/v3/organization_quotas":
+					_, _ = w.Write([]byte(`{"resources": []}`))
+				case strings.HasSuffix(r.URL.Path, "/usage_summary"):
+					_, _ = w.Write([]byte(`{"usage_summary": {"started_instances": 0, "memory_in_mb": 0}}`))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			cliConn = &pluginfakes.FakeCliConnection{}
+			cliConn.ApiEndpointReturns(server.URL, nil)
+			cliConn.AccessTokenReturns("bearer token", nil)
+			cliConn.GetCurrentSpaceReturns(plugin_models.Space{SpaceFields: plugin_models.SpaceFields{Guid: "space-guid", Name: "space"}}, nil)
+			cliConn.GetCurrentOrgReturns(plugin_models.Organization{OrganizationFields: plugin_models.OrganizationFields{Guid: "org-guid", Name: "org"}}, nil)
+			appRepo = &ApplicationRepo{conn: cliConn, v3Client: ccv3.NewClient(cli.NewHttpClient(cliConn, false, 30, false))}
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		//addApp adds an application with its web process to the fake cloud controller
+		addApp := func(name string, guid string, state string, memoryInMB string, instances string) {
+			apps[name] = `{"guid": "` + guid + `", "name": "` + name + `", "state": "` + state + `"}`
+			apps[guid] = `{"guid": "` + guid + `-web", "type": "web", "memory_in_mb": ` + memoryInMB + `, "instances": ` + instances + `}`
+		}
+
+		table.DescribeTable("quota the new application needs",
+			func(strategy string, application manifest.Application, existingApps func(), expectedNeeds quotaNeeds) {
+				existingApps()
+				parsedArguments := &arguments.ParserArguments{AppName: "my-app", Strategy: strategy, ApplicationManifest: application}
+
+				needs, err := applicationQuotaNeeds(context.Background(), appRepo, "space-guid", parsedArguments)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(needs).To(Equal(expectedNeeds))
+			},
+			table.Entry("uses the manifest",
+				arguments.StrategyVenerable, manifest.Application{Memory: "512M", Instances: "2"}, func() {},
+				quotaNeeds{MemoryInMB: 1024, Instances: 2}),
+			table.Entry("uses the default memory and one instance without current application",
+				arguments.StrategyVenerable, manifest.Application{}, func() {},
+				quotaNeeds{MemoryInMB: defaultMemoryInMB, Instances: 1}),
+			table.Entry("takes missing values from the current application",
+				arguments.StrategyVenerable, manifest.Application{}, func() {
+					addApp("my-app", "cur-guid", "STARTED", "256", "3")
+				},
+				quotaNeeds{MemoryInMB: 768, Instances: 3}),
+			table.Entry("frees the started venerable application that will be deleted",
+				arguments.StrategyVenerable, manifest.Application{Memory: "512M", Instances: "2"}, func() {
+					addApp("my-app-venerable", "ven-guid", "STARTED", "256", "1")
+				},
+				quotaNeeds{MemoryInMB: 768, Instances: 1}),
+			table.Entry("ignores a stopped venerable application",
+				arguments.StrategyVenerable, manifest.Application{Memory: "512M", Instances: "2"}, func() {
+					addApp("my-app-venerable", "ven-guid", "STOPPED", "256", "1")
+				},
+				quotaNeeds{MemoryInMB: 1024, Instances: 2}),
+			table.Entry("keeps the venerable application of a blue-green deployment",
+				arguments.StrategyBlueGreen, manifest.Application{Memory: "512M", Instances: "2"}, func() {
+					addApp("my-app-venerable", "ven-guid", "STARTED", "256", "1")
+				},
+				quotaNeeds{MemoryInMB: 1024, Instances: 2}),
+			table.Entry("needs one instance for a rolling deployment",
+				arguments.StrategyRolling, manifest.Application{Memory: "512M", Instances: "4"}, func() {
+					addApp("my-app", "cur-guid", "STARTED", "256", "4")
+				},
+				quotaNeeds{MemoryInMB: 512, Instances: 1}),
+		)
+
+		It("needs nothing if the application is not started", func() {
+			parsedArguments := &arguments.ParserArguments{AppName: "my-app", NoStart: true, ApplicationManifest: manifest.Application{Memory: "512M"}}
+
+			Expect(applicationQuotaNeeds(context.Background(), appRepo, "space-guid", parsedArguments)).To(Equal(quotaNeeds{}))
+		})
+
+		It("fails on an invalid memory in the manifest", func() {
+			parsedArguments := &arguments.ParserArguments{AppName: "my-app", ApplicationManifest: manifest.Application{Memory: "lots"}}
+
+			_, err := applicationQuotaNeeds(context.Background(), appRepo, "space-guid", parsedArguments)
+			Expect(err).To(MatchError(manifest.ErrInvalidMemory))
+		})
+
+		It("skips only the application that can't be read in the quota check", func() {
+			apps["other-app"] = `{"guid": "other-guid", "name": "other-app", "state": "STARTED"}`
+			parsedArguments := &arguments.ParserArguments{Applications: []*arguments.ParserArguments{
+				{AppName: "other-app", ApplicationManifest: manifest.Application{Memory: "512M"}},
+				{AppName: "my-app", ApplicationManifest: manifest.Application{Memory: "2G"}},
+			}}
+
+			err := checkQuota(context.Background(), appRepo, parsedArguments)
+			Expect(err).To(MatchError(ContainSubstring("the deployment needs 2048 MB memory but the space quota small")))
+		})
+
+		It("fails the quota check on an invalid manifest", func() {
+			parsedArguments := &arguments.ParserArguments{Applications: []*arguments.ParserArguments{
+				{AppName: "my-app", ApplicationManifest: manifest.Application{Memory: "lots"}},
+			}}
+
+			Expect(checkQuota(context.Background(), appRepo, parsedArguments)).To(MatchError(manifest.ErrInvalidMemory))
+		})
+	})
+})
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/happytobi/cf-puppeteer/cf/ccv3"
+	"github.com/happytobi/cf-puppeteer/rewind"
+
+	v2 "github.com/happytobi/cf-puppeteer/cf/v2"
+)
+
+//releaseLabel label with the release number that is set when the venerable application is kept as release, only
+//applications with the label are releases, another application named like a release is never deleted
+const releaseLabel = journalLabelPrefix + "release"
+
+//release previous version of an application that is kept as stopped application <app>-v<number>
+type release struct {
+	Name   string
+	Number int
+}
+
+func releaseAppName(appName string, number int) string {
+	return fmt.Sprintf("%s-v%d", appName, number)
+}
+
+//listReleases returns the kept releases of the application, the newest release first
+func listReleases(ctx context.Context, appRepo *ApplicationRepo, appName string) ([]release, error) {
+	space, err := appRepo.conn.GetCurrentSpace()
+	if err != nil {
+		return nil, err
+	}
+	apps, err := appRepo.v3Client.ListApps(ctx, space.Guid)
+	if err != nil {
+		return nil, err
+	}
+	return findReleases(apps, appName), nil
+}
+
+//findReleases returns the applications named <app>-v<number> with the release label of the same number, the newest
+//release first
+func findReleases(apps []ccv3.App, appName string) []release {
+	releaseName := regexp.MustCompile("^" + regexp.QuoteMeta(appName) + `-v(\d+)$`)
+	var releases []release
+	for _, app := range apps {
+		match := releaseName.FindStringSubmatch(app.Name)
+		if match == nil || app.Metadata.Labels[releaseLabel] != match[1] {
+			continue
+		}
+		number, err := strconv.Atoi(match[1])
+		if err != nil {
+			continue
+		}
+		releases = append(releases, release{Name: app.Name, Number: number})
+	}
+	sort.Slice(releases, func(i, j int) bool {
+		return releases[i].Number > releases[j].Number
+	})
+	return releases
+}
+
+//nextReleaseNumber returns the number of the next release, releases are sorted with the newest release first
+func nextReleaseNumber(releases []release) int {
+	if len(releases) == 0 {
+		return 1
+	}
+	return releases[0].Number + 1
+}
+
+//prunedReleases returns the releases that are older than the kept ones
+func prunedReleases(releases []release, keepReleases int) []release {
+	if keepReleases >= len(releases) {
+		return nil
+	}
+	return releases[keepReleases:]
+}
+
+//getReleaseActionsForApp keeps the venerable application as the newest release instead of the venerable action and
+//deletes the releases that are older than the kept ones
+func getReleaseActionsForApp(deployment *applicationDeployment) []rewind.Action {
+	appRepo := deployment.appRepo
+	appName := deployment.parsedArguments.AppName
+	venName := deployment.venName
+	keepReleases := deployment.parsedArguments.KeepReleases
+	output := deployment.output
+	retry := deployment.retryPolicy()
+	var err error
+
+	return []rewind.Action{
+		{
+			Name:    "get venerable application",
+			Retry:   retry,
+			Timeout: stepTimeout,
+			Forward: func(ctx context.Context) error {
+				deployment.state.VenApp, err = appRepo.v2Resources.GetAppMetadata(ctx, venName)
+				if err != nil && err != v2.ErrAppNotFound {
+					return err
+				}
+				return nil
+			},
+		},
+		// stop the venerable app, label it and rename it to the next release
+		{
+			Name:    "keep release",
+			Retry:   retry,
+			Timeout: stepTimeout,
+			Forward: func(ctx context.Context) error {
+				if deployment.state.VenApp == nil {
+					return nil
+				}
+				releases, err := listReleases(ctx, appRepo, appName)
+				if err != nil {
+					return err
+				}
+				number := nextReleaseNumber(releases)
+
+				err = appRepo.v2Resources.StopApplication(ctx, venName)
+				if err != nil {
+					return err
+				}
+				metadata := ccv3.Metadata{Labels: map[string]string{releaseLabel: strconv.Itoa(number)}}
+				err = appRepo.v3Client.UpdateAppMetadata(ctx, deployment.state.VenApp.Metadata.GUID, metadata)
+				if err != nil {
+					return err
+				}
+				output.Say("keep application %s as release %s", venName, releaseAppName(appName, number))
+				return appRepo.v2Resources.RenameApplication(ctx, venName, releaseAppName(appName, number))
+			},
+		},
+		// delete the releases that are not kept anymore
+		{
+			Name:    "prune releases",
+			Retry:   retry,
+			Timeout: stepTimeout,
+			Forward: func(ctx context.Context) error {
+				releases, err := listReleases(ctx, appRepo, appName)
+				if err != nil {
+					return err
+				}
+				for _, prunedRelease := range prunedReleases(releases, keepReleases) {
+					output.Say("delete release %s", prunedRelease.Name)
+					err := appRepo.v2Resources.DeleteApplication(ctx, prunedRelease.Name)
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		},
+	}
+}
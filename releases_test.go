@@ -0,0 +1,65 @@
+package main
+
+import (
+	"github.com/happytobi/cf-puppeteer/cf/ccv3"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+//labeledApp application with the release label
+func labeledApp(name string, number string) ccv3.App {
+	return ccv3.App{Name: name, Metadata: ccv3.Metadata{Labels: map[string]string{releaseLabel: number}}}
+}
+
+var _ = Describe("releases", func() {
+	It("finds the labeled releases of the application with the newest release first", func() {
+		apps := []ccv3.App{
+			labeledApp("my-app-v1", "1"),
+			labeledApp("my-app-v10", "10"),
+			labeledApp("my-app-v2", "2"),
+			{Name: "my-app-v3"},
+			labeledApp("my-app-v4", "5"),
+			labeledApp("my-app-venerable", "1"),
+			labeledApp("other-my-app-v6", "6"),
+			labeledApp("my-app-api-v7", "7"),
+			{Name: "my-app"},
+		}
+
+		Expect(findReleases(apps, "my-app")).To(Equal([]release{
+			{Name: "my-app-v10", Number: 10},
+			{Name: "my-app-v2", Number: 2},
+			{Name: "my-app-v1", Number: 1},
+		}))
+	})
+
+	It("quotes the application name in the release pattern", func() {
+		apps := []ccv3.App{labeledApp("myXapp-v1", "1"), labeledApp("my.app-v2", "2")}
+
+		Expect(findReleases(apps, "my.app")).To(Equal([]release{{Name: "my.app-v2", Number: 2}}))
+	})
+
+	table.DescribeTable("number of the next release",
+		func(releases []release, expectedNumber int) {
+			Expect(nextReleaseNumber(releases)).To(Equal(expectedNumber))
+		},
+		table.Entry("first release", nil, 1),
+		table.Entry("after the newest release", []release{{Name: "my-app-v7", Number: 7}, {Name: "my-app-v3", Number: 3}}, 8),
+	)
+
+	table.DescribeTable("releases older than the kept ones",
+		func(keepReleases int, expectedNames []string) {
+			releases := []release{{Name: "my-app-v3", Number: 3}, {Name: "my-app-v2", Number: 2}, {Name: "my-app-v1", Number: 1}}
+
+			var names []string
+			for _, pruned := range prunedReleases(releases, keepReleases) {
+				names = append(names, pruned.Name)
+			}
+			Expect(names).To(Equal(expectedNames))
+		},
+		table.Entry("keeps the newest ones", 1, []string{"my-app-v2", "my-app-v1"}),
+		table.Entry("keeps all releases", 3, nil),
+		table.Entry("keeps more releases than exist", 5, nil),
+	)
+})
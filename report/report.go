@@ -0,0 +1,163 @@
+package report
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/happytobi/cf-puppeteer/rewind"
+)
+
+const (
+	//OutcomeSucceeded step or application was deployed successful
+	OutcomeSucceeded = "succeeded"
+	//OutcomeFailed step or application failed
+	OutcomeFailed = "failed"
+	//OutcomeRolledBack application failed or was rolled back because another application failed
+	OutcomeRolledBack = "rolled-back"
+	//Stdout report path that writes the report to stdout
+	Stdout = "-"
+)
+
+//Report machine readable result of a deployment
+type Report struct {
+	Success         bool           `json:"success"`
+	Error           string         `json:"error,omitempty"`
+	StartedAt       time.Time      `json:"started_at"`
+	DurationSeconds float64        `json:"duration_seconds"`
+	Applications    []*Application `json:"applications"`
+
+	mutex sync.Mutex
+}
+
+//Application result of the deployment of one application
+type Application struct {
+	Name          string   `json:"name"`
+	Strategy      string   `json:"strategy"`
+	Outcome       string   `json:"outcome"`
+	GUID          string   `json:"guid,omitempty"`
+	VenerableGUID string   `json:"venerable_guid,omitempty"`
+	DropletGUID   string   `json:"droplet_guid,omitempty"`
+	Routes        []string `json:"routes"`
+	Steps         []Step   `json:"steps"`
+	RollbackSteps []Step   `json:"rollback_steps"`
+
+	report *Report
+}
+
+//Step executed step of the deployment
+type Step struct {
+	Name            string  `json:"name"`
+	Outcome         string  `json:"outcome"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	Attempts        int     `json:"attempts,omitempty"`
+	Error           string  `json:"error,omitempty"`
+}
+
+//New constructor, the start time of the deployment is now
+func New() *Report {
+	return &Report{
+		StartedAt:    time.Now(),
+		Applications: []*Application{},
+	}
+}
+
+//Application adds the application to the report
+func (report *Report) Application(name string, strategy string) *Application {
+	report.mutex.Lock()
+	defer report.mutex.Unlock()
+
+	application := &Application{
+		Name:          name,
+		Strategy:      strategy,
+		Outcome:       OutcomeSucceeded,
+		Routes:        []string{},
+		Steps:         []Step{},
+		RollbackSteps: []Step{},
+		report:        report,
+	}
+	report.Applications = append(report.Applications, application)
+	return application
+}
+
+//Finish sets the result and the duration of the deployment
+func (report *Report) Finish(err error) {
+	report.mutex.Lock()
+	defer report.mutex.Unlock()
+
+	report.Success = err == nil
+	if err != nil {
+		report.Error = err.Error()
+	}
+	report.DurationSeconds = time.Since(report.StartedAt).Seconds()
+}
+
+//Write writes the report as json to the file or to stdout if the path is Stdout
+func (report *Report) Write(path string) error {
+	report.mutex.Lock()
+	defer report.mutex.Unlock()
+
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	content = append(content, '\n')
+
+	if path == Stdout {
+		_, err = os.Stdout.Write(content)
+		return err
+	}
+	return ioutil.WriteFile(path, content, 0644)
+}
+
+//AddStep adds the result of an executed rewind action, reverse actions are rollback steps
+func (application *Application) AddStep(result rewind.StepResult) {
+	application.report.mutex.Lock()
+	defer application.report.mutex.Unlock()
+
+	step := Step{
+		Name:            result.Name,
+		Outcome:         OutcomeSucceeded,
+		DurationSeconds: result.Duration.Seconds(),
+	}
+	//attempts are only of interest if the step was retried
+	if result.Attempts > 1 {
+		step.Attempts = result.Attempts
+	}
+	if result.Err != nil {
+		step.Outcome = OutcomeFailed
+		step.Error = result.Err.Error()
+	}
+
+	if result.Reverse {
+		application.RollbackSteps = append(application.RollbackSteps, step)
+		application.Outcome = OutcomeRolledBack
+		return
+	}
+	application.Steps = append(application.Steps, step)
+	if result.Err != nil {
+		application.Outcome = OutcomeFailed
+	}
+}
+
+//SetOutcome overrides the outcome of the application
+func (application *Application) SetOutcome(outcome string) {
+	application.report.mutex.Lock()
+	defer application.report.mutex.Unlock()
+	application.Outcome = outcome
+}
+
+//SetState sets guids and routes of the deployed application
+func (application *Application) SetState(guid string, venerableGUID string, dropletGUID string, routes []string) {
+	application.report.mutex.Lock()
+	defer application.report.mutex.Unlock()
+
+	application.GUID = guid
+	application.VenerableGUID = venerableGUID
+	application.DropletGUID = dropletGUID
+	if routes != nil {
+		application.Routes = routes
+	}
+}
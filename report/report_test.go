@@ -0,0 +1,60 @@
+package report_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/happytobi/cf-puppeteer/report"
+	"github.com/happytobi/cf-puppeteer/rewind"
+)
+
+var _ = Describe("Report", func() {
+	It("collects steps, rollback steps and the application state", func() {
+		deploymentReport := report.New()
+		application := deploymentReport.Application("my-app", "venerable")
+
+		application.AddStep(rewind.StepResult{Name: "push application", Duration: 2 * time.Second})
+		application.AddStep(rewind.StepResult{Name: "start application", Err: errors.New("crashed")})
+		application.AddStep(rewind.StepResult{Name: "start application", Reverse: true})
+		application.SetState("app-guid", "", "droplet-guid", []string{"my-app.example.com"})
+		deploymentReport.Finish(errors.New("crashed"))
+
+		Expect(deploymentReport.Success).To(BeFalse())
+		Expect(deploymentReport.Error).To(Equal("crashed"))
+		Expect(application.Outcome).To(Equal(report.OutcomeRolledBack))
+		Expect(application.Steps).To(Equal([]report.Step{
+			{Name: "push application", Outcome: report.OutcomeSucceeded, DurationSeconds: 2},
+			{Name: "start application", Outcome: report.OutcomeFailed, Error: "crashed"},
+		}))
+		Expect(application.RollbackSteps).To(Equal([]report.Step{{Name: "start application", Outcome: report.OutcomeSucceeded}}))
+		Expect(application.DropletGUID).To(Equal("droplet-guid"))
+	})
+
+	It("writes the report as json file", func() {
+		dir, err := ioutil.TempDir("", "puppeteer-report")
+		Expect(err).ToNot(HaveOccurred())
+		defer os.RemoveAll(dir)
+
+		deploymentReport := report.New()
+		deploymentReport.Application("my-app", "canary")
+		deploymentReport.Finish(nil)
+
+		path := filepath.Join(dir, "report.json")
+		Expect(deploymentReport.Write(path)).To(Succeed())
+
+		content, err := ioutil.ReadFile(path)
+		Expect(err).ToNot(HaveOccurred())
+		var written map[string]interface{}
+		Expect(json.Unmarshal(content, &written)).To(Succeed())
+		Expect(written["success"]).To(BeTrue())
+		Expect(written["applications"]).To(HaveLen(1))
+		Expect(written["applications"].([]interface{})[0]).To(HaveKeyWithValue("outcome", "succeeded"))
+	})
+})
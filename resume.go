@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/happytobi/cf-puppeteer/arguments"
+	"github.com/happytobi/cf-puppeteer/cf/ccv3"
+	"github.com/happytobi/cf-puppeteer/journal"
+	"github.com/happytobi/cf-puppeteer/report"
+	"github.com/happytobi/cf-puppeteer/rewind"
+	"github.com/happytobi/cf-puppeteer/ui"
+)
+
+//journalLabelPrefix prefix of the labels that mirror the journal on the applications
+const journalLabelPrefix = "cf-puppeteer/"
+
+var invalidLabelCharacters = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+//useJournal restores the state of an interrupted deployment out of the journal, every completed step will be written to it
+func (deployment *applicationDeployment) useJournal(deploymentJournal *journal.Journal) error {
+	if deploymentJournal == nil {
+		return nil
+	}
+	deployment.journal = deploymentJournal
+	deployment.journalEntry, deployment.resumed = deploymentJournal.Application(deployment.parsedArguments.AppName)
+	if !deployment.resumed {
+		return nil
+	}
+	return deployment.journalEntry.GetState(&deployment.state)
+}
+
+//execute runs the steps of the deployment, an interrupted deployment continues after its completed steps
+func (deployment *applicationDeployment) execute(ctx context.Context) error {
+	if !deployment.resumed {
+		return deployment.actions.Execute(ctx)
+	}
+	if deployment.journalEntry.Completed < len(deployment.actions.Actions) {
+		deployment.output.Say("resume deployment of application %s after step %q", deployment.parsedArguments.AppName, deployment.journalEntry.Step)
+	}
+	return deployment.actions.Resume(ctx, deployment.journalEntry.Completed)
+}
+
+//checkpoint writes the completed steps and the state of the deployment to the journal
+func (deployment *applicationDeployment) checkpoint(completed int) {
+	if deployment.journal == nil {
+		return
+	}
+	actions := deployment.actions.Actions
+	step := ""
+	if completed > 0 {
+		step = actions[completed-1].Name
+	}
+	deployed := completed == len(actions)
+
+	err := deployment.journal.Checkpoint(deployment.journalEntry, step, completed, deployed, deployment.state)
+	if err != nil {
+		deployment.output.Warn("could not write deployment journal %s - error: %s", deployment.journal.Path(), err)
+	}
+	//the labels are written during the rollback of a canceled deployment too
+	if deployment.parsedArguments.JournalLabels {
+		ctx, cancel := context.WithTimeout(context.Background(), stepTimeout)
+		defer cancel()
+		deployment.labelApplications(ctx, step, deployed)
+	}
+}
+
+//finalize marks the deployment in the journal before the venerable action stops or deletes the venerable application
+func (deployment *applicationDeployment) finalize() {
+	if deployment.journal == nil {
+		return
+	}
+	err := deployment.journal.Finalize(deployment.journalEntry)
+	if err != nil {
+		deployment.output.Warn("could not write deployment journal %s - error: %s", deployment.journal.Path(), err)
+	}
+}
+
+//labelApplications mirrors the progress of the deployment as labels on the application and the venerable application
+func (deployment *applicationDeployment) labelApplications(ctx context.Context, step string, deployed bool) {
+	appRepo := deployment.appRepo
+	state := "in-progress"
+	if deployed {
+		state = "deployed"
+	}
+	metadata := ccv3.Metadata{
+		Labels: map[string]string{
+			journalLabelPrefix + "deployment": state,
+			journalLabelPrefix + "step":       labelValue(step),
+		},
+	}
+
+	space, err := appRepo.conn.GetCurrentSpace()
+	if err != nil {
+		deployment.output.Warn("could not label applications - error: %s", err)
+		return
+	}
+	for _, appName := range []string{deployment.parsedArguments.AppName, deployment.venName} {
+		app, err := appRepo.v3Client.GetApp(ctx, appName, space.Guid)
+		if err != nil {
+			continue
+		}
+		err = appRepo.v3Client.UpdateAppMetadata(ctx, app.GUID, metadata)
+		if err != nil {
+			deployment.output.Warn("could not label application %s - error: %s", appName, err)
+		}
+	}
+}
+
+//labelValue converts the step name into a valid label value
+func labelValue(value string) string {
+	value = invalidLabelCharacters.ReplaceAllString(strings.ToLower(value), "-")
+	if len(value) > 63 {
+		value = value[:63]
+	}
+	return strings.Trim(value, "-._")
+}
+
+//renamedBeforeInterruption checks if an interrupted deployment renamed the current application to the venerable one already
+func (deployment *applicationDeployment) renamedBeforeInterruption(ctx context.Context) bool {
+	if !deployment.resumed || deployment.state.CurApp == nil {
+		return false
+	}
+	venApp, err := deployment.appRepo.v2Resources.GetAppMetadata(ctx, deployment.venName)
+	return err == nil && venApp.Metadata.GUID == deployment.state.CurApp.Metadata.GUID
+}
+
+//renameBackInterrupted gives the current application its name back if an interrupted deployment renamed it already
+func (deployment *applicationDeployment) renameBackInterrupted(ctx context.Context) error {
+	if !deployment.renamedBeforeInterruption(ctx) {
+		return nil
+	}
+	return deployment.appRepo.v2Resources.RenameApplication(ctx, deployment.venName, deployment.parsedArguments.AppName)
+}
+
+//removeJournal deletes the journal after the deployment is finished or rolled back
+func removeJournal(deploymentJournal *journal.Journal) {
+	err := deploymentJournal.Remove()
+	if err != nil {
+		ui.Warn("could not remove deployment journal %s - error: %s", deploymentJournal.Path(), err)
+	}
+}
+
+//rollbackInterruptedDeployment reverses the completed steps of all applications of an interrupted deployment
+func rollbackInterruptedDeployment(appRepo *ApplicationRepo, journalPath string) error {
+	deploymentJournal, err := journal.Load(journalPath)
+	if err != nil {
+		return err
+	}
+	parsedArguments, err := arguments.ParseArgs(deploymentJournal.Args)
+	if err != nil {
+		return err
+	}
+
+	var errs []string
+	applications := parsedArguments.Applications
+	for i := len(applications) - 1; i >= 0; i-- {
+		deployment := newApplicationDeployment(appRepo, applications[i], "", report.New())
+		err := deployment.useJournal(deploymentJournal)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", deployment.parsedArguments.AppName, err))
+			continue
+		}
+		if !deployment.resumed {
+			continue
+		}
+		if deployment.journalEntry.Finalized {
+			ui.Warn("venerable action of application %s was already started, it can't be rolled back", deployment.parsedArguments.AppName)
+			continue
+		}
+
+		deployment.actions = &rewind.Actions{
+			Actions:    getActionsForApp(deployment),
+			Checkpoint: deployment.checkpoint,
+		}
+		deployment.output.FailedMessage(fmt.Sprintf("roll back application %s after step %q", deployment.parsedArguments.AppName, deployment.journalEntry.Step))
+		err = deployment.actions.RollbackFrom(deployment.journalEntry.Completed)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", deployment.parsedArguments.AppName, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	removeJournal(deploymentJournal)
+	return nil
+}
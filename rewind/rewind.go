@@ -1,38 +1,288 @@
 package rewind
 
-import "fmt"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
 
+//Actions executes actions in order, when one fails all completed actions will be reversed in reverse order
 type Actions struct {
 	Actions []Action
 
 	RewindFailureMessage string
+
+	//Reporter is called with the result of every executed forward and reverse action
+	Reporter func(result StepResult)
+
+	//Checkpoint is called with the number of completed actions whenever it changes, it allows to persist the progress
+	Checkpoint func(completed int)
+
+	//completed number of actions whose forward function succeeded
+	completed int
 }
 
-func (actions Actions) Execute() error {
-	for _, action := range actions.Actions {
-		err := action.Forward()
-		if err != nil {
-			if action.ReversePrevious == nil {
-				return err
-			}
+//Action step with a forward function and the functions that undo it.
+//ReversePrevious is called if the forward function of this action fails, Reverse is called if a later action fails
+//or the completed actions are rolled back
+type Action struct {
+	Name            string
+	Forward         func(ctx context.Context) error
+	ReversePrevious func(ctx context.Context) error
+	Reverse         func(ctx context.Context) error
+	//Cleanup prepares an interrupted action to run again when the execution is resumed
+	Cleanup func(ctx context.Context) error
+	//Retry retries the forward function before the action counts as failed
+	Retry *RetryPolicy
+	//Timeout of every call of the forward and reverse functions, the context passed to them is canceled afterwards
+	Timeout time.Duration
+}
+
+//RetryPolicy calls a function again with an exponential backoff as long as it fails with a retryable error
+type RetryPolicy struct {
+	//MaxAttempts number of calls including the first one
+	MaxAttempts int
+	//Backoff wait time before the first retry, it is doubled for every further retry up to MaxBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	//Retryable decides which errors will be retried, all errors are retried if it's nil
+	Retryable func(err error) bool
+	//OnRetry is called before waiting for the next attempt
+	OnRetry func(attempt int, err error, wait time.Duration)
+}
+
+//StepResult outcome of one executed action, reverse is set if it was the rollback of the action
+type StepResult struct {
+	Name     string
+	Reverse  bool
+	Duration time.Duration
+	Attempts int
+	Err      error
+}
+
+//RollbackError the original error of the failed action and the errors of the rollback
+type RollbackError struct {
+	Err            error
+	RollbackErrors []error
+	Message        string
+}
+
+func (rollbackError *RollbackError) Error() string {
+	var rollbackErrors []string
+	for _, err := range rollbackError.RollbackErrors {
+		rollbackErrors = append(rollbackErrors, err.Error())
+	}
+	if rollbackError.Message != "" {
+		return fmt.Sprintf("%s: %s - rollback errors: %s", rollbackError.Message, rollbackError.Err, strings.Join(rollbackErrors, "; "))
+	}
+	return fmt.Sprintf("%s - rollback errors: %s", rollbackError.Err, strings.Join(rollbackErrors, "; "))
+}
+
+//Execute runs all actions, the first failing action stops the execution and all completed actions will be reversed.
+//When the context is done no further action will be started. The reverse functions don't get the context because the
+//rollback has to run even if the deployment was canceled.
+//The error of the failing action is returned, a RollbackError if the rollback failed too
+func (actions *Actions) Execute(ctx context.Context) error {
+	actions.completed = 0
+	return actions.execute(ctx, 0)
+}
+
+//Resume continues an interrupted execution after the completed actions. The action that was running when the
+//execution was interrupted may be partially applied, its Cleanup function prepares it before it runs again.
+//The ReversePrevious function is not called because the action didn't fail
+func (actions *Actions) Resume(ctx context.Context, completed int) error {
+	actions.setCompleted(completed)
+	if actions.completed < len(actions.Actions) {
+		action := actions.Actions[actions.completed]
+		if action.Cleanup != nil {
+			//the action may not have been started at all, so the cleanup is allowed to fail
+			_ = actions.run(context.Background(), actions.completed, true, nil, action.Cleanup)
+		}
+	}
+	return actions.execute(ctx, actions.completed)
+}
+
+func (actions *Actions) execute(ctx context.Context, start int) error {
+	for i := start; i < len(actions.Actions); i++ {
+		if err := ctx.Err(); err != nil {
+			return actions.abort(err, nil)
+		}
 
-			reverseError := action.ReversePrevious()
+		action := actions.Actions[i]
+		err := actions.run(ctx, i, false, action.Retry, action.Forward)
+		if err == nil {
+			actions.setCompleted(i + 1)
+			continue
+		}
+
+		var rollbackErrors []error
+		if action.ReversePrevious != nil {
+			reverseError := actions.run(context.Background(), i, true, nil, action.ReversePrevious)
 			if reverseError != nil {
-				if actions.RewindFailureMessage != "" {
-					return fmt.Errorf("%s: %s", actions.RewindFailureMessage, reverseError)
-				} else {
-					return reverseError
-				}
+				rollbackErrors = append(rollbackErrors, reverseError)
 			}
-
-			return err
 		}
+		return actions.abort(err, rollbackErrors)
 	}
 
 	return nil
 }
 
-type Action struct {
-	Forward         func() error
-	ReversePrevious func() error
+//abort reverses all completed actions after the execution failed with the error
+func (actions *Actions) abort(err error, rollbackErrors []error) error {
+	rollbackErrors = append(rollbackErrors, actions.reverseCompleted(len(rollbackErrors) > 0)...)
+	if len(rollbackErrors) > 0 {
+		return &RollbackError{Err: err, RollbackErrors: rollbackErrors, Message: actions.RewindFailureMessage}
+	}
+	return err
+}
+
+//Rollback reverses all completed actions of the last execution in reverse order
+func (actions *Actions) Rollback() error {
+	return joinErrors(actions.reverseCompleted(false))
+}
+
+//RollbackFrom reverses an interrupted execution with the given number of completed actions. The action that was
+//running when the execution was interrupted is cleaned up with its ReversePrevious function first
+func (actions *Actions) RollbackFrom(completed int) error {
+	actions.setCompleted(completed)
+
+	var rollbackErrors []error
+	if actions.completed < len(actions.Actions) {
+		action := actions.Actions[actions.completed]
+		if action.ReversePrevious != nil {
+			err := actions.run(context.Background(), actions.completed, true, nil, action.ReversePrevious)
+			if err != nil {
+				rollbackErrors = append(rollbackErrors, err)
+			}
+		}
+	}
+	return joinErrors(append(rollbackErrors, actions.reverseCompleted(len(rollbackErrors) > 0)...))
+}
+
+//joinErrors returns nil, the only error or an error with the messages of all errors
+func joinErrors(rollbackErrors []error) error {
+	if len(rollbackErrors) == 0 {
+		return nil
+	}
+	if len(rollbackErrors) == 1 {
+		return rollbackErrors[0]
+	}
+
+	var messages []string
+	for _, err := range rollbackErrors {
+		messages = append(messages, err.Error())
+	}
+	return errors.New(strings.Join(messages, "; "))
+}
+
+//reverseCompleted calls all reverse functions of the completed actions, all of them are called even if one fails.
+//After a failed reverse function the checkpoint keeps the action completed, so a later rollback retries it
+func (actions *Actions) reverseCompleted(failed bool) []error {
+	keep := 0
+	if failed {
+		keep = actions.completed
+	}
+
+	var rollbackErrors []error
+	for i := actions.completed - 1; i >= 0; i-- {
+		reverse := actions.Actions[i].Reverse
+		if reverse == nil {
+			continue
+		}
+		err := actions.run(context.Background(), i, true, nil, reverse)
+		if err != nil {
+			rollbackErrors = append(rollbackErrors, err)
+			if keep == 0 {
+				keep = i + 1
+			}
+		}
+		completed := i
+		if keep > completed {
+			completed = keep
+		}
+		actions.setCompleted(completed)
+	}
+	actions.setCompleted(keep)
+	return rollbackErrors
+}
+
+//setCompleted updates the number of completed actions and reports it to the checkpoint function
+func (actions *Actions) setCompleted(completed int) {
+	if completed < 0 {
+		completed = 0
+	}
+	if completed > len(actions.Actions) {
+		completed = len(actions.Actions)
+	}
+	actions.completed = completed
+	if actions.Checkpoint != nil {
+		actions.Checkpoint(completed)
+	}
+}
+
+func (actions *Actions) name(index int) string {
+	if actions.Actions[index].Name != "" {
+		return actions.Actions[index].Name
+	}
+	return fmt.Sprintf("step %d", index+1)
+}
+
+//run executes a function of the action with the retry policy and the timeout of the action and reports its result
+func (actions *Actions) run(ctx context.Context, index int, reverse bool, retry *RetryPolicy, function func(ctx context.Context) error) error {
+	name := actions.name(index)
+	timeout := actions.Actions[index].Timeout
+
+	stepCtx, cancel := context.WithCancel(ctx)
+	if timeout > 0 {
+		stepCtx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	defer cancel()
+
+	start := time.Now()
+	attempts, err := retry.Do(stepCtx, function)
+	if err != nil && stepCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+		err = fmt.Errorf("%s did not finish within %s: %s", name, timeout, err)
+	}
+	if actions.Reporter != nil {
+		actions.Reporter(StepResult{Name: name, Reverse: reverse, Duration: time.Since(start), Attempts: attempts, Err: err})
+	}
+	return err
+}
+
+//Do calls the function until it succeeds, fails with an error that is not retryable, all attempts are used or the
+//context is done. A nil policy calls the function once
+func (policy *RetryPolicy) Do(ctx context.Context, function func(ctx context.Context) error) (int, error) {
+	attempt := 1
+	err := function(ctx)
+	if policy == nil {
+		return attempt, err
+	}
+
+	wait := policy.Backoff
+	for err != nil && attempt < policy.MaxAttempts && ctx.Err() == nil {
+		if policy.Retryable != nil && !policy.Retryable(err) {
+			break
+		}
+		if policy.OnRetry != nil {
+			policy.OnRetry(attempt, err, wait)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return attempt, err
+		}
+
+		attempt++
+		err = function(ctx)
+		wait *= 2
+		if policy.MaxBackoff > 0 && wait > policy.MaxBackoff {
+			wait = policy.MaxBackoff
+		}
+	}
+	return attempt, err
 }
@@ -1,7 +1,9 @@
 package rewind_test
 
 import (
+	"context"
 	"errors"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -17,13 +19,13 @@ var _ = Describe("Rewind", func() {
 		actions := rewind.Actions{
 			Actions: []rewind.Action{
 				{
-					Forward: func() error {
+					Forward: func(ctx context.Context) error {
 						firstRun = true
 						return nil
 					},
 				},
 				{
-					Forward: func() error {
+					Forward: func(ctx context.Context) error {
 						secondRun = true
 						return nil
 					},
@@ -31,7 +33,7 @@ var _ = Describe("Rewind", func() {
 			},
 		}
 
-		err := actions.Execute()
+		err := actions.Execute(context.Background())
 		Expect(err).ToNot(HaveOccurred())
 
 		Expect(firstRun).To(BeTrue())
@@ -47,23 +49,23 @@ var _ = Describe("Rewind", func() {
 		actions := rewind.Actions{
 			Actions: []rewind.Action{
 				{
-					Forward: func() error {
+					Forward: func(ctx context.Context) error {
 						firstRun = true
 						return nil
 					},
 				},
 				{
-					Forward: func() error {
+					Forward: func(ctx context.Context) error {
 						secondRun = true
 						return errors.New("disaster")
 					},
-					ReversePrevious: func() error {
+					ReversePrevious: func(ctx context.Context) error {
 						secondReverseRun = true
 						return nil
 					},
 				},
 				{
-					Forward: func() error {
+					Forward: func(ctx context.Context) error {
 						thirdRun = true
 						return nil
 					},
@@ -71,7 +73,7 @@ var _ = Describe("Rewind", func() {
 			},
 		}
 
-		err := actions.Execute()
+		err := actions.Execute(context.Background())
 		Expect(err).To(MatchError("disaster"))
 
 		Expect(firstRun).To(BeTrue())
@@ -80,7 +82,7 @@ var _ = Describe("Rewind", func() {
 		Expect(thirdRun).To(BeFalse())
 	})
 
-	It("gives up if the rewind action fails", func() {
+	It("returns the original and the rewind error if the rewind action fails", func() {
 		firstRun := false
 		secondRun := false
 		secondReverseRun := false
@@ -89,23 +91,23 @@ var _ = Describe("Rewind", func() {
 		actions := rewind.Actions{
 			Actions: []rewind.Action{
 				{
-					Forward: func() error {
+					Forward: func(ctx context.Context) error {
 						firstRun = true
 						return nil
 					},
 				},
 				{
-					Forward: func() error {
+					Forward: func(ctx context.Context) error {
 						secondRun = true
 						return errors.New("disaster")
 					},
-					ReversePrevious: func() error {
+					ReversePrevious: func(ctx context.Context) error {
 						secondReverseRun = true
 						return errors.New("another disaster")
 					},
 				},
 				{
-					Forward: func() error {
+					Forward: func(ctx context.Context) error {
 						thirdRun = true
 						return nil
 					},
@@ -114,8 +116,8 @@ var _ = Describe("Rewind", func() {
 			RewindFailureMessage: "uh oh",
 		}
 
-		err := actions.Execute()
-		Expect(err).To(MatchError("uh oh: another disaster"))
+		err := actions.Execute(context.Background())
+		Expect(err).To(MatchError("uh oh: disaster - rollback errors: another disaster"))
 
 		Expect(firstRun).To(BeTrue())
 		Expect(secondRun).To(BeTrue())
@@ -123,7 +125,7 @@ var _ = Describe("Rewind", func() {
 		Expect(thirdRun).To(BeFalse())
 	})
 
-	It("just returns the error if a rewind fails with no reverse message", func() {
+	It("returns the errors without message if a rewind fails with no reverse message", func() {
 		firstRun := false
 		secondRun := false
 		secondReverseRun := false
@@ -132,23 +134,23 @@ var _ = Describe("Rewind", func() {
 		actions := rewind.Actions{
 			Actions: []rewind.Action{
 				{
-					Forward: func() error {
+					Forward: func(ctx context.Context) error {
 						firstRun = true
 						return nil
 					},
 				},
 				{
-					Forward: func() error {
+					Forward: func(ctx context.Context) error {
 						secondRun = true
 						return errors.New("disaster")
 					},
-					ReversePrevious: func() error {
+					ReversePrevious: func(ctx context.Context) error {
 						secondReverseRun = true
 						return errors.New("another disaster")
 					},
 				},
 				{
-					Forward: func() error {
+					Forward: func(ctx context.Context) error {
 						thirdRun = true
 						return nil
 					},
@@ -156,12 +158,414 @@ var _ = Describe("Rewind", func() {
 			},
 		}
 
-		err := actions.Execute()
-		Expect(err).To(MatchError("another disaster"))
+		err := actions.Execute(context.Background())
+		Expect(err).To(MatchError("disaster - rollback errors: another disaster"))
+		rollbackError, ok := err.(*rewind.RollbackError)
+		Expect(ok).To(BeTrue())
+		Expect(rollbackError.Err).To(MatchError("disaster"))
+		Expect(rollbackError.RollbackErrors).To(HaveLen(1))
 
 		Expect(firstRun).To(BeTrue())
 		Expect(secondRun).To(BeTrue())
 		Expect(secondReverseRun).To(BeTrue())
 		Expect(thirdRun).To(BeFalse())
 	})
+	It("reports the result of every executed action", func() {
+		var results []rewind.StepResult
+
+		actions := rewind.Actions{
+			Actions: []rewind.Action{
+				{
+					Name: "first",
+					Forward: func(ctx context.Context) error {
+						return nil
+					},
+				},
+				{
+					Forward: func(ctx context.Context) error {
+						return errors.New("disaster")
+					},
+					ReversePrevious: func(ctx context.Context) error {
+						return nil
+					},
+				},
+			},
+			Reporter: func(result rewind.StepResult) {
+				results = append(results, result)
+			},
+		}
+
+		err := actions.Execute(context.Background())
+		Expect(err).To(MatchError("disaster"))
+
+		Expect(results).To(HaveLen(3))
+		Expect(results[0].Name).To(Equal("first"))
+		Expect(results[0].Err).ToNot(HaveOccurred())
+		Expect(results[1].Name).To(Equal("step 2"))
+		Expect(results[1].Reverse).To(BeFalse())
+		Expect(results[1].Err).To(MatchError("disaster"))
+		Expect(results[2].Name).To(Equal("step 2"))
+		Expect(results[2].Reverse).To(BeTrue())
+		Expect(results[2].Err).ToNot(HaveOccurred())
+	})
+	It("reverses all completed actions in reverse order", func() {
+		var calls []string
+
+		actions := rewind.Actions{
+			Actions: []rewind.Action{
+				{
+					Forward: func(ctx context.Context) error {
+						calls = append(calls, "first")
+						return nil
+					},
+					Reverse: func(ctx context.Context) error {
+						calls = append(calls, "reverse first")
+						return nil
+					},
+				},
+				{
+					Forward: func(ctx context.Context) error {
+						calls = append(calls, "second")
+						return nil
+					},
+					Reverse: func(ctx context.Context) error {
+						calls = append(calls, "reverse second")
+						return nil
+					},
+				},
+				{
+					Forward: func(ctx context.Context) error {
+						calls = append(calls, "third")
+						return errors.New("disaster")
+					},
+					ReversePrevious: func(ctx context.Context) error {
+						calls = append(calls, "reverse previous third")
+						return nil
+					},
+					Reverse: func(ctx context.Context) error {
+						calls = append(calls, "reverse third")
+						return nil
+					},
+				},
+			},
+		}
+
+		err := actions.Execute(context.Background())
+		Expect(err).To(MatchError("disaster"))
+		Expect(calls).To(Equal([]string{"first", "second", "third", "reverse previous third", "reverse second", "reverse first"}))
+	})
+
+	It("reverses all completed actions even if one reverse fails", func() {
+		firstReverseRun := false
+
+		actions := rewind.Actions{
+			Actions: []rewind.Action{
+				{
+					Forward: func(ctx context.Context) error {
+						return nil
+					},
+					Reverse: func(ctx context.Context) error {
+						firstReverseRun = true
+						return errors.New("first rollback failed")
+					},
+				},
+				{
+					Forward: func(ctx context.Context) error {
+						return nil
+					},
+					Reverse: func(ctx context.Context) error {
+						return errors.New("second rollback failed")
+					},
+				},
+				{
+					Forward: func(ctx context.Context) error {
+						return errors.New("disaster")
+					},
+				},
+			},
+		}
+
+		err := actions.Execute(context.Background())
+		Expect(err).To(MatchError("disaster - rollback errors: second rollback failed; first rollback failed"))
+		Expect(firstReverseRun).To(BeTrue())
+	})
+
+	It("keeps the actions from the first failed reverse on completed so a later rollback retries them", func() {
+		var checkpoints []int
+
+		actions := rewind.Actions{
+			Actions: []rewind.Action{
+				{
+					Forward: func(ctx context.Context) error {
+						return nil
+					},
+					Reverse: func(ctx context.Context) error {
+						return nil
+					},
+				},
+				{
+					Forward: func(ctx context.Context) error {
+						return nil
+					},
+					Reverse: func(ctx context.Context) error {
+						return errors.New("second rollback failed")
+					},
+				},
+				{
+					Forward: func(ctx context.Context) error {
+						return nil
+					},
+					Reverse: func(ctx context.Context) error {
+						return nil
+					},
+				},
+				{
+					Forward: func(ctx context.Context) error {
+						return errors.New("disaster")
+					},
+				},
+			},
+			Checkpoint: func(completed int) {
+				checkpoints = append(checkpoints, completed)
+			},
+		}
+
+		Expect(actions.Execute(context.Background())).To(HaveOccurred())
+		Expect(checkpoints).To(Equal([]int{1, 2, 3, 2, 2, 2, 2}))
+	})
+
+	It("rolls back the completed actions of a successful execution", func() {
+		var calls []string
+
+		actions := rewind.Actions{
+			Actions: []rewind.Action{
+				{
+					Forward: func(ctx context.Context) error {
+						return nil
+					},
+					Reverse: func(ctx context.Context) error {
+						calls = append(calls, "reverse first")
+						return nil
+					},
+				},
+				{
+					Forward: func(ctx context.Context) error {
+						return nil
+					},
+					Reverse: func(ctx context.Context) error {
+						calls = append(calls, "reverse second")
+						return nil
+					},
+				},
+			},
+		}
+
+		Expect(actions.Execute(context.Background())).To(Succeed())
+		Expect(actions.Rollback()).To(Succeed())
+		Expect(calls).To(Equal([]string{"reverse second", "reverse first"}))
+
+		Expect(actions.Rollback()).To(Succeed())
+		Expect(calls).To(HaveLen(2))
+	})
+
+	It("resumes an interrupted execution after the completed actions", func() {
+		var calls []string
+		var checkpoints []int
+
+		actions := rewind.Actions{
+			Actions: []rewind.Action{
+				{
+					Forward: func(ctx context.Context) error {
+						calls = append(calls, "first")
+						return nil
+					},
+				},
+				{
+					Forward: func(ctx context.Context) error {
+						calls = append(calls, "second")
+						return nil
+					},
+					ReversePrevious: func(ctx context.Context) error {
+						calls = append(calls, "reverse previous second")
+						return nil
+					},
+					Cleanup: func(ctx context.Context) error {
+						calls = append(calls, "cleanup second")
+						return errors.New("nothing to clean up")
+					},
+				},
+				{
+					Forward: func(ctx context.Context) error {
+						calls = append(calls, "third")
+						return nil
+					},
+				},
+			},
+			Checkpoint: func(completed int) {
+				checkpoints = append(checkpoints, completed)
+			},
+		}
+
+		Expect(actions.Resume(context.Background(), 1)).To(Succeed())
+		Expect(calls).To(Equal([]string{"cleanup second", "second", "third"}))
+		Expect(checkpoints).To(Equal([]int{1, 2, 3}))
+	})
+
+	It("rolls back an interrupted execution", func() {
+		var calls []string
+
+		actions := rewind.Actions{
+			Actions: []rewind.Action{
+				{
+					Forward: func(ctx context.Context) error {
+						return nil
+					},
+					Reverse: func(ctx context.Context) error {
+						calls = append(calls, "reverse first")
+						return nil
+					},
+				},
+				{
+					Forward: func(ctx context.Context) error {
+						return nil
+					},
+					ReversePrevious: func(ctx context.Context) error {
+						calls = append(calls, "cleanup second")
+						return nil
+					},
+					Reverse: func(ctx context.Context) error {
+						calls = append(calls, "reverse second")
+						return nil
+					},
+				},
+			},
+		}
+
+		Expect(actions.RollbackFrom(1)).To(Succeed())
+		Expect(calls).To(Equal([]string{"cleanup second", "reverse first"}))
+	})
+
+	It("retries the forward function of an action with a retry policy", func() {
+		calls := 0
+		var retries []time.Duration
+		var results []rewind.StepResult
+
+		actions := rewind.Actions{
+			Actions: []rewind.Action{
+				{
+					Forward: func(ctx context.Context) error {
+						calls++
+						if calls < 3 {
+							return errors.New("502 bad gateway")
+						}
+						return nil
+					},
+					Retry: &rewind.RetryPolicy{
+						MaxAttempts: 5,
+						Backoff:     time.Millisecond,
+						OnRetry: func(attempt int, err error, wait time.Duration) {
+							retries = append(retries, wait)
+						},
+					},
+				},
+			},
+			Reporter: func(result rewind.StepResult) {
+				results = append(results, result)
+			},
+		}
+
+		Expect(actions.Execute(context.Background())).To(Succeed())
+		Expect(calls).To(Equal(3))
+		Expect(retries).To(Equal([]time.Duration{time.Millisecond, 2 * time.Millisecond}))
+		Expect(results).To(HaveLen(1))
+		Expect(results[0].Attempts).To(Equal(3))
+	})
+
+	It("stops retrying after the max attempts or an error that is not retryable", func() {
+		calls := 0
+		policy := &rewind.RetryPolicy{
+			MaxAttempts: 3,
+			Backoff:     time.Millisecond,
+			Retryable: func(err error) bool {
+				return err.Error() == "503 service unavailable"
+			},
+		}
+
+		attempts, err := policy.Do(context.Background(), func(ctx context.Context) error {
+			calls++
+			return errors.New("503 service unavailable")
+		})
+		Expect(err).To(MatchError("503 service unavailable"))
+		Expect(attempts).To(Equal(3))
+
+		attempts, err = policy.Do(context.Background(), func(ctx context.Context) error {
+			calls++
+			return errors.New("disaster")
+		})
+		Expect(err).To(MatchError("disaster"))
+		Expect(attempts).To(Equal(1))
+		Expect(calls).To(Equal(4))
+	})
+	It("fails a step that exceeds its timeout and reverses the completed actions", func() {
+		reversed := false
+
+		actions := rewind.Actions{
+			Actions: []rewind.Action{
+				{
+					Name: "first",
+					Forward: func(ctx context.Context) error {
+						return nil
+					},
+					Reverse: func(ctx context.Context) error {
+						reversed = true
+						return nil
+					},
+				},
+				{
+					Name:    "hanging",
+					Timeout: 10 * time.Millisecond,
+					Forward: func(ctx context.Context) error {
+						<-ctx.Done()
+						return ctx.Err()
+					},
+				},
+			},
+		}
+
+		err := actions.Execute(context.Background())
+		Expect(err).To(MatchError(ContainSubstring("hanging did not finish within 10ms")))
+		Expect(reversed).To(BeTrue())
+	})
+
+	It("starts no further action after the context was canceled and reverses the completed ones", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		secondRun := false
+		reversed := false
+
+		actions := rewind.Actions{
+			Actions: []rewind.Action{
+				{
+					Forward: func(ctx context.Context) error {
+						cancel()
+						return nil
+					},
+					Reverse: func(ctx context.Context) error {
+						reversed = ctx.Err() == nil
+						return nil
+					},
+				},
+				{
+					Forward: func(ctx context.Context) error {
+						secondRun = true
+						return nil
+					},
+				},
+			},
+		}
+
+		err := actions.Execute(ctx)
+		Expect(err).To(Equal(context.Canceled))
+		Expect(secondRun).To(BeFalse())
+		Expect(reversed).To(BeTrue())
+	})
 })
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/happytobi/cf-puppeteer/arguments"
+	"github.com/happytobi/cf-puppeteer/cf"
+	"github.com/happytobi/cf-puppeteer/cf/ccv3"
+	"github.com/happytobi/cf-puppeteer/rewind"
+	"github.com/happytobi/cf-puppeteer/ui"
+
+	v2 "github.com/happytobi/cf-puppeteer/cf/v2"
+)
+
+//ErrNoVenerableApp error when there is no venerable application to roll back to
+var ErrNoVenerableApp = errors.New("no venerable application found, it was deleted by the venerable action of the deployment")
+
+func failedAppName(appName string) string {
+	return fmt.Sprintf("%s-failed", appName)
+}
+
+//rollbackSourceName returns the name of the application that is put back in place, the venerable application or a kept release
+func rollbackSourceName(rollbackArguments *arguments.RollbackArguments) string {
+	if rollbackArguments.Release > 0 {
+		return releaseAppName(rollbackArguments.AppName, rollbackArguments.Release)
+	}
+	return venerableAppName(rollbackArguments.AppName)
+}
+
+//getRollbackActions puts the venerable application or a kept release back in place of the current one, the current
+//application takes over its name and will be deleted or stopped afterwards
+func getRollbackActions(appRepo *ApplicationRepo, rollbackArguments *arguments.RollbackArguments) []rewind.Action {
+	appName := rollbackArguments.AppName
+	venName := rollbackSourceName(rollbackArguments)
+	failedName := failedAppName(appName)
+	legacyPush := rollbackArguments.LegacyPush
+	puppeteerPush := cf.NewApplicationPush(appRepo.conn, appRepo.traceLogging)
+	routes := rollbackArguments.Routes
+	retry := newRetryPolicy(ui.Prefixed{})
+	var curApp, venApp *v2.AppResourcesEntity
+
+	//renamed tracks the renames of the swap so that a partial swap can be reversed
+	var renamed struct {
+		failed, venerable, swapped bool
+	}
+	renameBack := func(ctx context.Context) error {
+		if renamed.swapped {
+			err := appRepo.v2Resources.RenameApplication(ctx, venName, failedName)
+			if err != nil {
+				return err
+			}
+			renamed.swapped = false
+		}
+		if renamed.venerable {
+			err := appRepo.v2Resources.RenameApplication(ctx, appName, venName)
+			if err != nil {
+				return err
+			}
+			renamed.venerable = false
+		}
+		if renamed.failed {
+			err := appRepo.v2Resources.RenameApplication(ctx, failedName, appName)
+			if err != nil {
+				return err
+			}
+			renamed.failed = false
+		}
+		return nil
+	}
+
+	return []rewind.Action{
+		// the current and the venerable app have to exist
+		{
+			Name:    "get applications",
+			Retry:   retry,
+			Timeout: stepTimeout,
+			Forward: func(ctx context.Context) error {
+				var err error
+				curApp, err = appRepo.v2Resources.GetAppMetadata(ctx, appName)
+				if err != nil {
+					return fmt.Errorf("could not find application %s - error: %s", appName, err)
+				}
+				venApp, err = appRepo.v2Resources.GetAppMetadata(ctx, venName)
+				if err == v2.ErrAppNotFound && rollbackArguments.Release > 0 {
+					return fmt.Errorf("release %s not found", venName)
+				}
+				if err == v2.ErrAppNotFound {
+					return ErrNoVenerableApp
+				}
+				if err != nil {
+					return err
+				}
+				if len(routes) > 0 {
+					return nil
+				}
+
+				//without a manifest the venerable app gets the routes of the current app
+				appRoutes, err := appRepo.v3Client.GetAppRoutes(ctx, curApp.Metadata.GUID)
+				if err != nil {
+					return err
+				}
+				for _, route := range appRoutes {
+					routes = append(routes, map[string]string{"route": route.URL})
+				}
+				return nil
+			},
+		},
+		// start the venerable app or release if it was stopped
+		{
+			Name:  "start previous application",
+			Retry: retry,
+			Forward: func(ctx context.Context) error {
+				if venApp.Entity.State == "STARTED" {
+					return nil
+				}
+				return appRepo.v2Resources.StartApplication(ctx, venName)
+			},
+			Reverse: func(ctx context.Context) error {
+				if venApp.Entity.State == "STARTED" {
+					return nil
+				}
+				return appRepo.v2Resources.StopApplication(ctx, venName)
+			},
+		},
+		// map the routes to the venerable app, both apps get the traffic for a moment
+		{
+			Name:    "map routes to previous application",
+			Retry:   retry,
+			Timeout: stepTimeout,
+			Forward: func(ctx context.Context) error {
+				ui.Say("map routes to application %s", venName)
+				return puppeteerPush.MapRoutes(ctx, venName, routes, legacyPush)
+			},
+			Reverse: func(ctx context.Context) error {
+				return puppeteerPush.UnMapRoutes(ctx, venName, routes, legacyPush)
+			},
+		},
+		// remove the routes from the failed app
+		{
+			Name:    "unmap routes from current application",
+			Retry:   retry,
+			Timeout: stepTimeout,
+			Forward: func(ctx context.Context) error {
+				ui.Say("remove routes from application %s", appName)
+				return puppeteerPush.UnMapRoutes(ctx, appName, routes, legacyPush)
+			},
+			Reverse: func(ctx context.Context) error {
+				return puppeteerPush.MapRoutes(ctx, appName, routes, legacyPush)
+			},
+		},
+		// swap the names, the failed app becomes the venerable one
+		{
+			Name: "rename applications",
+			Forward: func(ctx context.Context) error {
+				err := appRepo.v2Resources.RenameApplication(ctx, appName, failedName)
+				if err != nil {
+					return err
+				}
+				renamed.failed = true
+				err = appRepo.v2Resources.RenameApplication(ctx, venName, appName)
+				if err != nil {
+					return err
+				}
+				renamed.venerable = true
+				err = appRepo.v2Resources.RenameApplication(ctx, failedName, venName)
+				if err != nil {
+					return err
+				}
+				renamed.swapped = true
+				return nil
+			},
+			ReversePrevious: renameBack,
+			Reverse:         renameBack,
+		},
+		// the failed app took over the name of the release, it gets its label so it is kept and pruned like a release
+		{
+			Name:    "label failed release",
+			Retry:   retry,
+			Timeout: stepTimeout,
+			Forward: func(ctx context.Context) error {
+				if rollbackArguments.Release == 0 {
+					return nil
+				}
+				metadata := ccv3.Metadata{Labels: map[string]string{releaseLabel: strconv.Itoa(rollbackArguments.Release)}}
+				return appRepo.v3Client.UpdateAppMetadata(ctx, curApp.Metadata.GUID, metadata)
+			},
+		},
+		// delete or stop the failed app
+		{
+			Name:    "failed action",
+			Retry:   retry,
+			Timeout: stepTimeout,
+			Forward: func(ctx context.Context) error {
+				if rollbackArguments.FailedAction == "stop" {
+					ui.Say("stop failed application %s", venName)
+					return appRepo.v2Resources.StopApplication(ctx, venName)
+				}
+				ui.Say("delete failed application %s", venName)
+				return appRepo.v2Resources.DeleteApplication(ctx, venName)
+			},
+		},
+	}
+}
+
+//rollbackApplication restores the venerable application of a deployment that used the venerable action stop or none,
+//a release kept by --keep-releases or the droplet kept by --keep-droplet
+func rollbackApplication(appRepo *ApplicationRepo, rollbackArguments *arguments.RollbackArguments) error {
+	actions := &rewind.Actions{
+		RewindFailureMessage: "Oh no. The rollback failed and I've tried to undo it but you should check to see if everything is OK.",
+	}
+	if rollbackArguments.Droplet {
+		ui.Say("roll back application %s to its previous droplet", rollbackArguments.AppName)
+		actions.Actions = getDropletRollbackActions(appRepo, rollbackArguments)
+	} else {
+		ui.Say("roll back application %s to %s", rollbackArguments.AppName, rollbackSourceName(rollbackArguments))
+		actions.Actions = getRollbackActions(appRepo, rollbackArguments)
+	}
+	return actions.Execute(context.Background())
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	"code.cloudfoundry.org/cli/plugin/pluginfakes"
+	"github.com/happytobi/cf-puppeteer/arguments"
+	"github.com/happytobi/cf-puppeteer/cf/ccv3"
+	"github.com/happytobi/cf-puppeteer/cf/cli"
+	"github.com/happytobi/cf-puppeteer/rewind"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	v2 "github.com/happytobi/cf-puppeteer/cf/v2"
+)
+
+//fakeRollbackResources records the changes of the applications
+type fakeRollbackResources struct {
+	fakeAppResources
+	calls []string
+}
+
+func (resources *fakeRollbackResources) RenameApplication(ctx context.Context, oldName string, newName string) error {
+	resources.calls = append(resources.calls, fmt.Sprintf("rename %s to %s", oldName, newName))
+	return nil
+}
+
+func (resources *fakeRollbackResources) StartApplication(ctx context.Context, appName string) error {
+	resources.calls = append(resources.calls, "start "+appName)
+	return nil
+}
+
+func (resources *fakeRollbackResources) StopApplication(ctx context.Context, appName string) error {
+	resources.calls = append(resources.calls, "stop "+appName)
+	return nil
+}
+
+func (resources *fakeRollbackResources) DeleteApplication(ctx context.Context, appName string) error {
+	resources.calls = append(resources.calls, "delete "+appName)
+	return nil
+}
+
+var _ = Describe("rollback", func() {
+	var (
+		server    *httptest.Server
+		appRepo   *ApplicationRepo
+		resources *fakeRollbackResources
+		metadata  []string
+	)
+
+	BeforeEach(func() {
+		metadata = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.Method == http.MethodGet && r.URL.Path == "/v3/apps/cur-guid/routes":
+				_, _ = w.Write([]byte(`{"resources": []}`))
+			case r.Method == http.MethodPatch && r.URL.Path == "/v3/apps/cur-guid":
+				body, _ := ioutil.ReadAll(r.Body)
+				metadata = append(metadata, string(body))
+				_, _ = w.Write([]byte(`{}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		cliConn := &pluginfakes.FakeCliConnection{}
+		cliConn.ApiEndpointReturns(server.URL, nil)
+		cliConn.AccessTokenReturns("bearer token", nil)
+		resources = &fakeRollbackResources{fakeAppResources: fakeAppResources{apps: map[string]*v2.AppResourcesEntity{
+			"my-app": {Metadata: v2.Metadata{GUID: "cur-guid"}, Entity: v2.Entity{State: "STARTED"}},
+		}}}
+		appRepo = &ApplicationRepo{conn: cliConn, v2Resources: resources, v3Client: ccv3.NewClient(cli.NewHttpClient(cliConn, false, 30, false))}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	rollback := func(rollbackArguments *arguments.RollbackArguments) error {
+		actions := &rewind.Actions{Actions: getRollbackActions(appRepo, rollbackArguments)}
+		return actions.Execute(context.Background())
+	}
+
+	It("keeps the failed application as the release it was rolled back to", func() {
+		resources.apps["my-app-v2"] = &v2.AppResourcesEntity{Metadata: v2.Metadata{GUID: "release-guid"}, Entity: v2.Entity{State: "STOPPED"}}
+
+		Expect(rollback(&arguments.RollbackArguments{AppName: "my-app", Release: 2, FailedAction: "stop"})).To(Succeed())
+		Expect(resources.calls).To(Equal([]string{
+			"start my-app-v2",
+			"rename my-app to my-app-failed",
+			"rename my-app-v2 to my-app",
+			"rename my-app-failed to my-app-v2",
+			"stop my-app-v2",
+		}))
+		Expect(metadata).To(HaveLen(1))
+		Expect(metadata[0]).To(MatchJSON(`{"metadata": {"labels": {"cf-puppeteer/release": "2"}}}`))
+	})
+
+	It("does not label the failed application when it is rolled back to the venerable application", func() {
+		resources.apps["my-app-venerable"] = &v2.AppResourcesEntity{Metadata: v2.Metadata{GUID: "ven-guid"}, Entity: v2.Entity{State: "STARTED"}}
+
+		Expect(rollback(&arguments.RollbackArguments{AppName: "my-app", FailedAction: "delete"})).To(Succeed())
+		Expect(resources.calls).To(Equal([]string{
+			"rename my-app to my-app-failed",
+			"rename my-app-venerable to my-app",
+			"rename my-app-failed to my-app-venerable",
+			"delete my-app-venerable",
+		}))
+		Expect(metadata).To(BeEmpty())
+	})
+})
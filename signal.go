@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/happytobi/cf-puppeteer/ui"
+)
+
+//trapSignals cancels the deployment on SIGINT or SIGTERM so the running step stops and all completed steps will be
+//rolled back, a second signal exits at once and keeps the journal for --rollback.
+//The returned function stops trapping the signals and returns the received signal or nil
+func trapSignals(cancel context.CancelFunc, journalPath string) func() os.Signal {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	stopHandler := handleSignals(signals, cancel, journalPath)
+	return func() os.Signal {
+		signal.Stop(signals)
+		return stopHandler()
+	}
+}
+
+//handleSignals cancels the deployment on the first signal of the channel and exits on the second one,
+//the returned function stops the handling and returns the first signal or nil
+func handleSignals(signals <-chan os.Signal, cancel context.CancelFunc, journalPath string) func() os.Signal {
+	received := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case sig := <-signals:
+			ui.Warn("received %s, stop the deployment and roll back the completed steps", sig)
+			received <- sig
+			cancel()
+		case <-done:
+			return
+		}
+
+		select {
+		case sig := <-signals:
+			ui.Failed("received %s again, exit without finishing the rollback - run --rollback --journal %s to undo the remaining steps", sig, journalPath)
+			os.Exit(signalExitCode(sig))
+		case <-done:
+		}
+	}()
+
+	return func() os.Signal {
+		close(done)
+		select {
+		case sig := <-received:
+			return sig
+		default:
+			return nil
+		}
+	}
+}
+
+//signalExitCode exit code of a deployment that was interrupted by the signal, 128 + signal number like a shell
+func signalExitCode(sig os.Signal) int {
+	if number, ok := sig.(syscall.Signal); ok {
+		return 128 + int(number)
+	}
+	return 1
+}
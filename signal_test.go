@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"os"
+	"syscall"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+//otherSignal signal that is not a syscall.Signal
+type otherSignal struct{}
+
+func (otherSignal) String() string { return "other" }
+func (otherSignal) Signal()        {}
+
+var _ = Describe("signals", func() {
+	table.DescribeTable("exit code of an interrupted deployment",
+		func(sig os.Signal, expectedExitCode int) {
+			Expect(signalExitCode(sig)).To(Equal(expectedExitCode))
+		},
+		table.Entry("SIGINT", syscall.SIGINT, 130),
+		table.Entry("SIGTERM", syscall.SIGTERM, 143),
+		table.Entry("os.Interrupt", os.Interrupt, 130),
+		table.Entry("a signal without number", otherSignal{}, 1),
+	)
+
+	//the signals are sent to the channel, ginkgo stops the suite on a real SIGINT or SIGTERM
+	It("cancels the deployment on SIGTERM and returns the signal", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		signals := make(chan os.Signal, 2)
+		stopHandler := handleSignals(signals, cancel, "journal.json")
+
+		signals <- syscall.SIGTERM
+		Eventually(ctx.Done()).Should(BeClosed())
+		Expect(stopHandler()).To(Equal(syscall.SIGTERM))
+	})
+
+	It("returns no signal if the deployment was not interrupted", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		stopTrap := trapSignals(cancel, "journal.json")
+
+		Expect(stopTrap()).To(BeNil())
+		Expect(ctx.Err()).ToNot(HaveOccurred())
+	})
+})
@@ -0,0 +1,146 @@
+package smoketest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/happytobi/cf-puppeteer/ui"
+)
+
+const (
+	//AppURLEnv environment variable that contains the url of the new application while the smoke test command runs
+	AppURLEnv = "PUPPETEER_APP_URL"
+	//AppNameEnv environment variable that contains the name of the new application while the smoke test command runs
+	AppNameEnv = "PUPPETEER_APP_NAME"
+)
+
+var (
+	//ErrNoSmokeTest error when the smoke test neither has an url nor a command
+	ErrNoSmokeTest = errors.New("smoke test needs an url or a command")
+)
+
+//SmokeTest checks the new application before the venerable application will be touched
+type SmokeTest struct {
+	URL            string
+	ExpectedStatus int
+	BodyRegex      string
+	Command        string
+	Timeout        time.Duration
+}
+
+//Enabled returns true if an url or a command was passed
+func (smokeTest SmokeTest) Enabled() bool {
+	return len(smokeTest.URL) > 0 || len(smokeTest.Command) > 0
+}
+
+//IsRelative returns true if the url is a path like /health that is called on the url of the new application
+func (smokeTest SmokeTest) IsRelative() bool {
+	return strings.HasPrefix(smokeTest.URL, "/")
+}
+
+//Run runs the http check and the command against the application, a relative url is called on appURL and appURL is
+//handed over to the command, the command is killed when the context is done. All output is printed with the output prefix
+func (smokeTest SmokeTest) Run(ctx context.Context, appName string, appURL string, output ui.Prefixed) error {
+	if !smokeTest.Enabled() {
+		return ErrNoSmokeTest
+	}
+
+	if len(smokeTest.URL) > 0 {
+		url := smokeTest.URL
+		if smokeTest.IsRelative() {
+			url = strings.TrimRight(appURL, "/") + smokeTest.URL
+		}
+		err := smokeTest.checkURL(ctx, url, output)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(smokeTest.Command) > 0 {
+		return smokeTest.runCommand(ctx, appName, appURL, output)
+	}
+	return nil
+}
+
+//checkURL calls the url and compares status code and body
+func (smokeTest SmokeTest) checkURL(ctx context.Context, url string, output ui.Prefixed) error {
+	output.Say("run smoke test against %s", url)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("smoke test url %s is not valid: %s", url, err)
+	}
+	client := &http.Client{Timeout: smokeTest.Timeout}
+	response, err := client.Do(request)
+	if err != nil {
+		return fmt.Errorf("smoke test call to %s failed: %s", url, err)
+	}
+	defer response.Body.Close()
+
+	expectedStatus := smokeTest.ExpectedStatus
+	if expectedStatus == 0 {
+		expectedStatus = http.StatusOK
+	}
+	if response.StatusCode != expectedStatus {
+		return fmt.Errorf("smoke test call to %s returned status code %d, expected %d", url, response.StatusCode, expectedStatus)
+	}
+
+	if len(smokeTest.BodyRegex) == 0 {
+		return nil
+	}
+
+	bodyRegex, err := regexp.Compile(smokeTest.BodyRegex)
+	if err != nil {
+		return fmt.Errorf("smoke test body regex %s is not valid: %s", smokeTest.BodyRegex, err)
+	}
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("could not read smoke test response from %s: %s", url, err)
+	}
+	if !bodyRegex.Match(body) {
+		return fmt.Errorf("smoke test response from %s does not match %s", url, smokeTest.BodyRegex)
+	}
+	return nil
+}
+
+//runCommand runs the command with the shell of the os, the app url is passed as environment variable
+func (smokeTest SmokeTest) runCommand(ctx context.Context, appName string, appURL string, output ui.Prefixed) error {
+	output.Say("run smoke test command %s", smokeTest.Command)
+	cmd := exec.CommandContext(ctx, "sh", "-c", smokeTest.Command)
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", smokeTest.Command)
+	}
+	cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%s", AppURLEnv, appURL), fmt.Sprintf("%s=%s", AppNameEnv, appName))
+	stdout := ui.NewPrefixWriter(output.Prefix, ui.Output())
+	stderr := ui.NewPrefixWriter(output.Prefix, os.Stderr)
+	defer stdout.Flush()
+	defer stderr.Flush()
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err := cmd.Start()
+	if err != nil {
+		return fmt.Errorf("could not start smoke test command %s: %s", smokeTest.Command, err)
+	}
+
+	if smokeTest.Timeout > 0 {
+		timer := time.AfterFunc(smokeTest.Timeout, func() {
+			_ = cmd.Process.Kill()
+		})
+		defer timer.Stop()
+	}
+
+	err = cmd.Wait()
+	if err != nil {
+		return fmt.Errorf("smoke test command %s failed: %s", smokeTest.Command, err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package smoketest_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/happytobi/cf-puppeteer/smoketest"
+	"github.com/happytobi/cf-puppeteer/ui"
+)
+
+func TestSmokeTest(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Smoke Test Suite")
+}
+
+var _ = Describe("Smoke test", func() {
+	var server *httptest.Server
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/health" {
+				fmt.Fprint(w, `{"status":"UP"}`)
+				return
+			}
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("is disabled without url and command", func() {
+		smokeTest := SmokeTest{}
+		Expect(smokeTest.Enabled()).To(BeFalse())
+		Expect(smokeTest.Run(context.Background(), "myApp", "", ui.Prefixed{})).To(MatchError(ErrNoSmokeTest))
+	})
+
+	It("passes when status and body match", func() {
+		smokeTest := SmokeTest{URL: server.URL + "/health", ExpectedStatus: 200, BodyRegex: `"status":\s*"UP"`, Timeout: time.Second}
+		Expect(smokeTest.Run(context.Background(), "myApp", "", ui.Prefixed{})).ToNot(HaveOccurred())
+	})
+
+	It("fails with an unexpected status code", func() {
+		smokeTest := SmokeTest{URL: server.URL + "/other", ExpectedStatus: 200, Timeout: time.Second}
+		Expect(smokeTest.Run(context.Background(), "myApp", "", ui.Prefixed{})).To(HaveOccurred())
+	})
+
+	It("fails when the body does not match", func() {
+		smokeTest := SmokeTest{URL: server.URL + "/health", BodyRegex: "DOWN", Timeout: time.Second}
+		Expect(smokeTest.Run(context.Background(), "myApp", "", ui.Prefixed{})).To(HaveOccurred())
+	})
+
+	It("calls a relative url on the url of the application", func() {
+		smokeTest := SmokeTest{URL: "/health", BodyRegex: "UP", Timeout: time.Second}
+		Expect(smokeTest.IsRelative()).To(BeTrue())
+		Expect(smokeTest.Run(context.Background(), "myApp", server.URL+"/", ui.Prefixed{})).ToNot(HaveOccurred())
+
+		smokeTest = SmokeTest{URL: "/other", Timeout: time.Second}
+		Expect(smokeTest.Run(context.Background(), "myApp", server.URL, ui.Prefixed{})).To(HaveOccurred())
+	})
+
+	It("passes the application url to the command", func() {
+		smokeTest := SmokeTest{Command: fmt.Sprintf(`test "$%s" = "https://myapp.test.com" && test "$%s" = "myApp"`, AppURLEnv, AppNameEnv), Timeout: time.Second}
+		Expect(smokeTest.Run(context.Background(), "myApp", "https://myapp.test.com", ui.Prefixed{})).ToNot(HaveOccurred())
+	})
+
+	It("fails when the command fails", func() {
+		smokeTest := SmokeTest{Command: "exit 1", Timeout: time.Second}
+		Expect(smokeTest.Run(context.Background(), "myApp", "https://myapp.test.com", ui.Prefixed{})).To(HaveOccurred())
+	})
+})
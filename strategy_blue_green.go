@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/happytobi/cf-puppeteer/manifest"
+	"github.com/happytobi/cf-puppeteer/rewind"
+
+	v2 "github.com/happytobi/cf-puppeteer/cf/v2"
+)
+
+func greenAppName(appName string) string {
+	return fmt.Sprintf("%s-green", appName)
+}
+
+//getBlueGreenActionsForApp pushes the new application as <app>-green with a temporary route only,
+//after it was verified the manifest routes will be switched and the applications renamed
+func getBlueGreenActionsForApp(deployment *applicationDeployment) []rewind.Action {
+	appRepo := deployment.appRepo
+	parsedArguments := deployment.parsedArguments
+	appName := parsedArguments.AppName
+	venName := deployment.venName
+	greenName := greenAppName(appName)
+	output := deployment.output
+	puppeteerPush := deployment.push()
+	retry := deployment.retryPolicy()
+	var err error
+
+	//the green application gets its own arguments and manifest without routes so the current application stays untouched
+	greenArguments := *parsedArguments
+	greenArguments.AppName = greenName
+
+	deleteGreenApp := func(ctx context.Context) error {
+		output.FailedMessage(fmt.Sprintf("error while deploying application %s... delete it", greenName))
+		return appRepo.v2Resources.DeleteApplication(ctx, greenName)
+	}
+
+	//renameBack gives the green and the current application the names they had before the rename
+	renameBack := func(ctx context.Context) error {
+		if deployment.state.GreenRenamed {
+			err := appRepo.v2Resources.RenameApplication(ctx, appName, greenName)
+			if err != nil {
+				return err
+			}
+			deployment.state.GreenRenamed = false
+		}
+		if deployment.state.CurrentRenamed {
+			err := appRepo.v2Resources.RenameApplication(ctx, venName, appName)
+			if err != nil {
+				return err
+			}
+			deployment.state.CurrentRenamed = false
+		}
+		return nil
+	}
+
+	//renameBackInterrupted renames the applications back, an interrupted deployment may have renamed the current app already
+	renameBackInterrupted := func(ctx context.Context) error {
+		if deployment.renamedBeforeInterruption(ctx) {
+			deployment.state.CurrentRenamed = true
+		}
+		return renameBack(ctx)
+	}
+
+	return []rewind.Action{
+		// get info about current app
+		{
+			Name:    "get current application",
+			Retry:   retry,
+			Timeout: stepTimeout,
+			Forward: func(ctx context.Context) error {
+				deployment.state.CurApp, err = appRepo.v2Resources.GetAppMetadata(ctx, appName)
+				if err != nil && err != v2.ErrAppNotFound {
+					return err
+				}
+				return nil
+			},
+		},
+		// get info about ven app
+		{
+			Name:    "get venerable application",
+			Retry:   retry,
+			Timeout: stepTimeout,
+			Forward: func(ctx context.Context) error {
+				deployment.state.VenApp, err = appRepo.v2Resources.GetAppMetadata(ctx, venName)
+				if err != nil && err != v2.ErrAppNotFound {
+					return err
+				}
+				return nil
+			},
+		},
+		// delete a green app that was left over by an earlier deployment
+		{
+			Name:    "delete left over green application",
+			Retry:   retry,
+			Timeout: stepTimeout,
+			Forward: func(ctx context.Context) error {
+				_, err := appRepo.v2Resources.GetAppMetadata(ctx, greenName)
+				if err == v2.ErrAppNotFound {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				output.Say("delete application %s left over by an earlier deployment", greenName)
+				return appRepo.v2Resources.DeleteApplication(ctx, greenName)
+			},
+		},
+		// push green app
+		{
+			Name: "push green application",
+			Forward: func(ctx context.Context) error {
+				greenApplication := parsedArguments.ApplicationManifest
+				greenApplication.Name = greenName
+				greenArguments.NoRouteManifestPath, err = manifest.GenerateApplicationNoRouteYml(greenApplication)
+				if err != nil {
+					return err
+				}
+
+				space, err := appRepo.conn.GetCurrentSpace()
+				if err != nil {
+					return err
+				}
+				return puppeteerPush.PushApplication(ctx, appName, deployment.state.CurApp != nil, space.Guid, &greenArguments)
+			},
+			ReversePrevious: deleteGreenApp,
+			Reverse:         deleteGreenApp,
+		},
+		// start green app
+		{
+			Name:  "start green application",
+			Retry: retry,
+			Forward: func(ctx context.Context) error {
+				return appRepo.v2Resources.StartApplication(ctx, greenName)
+			},
+			ReversePrevious: func(ctx context.Context) error {
+				if parsedArguments.ShowCrashLogs {
+					output.Say("show crash logs")
+					_ = appRepo.v2Resources.ShowCrashLogs(ctx, greenName)
+				}
+				return nil
+			},
+		},
+		// map temporary route <app>-green.<domain>
+		{
+			Name:    "map temporary route",
+			Retry:   retry,
+			Timeout: stepTimeout,
+			Forward: func(ctx context.Context) error {
+				deployment.state.TemporaryDomain = parsedArguments.TemporaryRouteDomain
+				if len(deployment.state.TemporaryDomain) == 0 {
+					if len(deployment.routes()) == 0 {
+						return fmt.Errorf("no route found in manifest for application %s, pass --temporary-route-domain", appName)
+					}
+					deployment.state.TemporaryDomain, err = puppeteerPush.ResolveDomain(ctx, deployment.routes()[0], parsedArguments.LegacyPush)
+					if err != nil {
+						return err
+					}
+				}
+				output.Say("map temporary route %s.%s to application %s", greenName, deployment.state.TemporaryDomain, greenName)
+				return puppeteerPush.MapRoute(ctx, greenName, greenName, deployment.state.TemporaryDomain, parsedArguments.LegacyPush)
+			},
+			Reverse: func(ctx context.Context) error {
+				//the route stays in the space, remove it from the green application before it will be deleted
+				_ = puppeteerPush.UnMapRoute(ctx, greenName, greenName, deployment.state.TemporaryDomain, parsedArguments.LegacyPush)
+				return nil
+			},
+		},
+		// verify green app is running and smoke test it on the temporary route
+		{
+			Name: "verify green application",
+			Forward: func(ctx context.Context) error {
+				greenApp, err := appRepo.v2Resources.GetAppMetadata(ctx, greenName)
+				if err != nil {
+					return err
+				}
+				if greenApp.Entity.State != "STARTED" {
+					return fmt.Errorf("application %s is not started, state is %s", greenName, greenApp.Entity.State)
+				}
+				output.InfoMessage(fmt.Sprintf("application %s is running on temporary route %s.%s", greenName, greenName, deployment.state.TemporaryDomain))
+				return deployment.smokeTest(ctx, greenName, fmt.Sprintf("https://%s.%s", greenName, deployment.state.TemporaryDomain))
+			},
+		},
+		// map manifest routes to green app and unmap them from the current app
+		{
+			Name:    "switch routes",
+			Retry:   retry,
+			Timeout: stepTimeout,
+			Forward: func(ctx context.Context) error {
+				err := puppeteerPush.SwitchRoutes(ctx, appName, deployment.state.CurApp != nil, greenName, deployment.routes(), parsedArguments.LegacyPush)
+				if err != nil {
+					return err
+				}
+				deployment.state.RoutesSwitched = true
+				return nil
+			},
+			Reverse: func(ctx context.Context) error {
+				if !deployment.state.RoutesSwitched {
+					return nil
+				}
+				deployment.state.RoutesSwitched = false
+				if deployment.state.CurApp == nil {
+					return puppeteerPush.UnMapRoutes(ctx, greenName, deployment.routes(), parsedArguments.LegacyPush)
+				}
+				return puppeteerPush.SwitchRoutes(ctx, greenName, true, appName, deployment.routes(), parsedArguments.LegacyPush)
+			},
+		},
+		// remove temporary route
+		{
+			Name: "unmap temporary route",
+			Forward: func(ctx context.Context) error {
+				err := puppeteerPush.UnMapRoute(ctx, greenName, greenName, deployment.state.TemporaryDomain, parsedArguments.LegacyPush)
+				if err != nil {
+					output.Warn("could not remove temporary route %s.%s from application %s", greenName, deployment.state.TemporaryDomain, greenName)
+				}
+				return nil
+			},
+		},
+		// rename current app to venerable and green app to current
+		{
+			Name: "rename applications",
+			Forward: func(ctx context.Context) error {
+				if deployment.state.CurApp != nil {
+					if deployment.state.VenApp != nil {
+						err := appRepo.v2Resources.DeleteApplication(ctx, venName)
+						if err != nil {
+							return err
+						}
+					}
+					err := appRepo.v2Resources.RenameApplication(ctx, appName, venName)
+					if err != nil {
+						return err
+					}
+					deployment.state.CurrentRenamed = true
+				}
+				err := appRepo.v2Resources.RenameApplication(ctx, greenName, appName)
+				if err != nil {
+					return err
+				}
+				deployment.state.GreenRenamed = true
+				return nil
+			},
+			ReversePrevious: func(ctx context.Context) error {
+				output.FailedMessage("error while renaming the applications... roll everything back")
+				return renameBackInterrupted(ctx)
+			},
+			Reverse: renameBack,
+			Cleanup: renameBackInterrupted,
+		},
+	}
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/happytobi/cf-puppeteer/cf/ccv3"
+	"github.com/happytobi/cf-puppeteer/rewind"
+
+	v2 "github.com/happytobi/cf-puppeteer/cf/v2"
+)
+
+//canaryCheckInterval time between two health checks while waiting for the next canary step
+var canaryCheckInterval = 5 * time.Second
+
+//canaryInstances returns the number of instances the new application should have at the passed percentage
+func canaryInstances(totalInstances int, percentage int) int {
+	instances := int(math.Ceil(float64(totalInstances) * float64(percentage) / 100))
+	if instances < 1 {
+		return 1
+	}
+	return instances
+}
+
+//totalInstances returns the instances out of the manifest or of the current application
+func (deployment *applicationDeployment) totalInstances() int {
+	instances, err := strconv.Atoi(deployment.parsedArguments.ApplicationManifest.Instances)
+	if err == nil && instances > 0 {
+		return instances
+	}
+	if deployment.state.CurApp != nil && deployment.state.CurApp.Entity.Instances > 0 {
+		return deployment.state.CurApp.Entity.Instances
+	}
+	return 1
+}
+
+//waitForHealthyInstances waits the passed duration and fails as soon as an instance crashed,
+//at the end all expected instances have to be running
+func waitForHealthyInstances(ctx context.Context, resources v2.Resources, appName string, expectedInstances int, duration time.Duration) error {
+	deadline := time.Now().Add(duration)
+	for {
+		instances, err := resources.GetAppInstances(ctx, appName)
+		if err != nil {
+			return err
+		}
+
+		//a DOWN instance is not placed yet, only a crashed instance fails the step before the timeout
+		running := 0
+		for _, instance := range instances {
+			switch instance.State {
+			case "RUNNING":
+				running++
+			case "CRASHED":
+				return fmt.Errorf("instance of application %s is %s", appName, instance.State)
+			}
+		}
+
+		if time.Now().After(deadline) {
+			if running < expectedInstances {
+				return fmt.Errorf("only %d of %d instances of application %s are running", running, expectedInstances, appName)
+			}
+			return nil
+		}
+		err = ccv3.Wait(ctx, canaryCheckInterval)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+//getCanaryActionsForApp starts the new application with one instance on the shared routes, then ramps it up
+//step by step while the venerable application will be scaled down
+func getCanaryActionsForApp(deployment *applicationDeployment) []rewind.Action {
+	appRepo := deployment.appRepo
+	parsedArguments := deployment.parsedArguments
+	appName := parsedArguments.AppName
+	venName := deployment.venName
+	output := deployment.output
+	puppeteerPush := deployment.push()
+	retry := deployment.retryPolicy()
+	interval := time.Duration(parsedArguments.CanaryInterval) * time.Second
+	var err error
+
+	//failed reports the failed canary deployment, all completed steps will be reversed afterwards
+	failed := func(ctx context.Context) error {
+		output.FailedMessage(fmt.Sprintf("canary deployment of application %s failed... roll everything back", appName))
+		if parsedArguments.ShowCrashLogs {
+			output.Say("show crash logs")
+			_ = appRepo.v2Resources.ShowCrashLogs(ctx, appName)
+		}
+		return nil
+	}
+
+	//restoreVenerable scales the venerable application back to its original instances
+	restoreVenerable := func(ctx context.Context) error {
+		if deployment.state.CurApp == nil || deployment.state.VenerableInstances == 0 {
+			return nil
+		}
+		err := appRepo.v2Resources.ScaleApplication(ctx, venName, deployment.state.VenerableInstances)
+		if err != nil {
+			return err
+		}
+		deployment.state.VenerableInstances = 0
+		return nil
+	}
+
+	//mapVenerableRoutes gives the venerable application its routes back
+	mapVenerableRoutes := func(ctx context.Context) error {
+		if deployment.state.CurApp == nil {
+			return nil
+		}
+		return puppeteerPush.MapRoutes(ctx, venName, deployment.routes(), parsedArguments.LegacyPush)
+	}
+
+	deleteApp := func(ctx context.Context) error {
+		return appRepo.v2Resources.DeleteApplication(ctx, appName)
+	}
+
+	actions := []rewind.Action{
+		// get info about current app
+		{
+			Name:    "get current application",
+			Retry:   retry,
+			Timeout: stepTimeout,
+			Forward: func(ctx context.Context) error {
+				deployment.state.CurApp, err = appRepo.v2Resources.GetAppMetadata(ctx, appName)
+				if err != nil && err != v2.ErrAppNotFound {
+					return err
+				}
+				return nil
+			},
+		},
+		// get info about ven app
+		{
+			Name:    "get venerable application",
+			Retry:   retry,
+			Timeout: stepTimeout,
+			Forward: func(ctx context.Context) error {
+				deployment.state.VenApp, err = appRepo.v2Resources.GetAppMetadata(ctx, venName)
+				if err != nil && err != v2.ErrAppNotFound {
+					return err
+				}
+				return nil
+			},
+		},
+		// rename the current app, it keeps its routes and instances
+		{
+			Name: "rename current application",
+			Forward: func(ctx context.Context) error {
+				if deployment.state.CurApp == nil {
+					return nil
+				}
+				if deployment.state.VenApp != nil {
+					err := appRepo.v2Resources.DeleteApplication(ctx, venName)
+					if err != nil {
+						return err
+					}
+				}
+				return appRepo.v2Resources.RenameApplication(ctx, appName, venName)
+			},
+			//an interrupted deployment may have renamed the current app already
+			ReversePrevious: deployment.renameBackInterrupted,
+			Cleanup:         deployment.renameBackInterrupted,
+			Reverse: func(ctx context.Context) error {
+				if deployment.state.CurApp == nil {
+					return nil
+				}
+				return appRepo.v2Resources.RenameApplication(ctx, venName, appName)
+			},
+		},
+		// push
+		{
+			Name: "push application",
+			Forward: func(ctx context.Context) error {
+				space, err := appRepo.conn.GetCurrentSpace()
+				if err != nil {
+					return err
+				}
+				return puppeteerPush.PushApplication(ctx, venName, deployment.state.CurApp != nil, space.Guid, parsedArguments)
+			},
+			ReversePrevious: func(ctx context.Context) error {
+				_ = failed(ctx)
+				return deleteApp(ctx)
+			},
+			Reverse: deleteApp,
+		},
+		// start with one instance
+		{
+			Name:  "start application",
+			Retry: retry,
+			Forward: func(ctx context.Context) error {
+				if deployment.state.CurApp != nil {
+					err := appRepo.v2Resources.ScaleApplication(ctx, appName, 1)
+					if err != nil {
+						return err
+					}
+				}
+				return appRepo.v2Resources.StartApplication(ctx, appName)
+			},
+			ReversePrevious: failed,
+		},
+		// add the shared routes, the venerable app keeps them
+		{
+			Name:    "map routes",
+			Retry:   retry,
+			Timeout: stepTimeout,
+			Forward: func(ctx context.Context) error {
+				output.Say("map routes to application %s next to application %s", appName, venName)
+				return puppeteerPush.MapRoutes(ctx, appName, deployment.routes(), parsedArguments.LegacyPush)
+			},
+			ReversePrevious: failed,
+		},
+	}
+
+	for _, percentage := range parsedArguments.CanarySteps {
+		percentage := percentage
+		actions = append(actions, rewind.Action{
+			Name: fmt.Sprintf("canary step %d%%", percentage),
+			Forward: func(ctx context.Context) error {
+				//without a current app there is no traffic to share, the app was already started with all instances
+				if deployment.state.CurApp == nil {
+					return nil
+				}
+
+				totalInstances := deployment.totalInstances()
+				newInstances := canaryInstances(totalInstances, percentage)
+				output.Say("canary step %d%%: scale application %s to %d of %d instances", percentage, appName, newInstances, totalInstances)
+
+				err := appRepo.v2Resources.ScaleApplication(ctx, appName, newInstances)
+				if err != nil {
+					return err
+				}
+
+				err = waitForHealthyInstances(ctx, appRepo.v2Resources, appName, newInstances, interval)
+				if err != nil {
+					return err
+				}
+
+				if percentage == 100 {
+					return nil
+				}
+
+				//remember the original instances of the venerable app to restore them on a rollback
+				if deployment.state.VenerableInstances == 0 {
+					deployment.state.VenerableInstances = deployment.state.CurApp.Entity.Instances
+				}
+				venInstances := totalInstances - newInstances
+				if venInstances < 1 {
+					venInstances = 1
+				}
+				output.Say("scale application %s down to %d instances", venName, venInstances)
+				return appRepo.v2Resources.ScaleApplication(ctx, venName, venInstances)
+			},
+			ReversePrevious: func(ctx context.Context) error {
+				_ = failed(ctx)
+				return restoreVenerable(ctx)
+			},
+			Reverse: restoreVenerable,
+		})
+	}
+
+	// remove the shared routes from the venerable app
+	actions = append(actions, rewind.Action{
+		Name:    "unmap venerable routes",
+		Retry:   retry,
+		Timeout: stepTimeout,
+		Forward: func(ctx context.Context) error {
+			if deployment.state.CurApp == nil {
+				return nil
+			}
+			output.Say("remove routes from venerable application %s", venName)
+			return puppeteerPush.UnMapRoutes(ctx, venName, deployment.routes(), parsedArguments.LegacyPush)
+		},
+		ReversePrevious: func(ctx context.Context) error {
+			_ = failed(ctx)
+			return mapVenerableRoutes(ctx)
+		},
+		Reverse: mapVenerableRoutes,
+	})
+
+	// smoke test the new application before the venerable action deletes or stops the old one
+	actions = append(actions, rewind.Action{
+		Name: "smoke test",
+		Forward: func(ctx context.Context) error {
+			return deployment.smokeTest(ctx, appName, deployment.appURL())
+		},
+		ReversePrevious: failed,
+	})
+
+	return actions
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	v2 "github.com/happytobi/cf-puppeteer/cf/v2"
+
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+//fakeInstanceResources returns the next states of the instances on every call
+type fakeInstanceResources struct {
+	v2.Resources
+	states [][]string
+	calls  int
+}
+
+func (resources *fakeInstanceResources) GetAppInstances(ctx context.Context, appName string) ([]v2.InstanceStats, error) {
+	states := resources.states[resources.calls]
+	if resources.calls < len(resources.states)-1 {
+		resources.calls++
+	}
+	var instances []v2.InstanceStats
+	for _, state := range states {
+		instances = append(instances, v2.InstanceStats{State: state})
+	}
+	return instances, nil
+}
+
+var _ = Describe("canary", func() {
+	table.DescribeTable("instances of the new application at a canary step",
+		func(totalInstances int, percentage int, expectedInstances int) {
+			Expect(canaryInstances(totalInstances, percentage)).To(Equal(expectedInstances))
+		},
+		table.Entry("rounds up", 3, 50, 2),
+		table.Entry("starts with at least one instance", 10, 1, 1),
+		table.Entry("keeps one instance of a single instance app", 1, 10, 1),
+		table.Entry("scales to all instances at 100%", 4, 100, 4),
+		table.Entry("uses the exact share", 10, 30, 3),
+	)
+
+	Describe("waitForHealthyInstances", func() {
+		var checkInterval time.Duration
+
+		BeforeEach(func() {
+			checkInterval = canaryCheckInterval
+			canaryCheckInterval = time.Millisecond
+		})
+
+		AfterEach(func() {
+			canaryCheckInterval = checkInterval
+		})
+
+		table.DescribeTable("health of the instances at the end of a canary step",
+			func(states [][]string, expectedError string) {
+				resources := &fakeInstanceResources{states: states}
+				err := waitForHealthyInstances(context.Background(), resources, "my-app", 2, 20*time.Millisecond)
+				if expectedError == "" {
+					Expect(err).ToNot(HaveOccurred())
+					return
+				}
+				Expect(err).To(MatchError(expectedError))
+			},
+			table.Entry("all instances are running", [][]string{{"RUNNING", "RUNNING"}}, ""),
+			table.Entry("waits for instances that are down or starting", [][]string{{"DOWN", "STARTING"}, {"RUNNING", "DOWN"}, {"RUNNING", "RUNNING"}}, ""),
+			table.Entry("fails at once on a crashed instance", [][]string{{"RUNNING", "CRASHED"}}, "instance of application my-app is CRASHED"),
+			table.Entry("fails if an instance is still down after the timeout", [][]string{{"RUNNING", "DOWN"}}, "only 1 of 2 instances of application my-app are running"),
+		)
+	})
+})
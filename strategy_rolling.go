@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/happytobi/cf-puppeteer/rewind"
+
+	v2 "github.com/happytobi/cf-puppeteer/cf/v2"
+)
+
+//getRollingActionsForApp rolls out a new droplet of the existing application with a deployment of the cloud controller,
+//the application keeps its guid, bindings and metrics. A new application is pushed and started like the venerable strategy
+func getRollingActionsForApp(deployment *applicationDeployment) []rewind.Action {
+	appRepo := deployment.appRepo
+	parsedArguments := deployment.parsedArguments
+	appName := parsedArguments.AppName
+	output := deployment.output
+	puppeteerPush := deployment.push()
+	retry := deployment.retryPolicy()
+	var err error
+
+	//deleteNewApp removes the application if it was pushed by this deployment
+	deleteNewApp := func(ctx context.Context) error {
+		if deployment.state.CurApp != nil {
+			return nil
+		}
+		return appRepo.v2Resources.DeleteApplication(ctx, appName)
+	}
+
+	return []rewind.Action{
+		// get info about current app
+		{
+			Name:    "get current application",
+			Retry:   retry,
+			Timeout: stepTimeout,
+			Forward: func(ctx context.Context) error {
+				deployment.state.CurApp, err = appRepo.v2Resources.GetAppMetadata(ctx, appName)
+				if err != nil && err != v2.ErrAppNotFound {
+					return err
+				}
+				return nil
+			},
+		},
+		// push a new app or roll out a new droplet of the current app
+		{
+			Name: "push application",
+			Forward: func(ctx context.Context) error {
+				space, err := appRepo.conn.GetCurrentSpace()
+				if err != nil {
+					return err
+				}
+				if deployment.state.CurApp == nil {
+					return puppeteerPush.PushApplication(ctx, deployment.venName, false, space.Guid, parsedArguments)
+				}
+
+				droplet, err := appRepo.v3Client.GetCurrentDroplet(ctx, deployment.state.CurApp.Metadata.GUID)
+				if err != nil {
+					return err
+				}
+				deployment.state.PreviousDroplet = droplet.GUID
+				err = puppeteerPush.RollingPush(ctx, space.Guid, parsedArguments)
+				if err != nil {
+					return err
+				}
+				deployment.state.RolledOut = true
+				return nil
+			},
+			ReversePrevious: func(ctx context.Context) error {
+				output.FailedMessage(fmt.Sprintf("error while deploying application %s... roll everything back", appName))
+				return deleteNewApp(ctx)
+			},
+			Reverse: func(ctx context.Context) error {
+				if !deployment.state.RolledOut {
+					return deleteNewApp(ctx)
+				}
+				output.Say("roll out previous droplet %s to application %s", deployment.state.PreviousDroplet, appName)
+				appGUID := deployment.state.CurApp.Metadata.GUID
+				rollback, err := appRepo.v3Client.CreateDeployment(ctx, appGUID, deployment.state.PreviousDroplet)
+				if err != nil {
+					return err
+				}
+				_, err = appRepo.v3Client.WaitForDeployment(ctx, rollback.GUID, nil)
+				if err != nil {
+					return err
+				}
+				deployment.state.RolledOut = false
+				return nil
+			},
+		},
+		// start a new app, the deployment started the instances of the current app
+		{
+			Name:  "start application",
+			Retry: retry,
+			Forward: func(ctx context.Context) error {
+				if deployment.state.CurApp != nil {
+					return nil
+				}
+				return appRepo.v2Resources.StartApplication(ctx, appName)
+			},
+			ReversePrevious: func(ctx context.Context) error {
+				if parsedArguments.ShowCrashLogs {
+					output.Say("show crash logs")
+					_ = appRepo.v2Resources.ShowCrashLogs(ctx, appName)
+				}
+				return nil
+			},
+		},
+		// map the manifest routes, routes of the current app stay mapped
+		{
+			Name:    "map routes",
+			Retry:   retry,
+			Timeout: stepTimeout,
+			Forward: func(ctx context.Context) error {
+				return puppeteerPush.MapRoutes(ctx, appName, deployment.routes(), parsedArguments.LegacyPush)
+			},
+		},
+		// smoke test the rolled out application, a failure rolls the previous droplet out again
+		{
+			Name: "smoke test",
+			Forward: func(ctx context.Context) error {
+				return deployment.smokeTest(ctx, appName, deployment.appURL())
+			},
+			ReversePrevious: func(ctx context.Context) error {
+				output.FailedMessage("smoke test failed... roll the previous version out again")
+				return nil
+			},
+		},
+	}
+}
@@ -1,54 +1,94 @@
 package ui
 
 import (
+	"bytes"
 	"code.cloudfoundry.org/cli/cf/i18n"
 	"code.cloudfoundry.org/cli/cf/terminal"
 	"code.cloudfoundry.org/cli/cf/trace"
 	"fmt"
+	"io"
 	"os"
+	"sync"
 )
 
 var ui terminal.UI
 
+//output writer of all messages and of the cf cli commands
+var output io.Writer = os.Stdout
+
+//outputMutex serializes the output when applications are deployed in parallel
+var outputMutex sync.Mutex
+
 func init() {
 	i18n.T = func(translationID string, args ...interface{}) string {
 		return translationID
 	}
 
-	ui = terminal.NewUI(
+	ui = newUI(os.Stdout)
+}
+
+func newUI(writer io.Writer) terminal.UI {
+	return terminal.NewUI(
 		os.Stdin,
-		os.Stdout,
-		terminal.NewTeePrinter(os.Stdout),
-		trace.NewLogger(os.Stdout, false, "", ""))
+		writer,
+		terminal.NewTeePrinter(writer),
+		trace.NewLogger(writer, false, "", ""))
+}
+
+//SetOutput prints all messages to the writer, used to keep stdout free for a json deployment report
+func SetOutput(writer io.Writer) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	output = writer
+	ui = newUI(writer)
+}
+
+//Output returns the writer of all messages, the output of commands has to be written to it as well
+func Output() io.Writer {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	return output
 }
 
 //Say message see cf/terminal
 func Say(message string, args ...interface{}) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
 	ui.Say(message, args...)
 }
 
 //Ok message see cf/terminal
 func Ok() {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
 	ui.Ok()
 }
 
 //InfoMessage print out message in green / ok colored mode
 func InfoMessage(message string) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
 	ui.Say(terminal.SuccessColor(message))
 }
 
 //Failed message see cf/terminal
 func Failed(message string, args ...interface{}) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
 	ui.Failed(message, args...)
 }
 
 //FailedMessage print out message in error color without the "FAILED" message
 func FailedMessage(message string) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
 	ui.Say(terminal.FailureColor(message))
 }
 
 //Warn message see cf/terminal
 func Warn(message string, args ...interface{}) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
 	ui.Warn(message, args...)
 }
 
@@ -57,11 +97,93 @@ func DebugMessage(message string, args ...interface{}) {
 	if traceEnv == "true" || (traceEnv != "false" && len(traceEnv) > 0) {
 		//check env for CF_TRACE
 		message = fmt.Sprintf(message, args...)
+		outputMutex.Lock()
+		defer outputMutex.Unlock()
 		ui.Say(terminal.AdvisoryColor(message))
 	}
 }
 
 //LoadingIndication message see cf/terminal
 func LoadingIndication() {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
 	ui.LoadingIndication()
 }
+
+//Prefixed prints all messages with a prefix to keep the output of parallel deployments readable
+type Prefixed struct {
+	Prefix string
+}
+
+//Say message with prefix see cf/terminal
+func (prefixed Prefixed) Say(message string, args ...interface{}) {
+	Say("%s%s", prefixed.Prefix, fmt.Sprintf(message, args...))
+}
+
+//Warn message with prefix see cf/terminal
+func (prefixed Prefixed) Warn(message string, args ...interface{}) {
+	Warn("%s%s", prefixed.Prefix, fmt.Sprintf(message, args...))
+}
+
+//Ok message with prefix see cf/terminal
+func (prefixed Prefixed) Ok() {
+	Say("%s%s", prefixed.Prefix, terminal.SuccessColor("OK"))
+}
+
+//InfoMessage print out message with prefix in green / ok colored mode
+func (prefixed Prefixed) InfoMessage(message string) {
+	InfoMessage(prefixed.Prefix + message)
+}
+
+//FailedMessage print out message with prefix in error color without the "FAILED" message
+func (prefixed Prefixed) FailedMessage(message string) {
+	FailedMessage(prefixed.Prefix + message)
+}
+
+//PrefixWriter writes every line with a prefix to the underlying writer, used for the output of cf cli commands
+type PrefixWriter struct {
+	prefix string
+	writer io.Writer
+	buffer bytes.Buffer
+}
+
+//NewPrefixWriter constructor
+func NewPrefixWriter(prefix string, writer io.Writer) *PrefixWriter {
+	return &PrefixWriter{
+		prefix: prefix,
+		writer: writer,
+	}
+}
+
+//Write buffers the passed bytes and writes all complete lines with the prefix
+func (prefixWriter *PrefixWriter) Write(p []byte) (int, error) {
+	prefixWriter.buffer.Write(p)
+	for {
+		line, err := prefixWriter.buffer.ReadBytes('\n')
+		if err != nil {
+			//keep incomplete line until the rest was written
+			prefixWriter.buffer.Write(line)
+			return len(p), nil
+		}
+		if err := prefixWriter.writeLine(line); err != nil {
+			return len(p), err
+		}
+	}
+}
+
+//Flush writes the remaining incomplete line
+func (prefixWriter *PrefixWriter) Flush() error {
+	if prefixWriter.buffer.Len() == 0 {
+		return nil
+	}
+	line := append(append([]byte{}, prefixWriter.buffer.Bytes()...), '\n')
+	prefixWriter.buffer.Reset()
+	return prefixWriter.writeLine(line)
+}
+
+func (prefixWriter *PrefixWriter) writeLine(line []byte) error {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	_, err := fmt.Fprintf(prefixWriter.writer, "%s%s", prefixWriter.prefix, line)
+	return err
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/happytobi/cf-puppeteer/arguments"
+	"github.com/happytobi/cf-puppeteer/manifest"
+	"github.com/happytobi/cf-puppeteer/ui"
+)
+
+//validateManifest prints all problems of the manifest with their file and line, nothing is pushed
+func validateManifest(validateArguments *arguments.ValidateArguments) error {
+	variables, err := manifest.LoadVariables(validateArguments.VarsFiles, validateArguments.Vars)
+	if err != nil {
+		return err
+	}
+
+	ui.Say("validate manifest %s", validateArguments.ManifestPath)
+	problems := manifest.ValidateApplicationManifest(validateArguments.ManifestPath, validateArguments.OpsFiles, variables, validateArguments.LegacyPush)
+	if len(problems) == 0 {
+		ui.Say("manifest %s is valid", validateArguments.ManifestPath)
+		ui.Ok()
+		return nil
+	}
+
+	for _, problem := range problems {
+		ui.FailedMessage(problem.String())
+	}
+	return fmt.Errorf("manifest %s has %d problems", validateArguments.ManifestPath, len(problems))
+}